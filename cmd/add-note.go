@@ -3,56 +3,120 @@ package cmd
 import (
 	"context"
 	"errors"
-	"note-logger/internal/databases/sqlite"
-	"time"
+	"io"
+	"os"
 
+	"note-logger/internal/editor"
 	"note-logger/internal/entities"
-	"note-logger/internal/repositories/notes"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+	"note-logger/internal/tags"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var addNoteCommand = &cobra.Command{
-	Use:   "add-note",
+	Use:   "add-note [-]",
 	Short: "Add a new note",
+	Long: `Add a new note, with the content from -c, or read from standard input when given - or when something is piped
+in. Otherwise the note is written in $VISUAL or $EDITOR, and leaving it empty aborts.`,
+	Args: inputArgs(cobra.MaximumNArgs(1)),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
-		noteLine, err := cmd.Flags().GetString("content")
+		noteLine, err := noteContent(cmd, args)
 		if err != nil {
 			return err
 		}
 
 		if noteLine == "" {
-			err := errors.New("note content required")
+			err := noteerrors.InvalidInput(errors.New("note content required"))
 			return err
 		}
 
-		sqliteDB, err := sqlite.New(ctx)
+		tagFlags, err := cmd.Flags().GetStringSlice("tag")
 		if err != nil {
 			return err
 		}
 
-		notesRepo, err := notes.NewRepository(&notes.Config{DB: sqliteDB})
+		noteTags, err := tags.Normalize(append(tags.Extract(noteLine), tagFlags...))
+		if err != nil {
+			return err
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
 		if err != nil {
 			return err
 		}
 
 		note, err := notesRepo.Create(ctx, &entities.Note{
 			Content: noteLine,
+			Tags:    noteTags,
 		})
 		if err != nil {
 			return err
 		}
 
-		cmd.Printf("Note added:\n%v - %v: %v\n", note.ID, note.CreatedAt.Format(time.Stamp), note.Content)
-
-		return nil
+		return output.WriteOne(cmd.OutOrStdout(), format, note, func(value interface{}) string {
+			return "Note added:\n" + textNote(value)
+		})
 	},
 }
 
+// noteContent gets the note from the content flag, standard input or the user's editor, in that order
+func noteContent(cmd *cobra.Command, args []string) (string, error) {
+	readStdin := len(args) == 1
+
+	if readStdin && args[0] != "-" {
+		err := noteerrors.InvalidInputf("unexpected argument '%v', use - to read the note from standard input", args[0])
+		return "", err
+	}
+
+	if cmd.Flags().Changed("content") {
+		if readStdin {
+			err := noteerrors.InvalidInput(errors.New("the note can't come from both --content and standard input"))
+			return "", err
+		}
+
+		content, err := cmd.Flags().GetString("content")
+		if err != nil {
+			return "", err
+		}
+
+		return editor.Clean(content), nil
+	}
+
+	if readStdin || !stdinIsTerminal(cmd) {
+		content, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return "", err
+		}
+
+		return editor.Clean(string(content)), nil
+	}
+
+	return editor.Edit("")
+}
+
+// stdinIsTerminal reports whether the command's input comes straight from a terminal, rather than a pipe or a file
+func stdinIsTerminal(cmd *cobra.Command) bool {
+	file, ok := cmd.InOrStdin().(*os.File)
+	if !ok {
+		return false
+	}
+
+	return term.IsTerminal(int(file.Fd()))
+}
+
 func init() {
 	rootCommand.AddCommand(addNoteCommand)
 
-	addNoteCommand.Flags().StringP("content", "c", "", "The note contents to add.")
+	addNoteCommand.Flags().StringP("content", "c", "", "The note contents to add, instead of writing it in an editor.")
+	addNoteCommand.Flags().StringSliceP("tag", "t", nil, "Tags for the note, on top of any #hashtags in the contents.")
 }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+
+	"note-logger/internal/clock"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/repositories/notes"
+	"note-logger/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+// app is what the commands share while one of them runs, with the repository only opened once a command asks for it
+type app struct {
+	cmd   *cobra.Command
+	clock clock.Clock
+	repo  notes.Repository
+}
+
+// newClock makes the clock for every run, which the integration tests swap for a fake one
+var newClock = clock.NewClock
+
+// currentApp is the app of the command that's running
+var currentApp *app
+
+func newApp(cmd *cobra.Command) *app {
+	return &app{
+		cmd:   cmd,
+		clock: newClock(),
+	}
+}
+
+// notesRepo gives the notes repository of the store picked by storeDSN, opening it the first time
+func (a *app) notesRepo(ctx context.Context) (notes.Repository, error) {
+	if a.repo != nil {
+		return a.repo, nil
+	}
+
+	dsn, err := storeDSN(a.cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	a.repo, err = store.Open(ctx, dsn, a.clock)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.repo, nil
+}
+
+// close closes the repository if the command opened it, which for SQLite checkpoints the WAL into the DB file
+func (a *app) close() error {
+	if a == nil || a.repo == nil {
+		return nil
+	}
+
+	err := a.repo.Close()
+	a.repo = nil
+
+	return noteerrors.Storage(err)
+}
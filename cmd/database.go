@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/url"
+	"strings"
+
+	"note-logger/internal/config"
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+// openSQLiteDB opens the SQLite DB of the store picked by storeDSN, for the commands that work on the DB itself
+func openSQLiteDB(ctx context.Context, cmd *cobra.Command, migrate bool) (*sql.DB, error) {
+	cfg, err := sqliteConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.SkipMigrations = !migrate
+
+	db, err := sqlite.New(ctx, cfg)
+	if err != nil {
+		return nil, noteerrors.Storage(err)
+	}
+
+	return db, nil
+}
+
+// sqliteFilename gives the location of the SQLite DB, failing for any other store
+func sqliteFilename(cmd *cobra.Command) (string, error) {
+	cfg, err := sqliteConfig(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.Filename, nil
+}
+
+func sqliteConfig(cmd *cobra.Command) (*sqlite.Config, error) {
+	dsn, err := storeDSN(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := store.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	return store.SQLiteConfig(parsed)
+}
+
+// storeDSN works out where the notes are kept, with the config file's busy_timeout for a SQLite store without one
+func storeDSN(cmd *cobra.Command) (string, error) {
+	dsn, err := configuredStoreDSN(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(strings.ToLower(dsn), "sqlite://") || strings.Contains(dsn, "busy_timeout=") {
+		return dsn, nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.BusyTimeout == "" {
+		return dsn, nil
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + "busy_timeout=" + url.QueryEscape(cfg.BusyTimeout), nil
+}
+
+// configuredStoreDSN works out where the notes are kept from the flags, then the env vars, then the config file
+func configuredStoreDSN(cmd *cobra.Command) (string, error) {
+	storeFlag, err := cmd.Flags().GetString("store")
+	if err != nil {
+		return "", err
+	}
+
+	if storeFlag != "" {
+		return storeFlag, nil
+	}
+
+	dbFlag, err := cmd.Flags().GetString("db")
+	if err != nil {
+		return "", err
+	}
+
+	if dbFlag != "" {
+		filename, err := config.ExpandHome(dbFlag)
+		if err != nil {
+			return "", err
+		}
+
+		return store.SQLiteDSN(filename), nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+
+	if cfg.Store != "" {
+		return cfg.Store, nil
+	}
+
+	if cfg.DB != "" {
+		return store.SQLiteDSN(cfg.DB), nil
+	}
+
+	filename, err := sqlite.DefaultFilename()
+	if err != nil {
+		return "", err
+	}
+
+	moved, err := sqlite.MoveLegacyDB(filename)
+	if err != nil {
+		return "", err
+	}
+
+	if moved {
+		log.Printf("Moved existing DB from next to the binary to %v\n", filename)
+	}
+
+	return store.SQLiteDSN(filename), nil
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"note-logger/internal/config"
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/noteerrors"
+
+	"github.com/spf13/cobra"
+)
+
+var dbCommand = &cobra.Command{
+	Use:   "db",
+	Short: "Manages the SQLite DB itself",
+}
+
+var dbMigrateCommand = &cobra.Command{
+	Use:   "migrate",
+	Short: "Shows and runs the DB migrations",
+}
+
+var dbMigrateStatusCommand = &cobra.Command{
+	Use:   "status",
+	Short: "Lists every migration, and whether it has been applied",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		writer, err := newOutputWriter(cmd, func(value interface{}) string {
+			status := value.(*sqlite.MigrationStatus)
+
+			state := "pending"
+
+			switch {
+			case status.Changed:
+				state = "CHANGED since applied"
+			case status.AppliedAt != nil:
+				state = "applied " + status.AppliedAt.Local().Format(time.Stamp)
+			case status.Applied:
+				state = "applied"
+			}
+
+			return fmt.Sprintf("%v - %v: %v", status.Version, status.Name, state)
+		})
+		if err != nil {
+			return err
+		}
+
+		db, err := openSQLiteDB(ctx, cmd, false)
+		if err != nil {
+			return err
+		}
+
+		//nolint
+		defer db.Close()
+
+		statuses, err := sqlite.Statuses(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			err = writer.Write(status)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writer.Close()
+	},
+}
+
+var dbMigrateUpCommand = &cobra.Command{
+	Use:   "up",
+	Short: "Runs the migrations that haven't been applied yet, up to the latest or --to",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrate(cmd, true)
+	},
+}
+
+var dbMigrateDownCommand = &cobra.Command{
+	Use:   "down",
+	Short: "Rolls back the migrations after --to",
+	Long: "Rolls back the migrations after --to. Any other command migrates the DB back up again, so this is for " +
+		"going back to an older version of note-logger.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrate(cmd, false)
+	},
+}
+
+func runMigrate(cmd *cobra.Command, up bool) error {
+	ctx := context.Background()
+
+	target, err := cmd.Flags().GetInt("to")
+	if err != nil {
+		return err
+	}
+
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Flags().Changed("to") {
+		if !up {
+			err := noteerrors.InvalidInput(errors.New("--to is needed to say which version to roll back to"))
+			return err
+		}
+
+		target = sqlite.LatestVersion()
+	}
+
+	db, err := openSQLiteDB(ctx, cmd, false)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer db.Close()
+
+	current, err := sqlite.CurrentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if up && target < current {
+		return noteerrors.InvalidInputf("the DB is already at version %v, use down to roll back to %v", current, target)
+	}
+
+	if !up && target > current {
+		return noteerrors.InvalidInputf("the DB is only at version %v, use up to migrate to %v", current, target)
+	}
+
+	if target == current {
+		cmd.Printf("The DB is already at version %v.\n", current)
+
+		return nil
+	}
+
+	err = sqlite.Migrate(ctx, db, &sqlite.MigrateOptions{
+		Target: target,
+		DryRun: dryRun,
+		Out:    cmd.OutOrStdout(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if !dryRun {
+		cmd.Printf("Migrated the DB from version %v to %v.\n", current, target)
+	}
+
+	return nil
+}
+
+var dbBackupCommand = &cobra.Command{
+	Use:   "backup <path>",
+	Short: "Backs up the DB to a file, or to a timestamped file in a directory",
+	Long: "Backs up the DB to a file, or to a timestamped file when given a directory. The backup is consistent even " +
+		"if a note is being written at the same time, unlike copying the DB by hand.",
+	Args: inputArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		gzip, err := cmd.Flags().GetBool("gzip")
+		if err != nil {
+			return err
+		}
+
+		keep, err := cmd.Flags().GetInt("keep")
+		if err != nil {
+			return err
+		}
+
+		path, err := config.ExpandHome(args[0])
+		if err != nil {
+			return err
+		}
+
+		db, err := openSQLiteDB(ctx, cmd, true)
+		if err != nil {
+			return err
+		}
+
+		//nolint
+		defer db.Close()
+
+		written, err := sqlite.Backup(ctx, db, path, &sqlite.BackupOptions{
+			Gzip: gzip,
+			Keep: keep,
+			Time: currentApp.clock.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Backed up the DB to %v.\n", written)
+
+		return nil
+	},
+}
+
+var dbRestoreCommand = &cobra.Command{
+	Use:   "restore <path>",
+	Short: "Replaces the DB with a backup",
+	Long: "Replaces the DB with a backup, which can be gzipped. The backup gets checked before anything is replaced, " +
+		"so a bad one leaves the DB as it was.",
+	Args: inputArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		path, err := config.ExpandHome(args[0])
+		if err != nil {
+			return err
+		}
+
+		filename, err := sqliteFilename(cmd)
+		if err != nil {
+			return err
+		}
+
+		err = sqlite.Restore(ctx, path, filename)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Restored the DB from %v.\n", path)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(dbCommand)
+
+	dbCommand.AddCommand(dbMigrateCommand)
+	dbCommand.AddCommand(dbBackupCommand)
+	dbCommand.AddCommand(dbRestoreCommand)
+
+	dbMigrateCommand.AddCommand(dbMigrateStatusCommand)
+	dbMigrateCommand.AddCommand(dbMigrateUpCommand)
+	dbMigrateCommand.AddCommand(dbMigrateDownCommand)
+
+	for _, migrateCommand := range []*cobra.Command{dbMigrateUpCommand, dbMigrateDownCommand} {
+		migrateCommand.Flags().Int("to", 0, "The version to migrate to")
+		migrateCommand.Flags().Bool("dry-run", false, "Print the SQL that would run, without running it")
+	}
+
+	dbBackupCommand.Flags().Bool("gzip", false, "Compress the backup with gzip")
+	dbBackupCommand.Flags().Int("keep", 0, "When backing up to a directory, how many of the newest backups to keep")
+}
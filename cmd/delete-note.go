@@ -3,8 +3,8 @@ package cmd
 import (
 	"context"
 	"errors"
-	"note-logger/internal/databases/sqlite"
-	"note-logger/internal/repositories/notes"
+
+	"note-logger/internal/noteerrors"
 
 	"github.com/spf13/cobra"
 )
@@ -21,16 +21,11 @@ var deleteNoteCommand = &cobra.Command{
 		}
 
 		if noteID == 0 {
-			err := errors.New("note ID required")
-			return err
-		}
-
-		sqliteDB, err := sqlite.New(ctx)
-		if err != nil {
+			err := noteerrors.InvalidInput(errors.New("note ID required"))
 			return err
 		}
 
-		notesRepo, err := notes.NewRepository(&notes.Config{DB: sqliteDB})
+		notesRepo, err := currentApp.notesRepo(ctx)
 		if err != nil {
 			return err
 		}
@@ -40,7 +35,7 @@ var deleteNoteCommand = &cobra.Command{
 			return err
 		}
 
-		cmd.Println("Note deleted.")
+		cmd.Printf("Note deleted. It can be restored with: note-logger trash restore -i %v\n", noteID)
 
 		return nil
 	},
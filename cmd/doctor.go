@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/noteerrors"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorCommand = &cobra.Command{
+	Use:   "doctor",
+	Short: "Checks the SQLite DB over for problems, and fixes the ones that are safe to with --fix",
+	Long: "Checks the SQLite DB over for problems: its integrity, rows referring to notes that are gone, pending " +
+		"migrations, timestamps that can't be read, the search index, and how much space is going to waste. With " +
+		"--fix, the safe repairs get made too, and the indexes get rebuilt.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		fix, err := cmd.Flags().GetBool("fix")
+		if err != nil {
+			return err
+		}
+
+		writer, err := newOutputWriter(cmd, func(value interface{}) string {
+			finding := value.(*sqlite.Finding)
+
+			var b strings.Builder
+
+			fmt.Fprintf(&b, "[%v] %v: %v", finding.Status, finding.Check, finding.Message)
+
+			for _, detail := range finding.Details {
+				b.WriteString("\n    " + detail)
+			}
+
+			return b.String()
+		})
+		if err != nil {
+			return err
+		}
+
+		// opened as it is, as the doctor looks at problems with the migrations too
+		db, err := openSQLiteDB(ctx, cmd, false)
+		if err != nil {
+			return err
+		}
+
+		//nolint
+		defer db.Close()
+
+		findings, err := sqlite.Doctor(ctx, db, &sqlite.DoctorOptions{Fix: fix})
+		if err != nil {
+			return err
+		}
+
+		problems := 0
+
+		for _, finding := range findings {
+			if finding.Status == sqlite.StatusProblem {
+				problems++
+			}
+
+			err = writer.Write(finding)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = writer.Close()
+		if err != nil {
+			return err
+		}
+
+		if problems > 0 {
+			return noteerrors.Storage(fmt.Errorf("found %v problems with the DB", problems))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(doctorCommand)
+
+	doctorCommand.Flags().Bool("fix", false, "Make the repairs that are safe to make, and rebuild the indexes")
+}
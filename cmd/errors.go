@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/spf13/cobra"
+)
+
+// The exit codes note-logger can finish with, as listed in the README
+const (
+	ExitOK           int = 0
+	ExitError        int = 1
+	ExitInvalidInput int = 2
+	ExitNotFound     int = 3
+	ExitConflict     int = 4
+	ExitStorage      int = 5
+)
+
+// errorKinds are the names and exit codes of the kinds of error, for the JSON error object
+var errorKinds = map[error]struct {
+	name     string
+	exitCode int
+}{
+	noteerrors.ErrInvalidInput: {name: "invalid_input", exitCode: ExitInvalidInput},
+	noteerrors.ErrNotFound:     {name: "not_found", exitCode: ExitNotFound},
+	noteerrors.ErrConflict:     {name: "conflict", exitCode: ExitConflict},
+	noteerrors.ErrStorage:      {name: "storage", exitCode: ExitStorage},
+}
+
+// errorResponse is what gets written for an error when the output format is a structured one
+type errorResponse struct {
+	Error    string `json:"error"`
+	Kind     string `json:"kind"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// errorKind gives the kind of err, treating anything from a DB driver that hasn't been given a kind as a storage error
+func errorKind(err error) error {
+	kind := noteerrors.KindOf(err)
+	if kind != nil {
+		return kind
+	}
+
+	var sqliteErr sqlite3.Error
+	var pqErr *pq.Error
+
+	if errors.As(err, &sqliteErr) || errors.As(err, &pqErr) {
+		return noteerrors.ErrStorage
+	}
+
+	return nil
+}
+
+// exitCode gives the exit code for err, with ExitError for an error that isn't one of the kinds
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	kind, ok := errorKinds[errorKind(err)]
+	if !ok {
+		return ExitError
+	}
+
+	return kind.exitCode
+}
+
+// writeError writes err out the way cobra would, or as a JSON object when the output format is a structured one
+func writeError(w io.Writer, format string, err error) {
+	if format == output.Text || format == "" || strings.HasPrefix(format, output.Template+"=") {
+		fmt.Fprintln(w, "Error:", err.Error())
+		return
+	}
+
+	response := &errorResponse{
+		Error:    err.Error(),
+		Kind:     "error",
+		ExitCode: ExitError,
+	}
+
+	if kind, ok := errorKinds[errorKind(err)]; ok {
+		response.Kind = kind.name
+		response.ExitCode = kind.exitCode
+	}
+
+	//nolint
+	json.NewEncoder(w).Encode(response)
+}
+
+// inputArgs makes the error from checking a command's arguments an invalid input one
+func inputArgs(args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, argValues []string) error {
+		return noteerrors.InvalidInput(args(cmd, argValues))
+	}
+}
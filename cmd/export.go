@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/export"
+	"note-logger/internal/repositories/notes"
+
+	"github.com/spf13/cobra"
+)
+
+var exportCommand = &cobra.Command{
+	Use:   "export",
+	Short: "Exports notes as JSON, CSV, Markdown or HTML",
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := context.Background()
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+
+		filename, err := cmd.Flags().GetString("file")
+		if err != nil {
+			return err
+		}
+
+		beginningTimeString, err := cmd.Flags().GetString("start")
+		if err != nil {
+			return err
+		}
+
+		endTimeString, err := cmd.Flags().GetString("end")
+		if err != nil {
+			return err
+		}
+
+		tagFlags, err := cmd.Flags().GetStringSlice("tag")
+		if err != nil {
+			return err
+		}
+
+		allTags, err := cmd.Flags().GetBool("all-tags")
+		if err != nil {
+			return err
+		}
+
+		beginningTime, err := parseOptionalTime(beginningTimeString)
+		if err != nil {
+			return err
+		}
+
+		endTime, err := parseOptionalTime(endTimeString)
+		if err != nil {
+			return err
+		}
+
+		if endTime.IsZero() {
+			endTime = notes.MaxTime
+		}
+
+		// check the format before creating the file
+		_, err = export.NewWriter(io.Discard, format)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+
+		if filename != "" {
+			file, err := os.Create(filename)
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				closeErr := file.Close()
+				if err == nil {
+					err = closeErr
+				}
+			}()
+
+			out = file
+		}
+
+		buffered := bufio.NewWriter(out)
+
+		writer, err := export.NewWriter(buffered, format)
+		if err != nil {
+			return err
+		}
+
+		err = notesRepo.ForEach(ctx, beginningTime, endTime, &notes.TagFilter{
+			Tags:     tagFlags,
+			MatchAll: allTags,
+		}, func(note *entities.Note) error {
+			return writer.Write(note)
+		})
+		if err != nil {
+			return err
+		}
+
+		err = writer.Close()
+		if err != nil {
+			return err
+		}
+
+		return buffered.Flush()
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(exportCommand)
+
+	exportCommand.Flags().StringP("format", "f", export.JSON, "Export format, one of: "+strings.Join(export.Formats, ", "))
+	exportCommand.Flags().String("file", "", "File to export to, instead of printing the export")
+	exportCommand.Flags().StringP("start", "s", "", "Start of the time window (optional)")
+	exportCommand.Flags().StringP("end", "e", "", "End of the time window (optional)")
+	exportCommand.Flags().StringSliceP("tag", "t", nil, "Only export notes with any of these tags")
+	exportCommand.Flags().Bool("all-tags", false, "Only export notes with all of the given tags")
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+	"note-logger/internal/tags"
+
+	"github.com/spf13/cobra"
+)
+
+// timeLayout is how times are shown in the plain text output
+const timeLayout string = "2006-01-02 15:04:05 MST"
+
+// formatNote formats a note as a single line, adding any tags that aren't already in the content as #hashtags
+func formatNote(note *entities.Note) string {
+	return fmt.Sprintf("%v - %v: %v", note.ID, formatTime(note.CreatedAt), tags.AppendMissing(note.Content, note.Tags))
+}
+
+func textNote(value interface{}) string {
+	return formatNote(value.(*entities.Note))
+}
+
+// outputFormat gives the format from the --output flag, after checking that it's supported
+func outputFormat(cmd *cobra.Command) (string, error) {
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+
+	err = output.Validate(format)
+	if err != nil {
+		return "", noteerrors.InvalidInput(err)
+	}
+
+	return format, nil
+}
+
+// newOutputWriter creates a writer for the format from the --output flag, with text for the plain text format
+func newOutputWriter(cmd *cobra.Command, text output.TextFunc) (output.Writer, error) {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.NewWriter(cmd.OutOrStdout(), format, text)
+}
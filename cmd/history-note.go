@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"note-logger/internal/diff"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+
+	"github.com/spf13/cobra"
+)
+
+var historyNoteCommand = &cobra.Command{
+	Use:   "history-note",
+	Short: "Shows every revision of a note, or the differences between two of them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		noteID, err := cmd.Flags().GetInt64("id")
+		if err != nil {
+			return err
+		}
+
+		if noteID == 0 {
+			err := noteerrors.InvalidInput(errors.New("note ID required"))
+			return err
+		}
+
+		diffRevisions, err := cmd.Flags().GetIntSlice("diff")
+		if err != nil {
+			return err
+		}
+
+		if len(diffRevisions) != 0 && len(diffRevisions) != 2 {
+			err := noteerrors.InvalidInput(errors.New("two revision numbers required to diff, e.g. --diff 1,3"))
+			return err
+		}
+
+		writer, err := newOutputWriter(cmd, func(value interface{}) string {
+			revision := value.(*entities.Revision)
+
+			return fmt.Sprintf("%v - %v: %v", revisionLabel(revision), formatTime(revision.CreatedAt), revision.Content)
+		})
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		revisions, err := notesRepo.ListRevisions(ctx, noteID)
+		if err != nil {
+			return err
+		}
+
+		if len(diffRevisions) == 0 {
+			for _, revision := range revisions {
+				err = writer.Write(revision)
+				if err != nil {
+					return err
+				}
+			}
+
+			return writer.Close()
+		}
+
+		from, err := findRevision(revisions, diffRevisions[0])
+		if err != nil {
+			return err
+		}
+
+		to, err := findRevision(revisions, diffRevisions[1])
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("%v -> %v:\n", revisionLabel(from), revisionLabel(to))
+
+		// notes are mostly a single line, where a word diff is a lot easier to read than a line diff
+		if strings.Contains(from.Content, "\n") || strings.Contains(to.Content, "\n") {
+			cmd.Print(diff.Lines(from.Content, to.Content))
+		} else {
+			cmd.Println(diff.Words(from.Content, to.Content))
+		}
+
+		return nil
+	},
+}
+
+func revisionLabel(revision *entities.Revision) string {
+	if revision.Current {
+		return fmt.Sprintf("Revision %v (current)", revision.Number)
+	}
+
+	return fmt.Sprintf("Revision %v", revision.Number)
+}
+
+func findRevision(revisions []*entities.Revision, number int) (*entities.Revision, error) {
+	if number < 1 || number > len(revisions) {
+		err := fmt.Errorf("revision %v does not exist, the note has %v revisions", number, len(revisions))
+		return nil, noteerrors.NotFound(err)
+	}
+
+	return revisions[number-1], nil
+}
+
+func init() {
+	rootCommand.AddCommand(historyNoteCommand)
+
+	historyNoteCommand.Flags().Int64P("id", "i", 0, "The ID of the note.")
+	historyNoteCommand.Flags().IntSliceP("diff", "d", nil, "Two revision numbers to show the differences between, e.g. 1,3")
+}
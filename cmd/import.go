@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"note-logger/internal/importer"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/tags"
+
+	"github.com/spf13/cobra"
+)
+
+var importCommand = &cobra.Command{
+	Use:   "import <file|->",
+	Short: "Imports notes from a JSON, JSONL, CSV or Markdown export",
+	Long: `Imports notes from a JSON, JSONL, CSV or Markdown export, keeping their original timestamps.
+
+Notes that are already in the database, with the same content and tags and created within the same second, are
+skipped, so importing the same file twice doesn't duplicate anything. Use - to read the notes from standard input, in
+which case the format has to be given with --format.`,
+	Args: inputArgs(cobra.ExactArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := context.Background()
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+
+		filename := args[0]
+
+		if format == "" {
+			if filename == "-" {
+				err := noteerrors.InvalidInput(errors.New("--format is needed when importing from standard input"))
+				return err
+			}
+
+			format, err = importer.FormatFromFilename(filename)
+			if err != nil {
+				return err
+			}
+		}
+
+		var in io.Reader = cmd.InOrStdin()
+
+		if filename != "-" {
+			file, err := os.Open(filename)
+			if err != nil {
+				return err
+			}
+
+			//nolint
+			defer file.Close()
+
+			in = file
+		}
+
+		importNotes, err := importer.Read(in, format)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		result, err := notesRepo.Import(ctx, importNotes, dryRun)
+		if err != nil {
+			return err
+		}
+
+		if !dryRun {
+			cmd.Printf("Imported %v notes, skipped %v duplicates.\n", len(result.Imported), len(result.Duplicates))
+
+			return nil
+		}
+
+		cmd.Printf("Would import %v notes, skipping %v duplicates.\n", len(result.Imported), len(result.Duplicates))
+
+		for _, note := range result.Imported {
+			cmd.Printf("%v: %v\n", formatTime(note.CreatedAt), tags.AppendMissing(note.Content, note.Tags))
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(importCommand)
+
+	importCommand.Flags().StringP("format", "f", "",
+		"Import format, one of: "+strings.Join(importer.Formats, ", ")+" (guessed from the file extension if not set)")
+	importCommand.Flags().Bool("dry-run", false, "Show what would be imported, without changing anything")
+}
@@ -2,47 +2,119 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"note-logger/internal/clock"
+	"note-logger/internal/config"
 	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/entities"
 
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestMain(m *testing.M) {
-	// for integration testing, let's start with a clean DB
-	filename, err := sqlite.DBFilename()
+	// for integration testing, let's start with a clean DB, and keep away from any real config or data
+	tempDir, err := os.MkdirTemp("", "note-logger-test")
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	os.Remove(filename)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data"))
+	os.Setenv(config.DBEnvVar, filepath.Join(tempDir, "notes.sqlite"))
 
 	exitVal := m.Run()
 
+	os.RemoveAll(tempDir)
+
 	os.Exit(exitVal)
 }
 
+// resetFlags puts every flag back to its default, since cobra keeps flag values around between executions
+func resetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok {
+			//nolint
+			sliceValue.Replace(nil)
+		} else {
+			//nolint
+			flag.Value.Set(flag.DefValue)
+		}
+
+		flag.Changed = false
+	})
+
+	for _, subCommand := range cmd.Commands() {
+		resetFlags(subCommand)
+	}
+}
+
+// useClock runs the commands under c until the test finishes
+func useClock(t *testing.T, c clock.Clock) {
+	t.Cleanup(func() {
+		newClock = clock.NewClock
+	})
+
+	newClock = func() clock.Clock {
+		return c
+	}
+}
+
 func runCommand(args []string) (string, error) {
+	return runCommandWithInput(args, "")
+}
+
+// runCommandWithInput runs the command with the input piped in to it
+func runCommandWithInput(args []string, input string) (string, error) {
+	resetFlags(rootCommand)
+
 	output := new(bytes.Buffer)
 
+	rootCommand.SetIn(strings.NewReader(input))
 	rootCommand.SetOut(output)
 	rootCommand.SetErr(output)
 
 	rootCommand.SetArgs(args)
 	err := rootCommand.Execute()
 
+	closeErr := currentApp.close()
+	if err == nil {
+		err = closeErr
+	}
+
 	return output.String(), err
 }
 
-var noteDetailsRegex = regexp.MustCompile(`^(\d*) - [\w ]*:\d*:\d*: (.*)`)
+// executeCommand runs the command the way main does, giving what was written to standard error and the exit code
+func executeCommand(args []string) (string, int) {
+	resetFlags(rootCommand)
+
+	stderr := new(bytes.Buffer)
+
+	rootCommand.SetIn(strings.NewReader(""))
+	rootCommand.SetOut(new(bytes.Buffer))
+	rootCommand.SetErr(stderr)
+
+	rootCommand.SetArgs(args)
+	code := Execute()
+
+	return stderr.String(), code
+}
+
+var noteDetailsRegex = regexp.MustCompile(`^(\d*) - \d{4}-\d\d-\d\d \d\d:\d\d:\d\d [\w+-]+: (.*)`)
 var noteDeletedRegex = regexp.MustCompile(`Note deleted.`)
 
 func getNoteDetails(output string) ([]int, []string) {
@@ -73,7 +145,43 @@ func getNoteDetails(output string) ([]int, []string) {
 func TestIntegration(t *testing.T) {
 	t.Run("error adding note without content", func(t *testing.T) {
 		_, err := runCommand([]string{"add-note"})
-		assert.Equal(t, errors.New("note content required"), err)
+		assert.EqualError(t, err, "note content required")
+	})
+
+	t.Run("adds notes read from standard input, then cleans up", func(t *testing.T) {
+		actual, err := runCommandWithInput([]string{"add-note", "-", "-o", "json"},
+			"\n  Multi-line note #idea  \r\n\nsecond line\n\n")
+		require.NoError(t, err)
+
+		addedNote := &entities.Note{}
+		require.NoError(t, json.Unmarshal([]byte(actual), addedNote))
+		assert.Equal(t, "  Multi-line note #idea\n\nsecond line", addedNote.Content)
+		assert.Equal(t, []string{"idea"}, addedNote.Tags)
+
+		_, err = runCommand([]string{"delete-note", "-i", strconv.FormatInt(addedNote.ID, 10)})
+		assert.NoError(t, err)
+
+		actual, err = runCommandWithInput([]string{"add-note", "-t", "piped"}, "Piped note\n")
+		require.NoError(t, err)
+
+		noteIDs, noteContents := getNoteDetails(actual)
+		require.Equal(t, 1, len(noteIDs))
+		assert.Equal(t, "Piped note #piped", noteContents[0])
+
+		_, err = runCommand([]string{"delete-note", "-i", strconv.Itoa(noteIDs[0])})
+		assert.NoError(t, err)
+
+		_, err = runCommand([]string{"trash", "purge", "--older-than", "now"})
+		assert.NoError(t, err)
+
+		_, err = runCommandWithInput([]string{"add-note", "-"}, " \n\n")
+		assert.EqualError(t, err, "note content required")
+
+		_, err = runCommandWithInput([]string{"add-note", "-c", "note", "-"}, "note")
+		assert.EqualError(t, err, "the note can't come from both --content and standard input")
+
+		_, err = runCommand([]string{"add-note", "note"})
+		assert.EqualError(t, err, "unexpected argument 'note', use - to read the note from standard input")
 	})
 
 	t.Run("add and then delete a note", func(t *testing.T) {
@@ -91,6 +199,51 @@ func TestIntegration(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Regexp(t, noteDeletedRegex, actual)
+
+		// deleting only moves the note to the trash, so purge it to start the next test from an empty DB
+		actual, err = runCommand([]string{"trash", "purge", "--older-than", "now"})
+		assert.NoError(t, err)
+
+		assert.Regexp(t, `^Purged 1 notes`, actual)
+	})
+
+	t.Run("shows a single note in full, then cleans up", func(t *testing.T) {
+		actual, err := runCommand([]string{"add-note", "-c", "first line #work\nsecond line", "-t", "ci"})
+		assert.NoError(t, err)
+
+		noteIDs, _ := getNoteDetails(actual)
+		require.Equal(t, 1, len(noteIDs))
+
+		noteID := strconv.Itoa(noteIDs[0])
+
+		actual, err = runCommand([]string{"show-note", "-i", noteID})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^Note:    `+noteID+`\nCreated: .*\nTags:    ci, work\n\nfirst line #work\nsecond line\n$`, actual)
+
+		_, err = runCommand([]string{"update-note", "-i", noteID, "-c", "only line #work"})
+		assert.NoError(t, err)
+
+		actual, err = runCommand([]string{"show-note", "-i", noteID, "-o", "json"})
+		assert.NoError(t, err)
+
+		var note entities.Note
+
+		require.NoError(t, json.Unmarshal([]byte(actual), &note))
+		assert.Equal(t, "only line #work", note.Content)
+		assert.Equal(t, []string{"ci", "work"}, note.Tags)
+		assert.NotNil(t, note.UpdatedAt)
+
+		_, err = runCommand([]string{"show-note"})
+		assert.EqualError(t, err, "note ID required")
+
+		_, err = runCommand([]string{"show-note", "-i", "9999"})
+		assert.EqualError(t, err, "note 9999 does not exist")
+
+		_, err = runCommand([]string{"delete-note", "-i", noteID})
+		assert.NoError(t, err)
+
+		_, err = runCommand([]string{"trash", "purge", "--older-than", "now"})
+		assert.NoError(t, err)
 	})
 
 	t.Run("adds a few notes and then lists them, then cleans up", func(t *testing.T) {
@@ -128,4 +281,845 @@ func TestIntegration(t *testing.T) {
 		_, err = runCommand([]string{"delete-note", "-i", strconv.Itoa(noteIDs[2])})
 		assert.NoError(t, err)
 	})
+
+	t.Run("adds tagged notes, filters them by tag, and counts the tags, then cleans up", func(t *testing.T) {
+		_, err := runCommand([]string{"add-note", "-c", "fixed the build #work"})
+		assert.NoError(t, err)
+
+		_, err = runCommand([]string{"add-note", "-c", "booked flights #travel", "-t", "Work"})
+		assert.NoError(t, err)
+
+		actual, err := runCommand([]string{"add-note", "-c", "groceries", "--tag", "home,errands"})
+		assert.NoError(t, err)
+
+		_, noteContents := getNoteDetails(actual)
+		require.Equal(t, 1, len(noteContents))
+		assert.Equal(t, "groceries #errands #home", noteContents[0])
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "-t", "work", "-t", "home"})
+		assert.NoError(t, err)
+
+		noteIDs, noteContents := getNoteDetails(actual)
+		require.Equal(t, 3, len(noteIDs))
+		assert.Equal(t, []string{"fixed the build #work", "booked flights #travel #work", "groceries #errands #home"},
+			noteContents)
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "-t", "work,travel", "--all-tags"})
+		assert.NoError(t, err)
+
+		_, noteContents = getNoteDetails(actual)
+		assert.Equal(t, []string{"booked flights #travel #work"}, noteContents)
+
+		actual, err = runCommand([]string{"tags"})
+		assert.NoError(t, err)
+		assert.Equal(t, "work: 2\nerrands: 1\nhome: 1\ntravel: 1\n", actual)
+
+		for _, noteID := range noteIDs {
+			_, err = runCommand([]string{"delete-note", "-i", strconv.Itoa(noteID)})
+			assert.NoError(t, err)
+		}
+
+		actual, err = runCommand([]string{"tags"})
+		assert.NoError(t, err)
+		assert.Equal(t, "", actual)
+	})
+
+	t.Run("updates a note, and shows its history, then cleans up", func(t *testing.T) {
+		actual, err := runCommand([]string{"add-note", "-c", "fixed teh build"})
+		assert.NoError(t, err)
+
+		noteIDs, _ := getNoteDetails(actual)
+		require.Equal(t, 1, len(noteIDs))
+
+		noteID := strconv.Itoa(noteIDs[0])
+
+		actual, err = runCommand([]string{"update-note", "-i", noteID, "-c", "fixed the build"})
+		assert.NoError(t, err)
+
+		updatedIDs, noteContents := getNoteDetails(actual)
+		assert.Equal(t, noteIDs, updatedIDs)
+		assert.Equal(t, []string{"fixed the build"}, noteContents)
+
+		_, err = runCommand([]string{"update-note", "-i", noteID, "-c", "fixed the flaky build"})
+		assert.NoError(t, err)
+
+		actual, err = runCommand([]string{"update-note", "-i", noteID, "-t", "ci", "-o", "json"})
+		assert.NoError(t, err)
+
+		updated := &entities.Note{}
+		require.NoError(t, json.Unmarshal([]byte(actual), updated))
+		assert.Equal(t, "fixed the flaky build", updated.Content)
+		assert.Equal(t, []string{"ci"}, updated.Tags)
+
+		actual, err = runCommand([]string{"update-note", "-i", noteID, "-t", "flaky", "-u", "ci", "-o", "json"})
+		assert.NoError(t, err)
+
+		updated = &entities.Note{}
+		require.NoError(t, json.Unmarshal([]byte(actual), updated))
+		assert.Equal(t, []string{"flaky"}, updated.Tags)
+
+		_, err = runCommand([]string{"update-note", "-i", noteID})
+		assert.EqualError(t, err, "note content or tags required")
+
+		actual, err = runCommand([]string{"history-note", "-i", noteID})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^Revision 1 - .*: fixed teh build\nRevision 2 - .*: fixed the build\n`+
+			`Revision 3 \(current\) - .*: fixed the flaky build\n$`, actual)
+
+		actual, err = runCommand([]string{"history-note", "-i", noteID, "--diff", "1,3"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Revision 1 -> Revision 3 (current):\nfixed [-teh-] {+the+} {+flaky+} build\n", actual)
+
+		_, err = runCommand([]string{"history-note", "-i", noteID, "--diff", "1,4"})
+		assert.EqualError(t, err, "revision 4 does not exist, the note has 3 revisions")
+
+		_, err = runCommand([]string{"delete-note", "-i", noteID})
+		assert.NoError(t, err)
+
+		_, err = runCommand([]string{"update-note", "-i", noteID, "-c", "too late"})
+		assert.EqualError(t, err, "note "+noteID+" does not exist")
+	})
+
+	t.Run("deletes a note into the trash, restores it, and then purges it", func(t *testing.T) {
+		actual, err := runCommand([]string{"add-note", "-c", "note for the trash"})
+		assert.NoError(t, err)
+
+		noteIDs, _ := getNoteDetails(actual)
+		require.Equal(t, 1, len(noteIDs))
+
+		noteID := strconv.Itoa(noteIDs[0])
+
+		_, err = runCommand([]string{"delete-note", "-i", noteID})
+		assert.NoError(t, err)
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+		assert.NotContains(t, actual, "note for the trash")
+
+		actual, err = runCommand([]string{"trash", "list"})
+		assert.NoError(t, err)
+		assert.Regexp(t, `(?m)^`+noteID+` - .*: note for the trash \(deleted .*\)$`, actual)
+
+		_, err = runCommand([]string{"delete-note", "-i", noteID})
+		assert.EqualError(t, err, "note "+noteID+" does not exist")
+
+		_, err = runCommand([]string{"show-note", "-i", noteID})
+		assert.EqualError(t, err, "note "+noteID+" does not exist")
+
+		actual, err = runCommand([]string{"trash", "restore", "-i", noteID})
+		assert.NoError(t, err)
+		assert.Equal(t, "Note restored.\n", actual)
+
+		_, err = runCommand([]string{"trash", "restore", "-i", noteID})
+		assert.EqualError(t, err, "note is not in the trash")
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "note for the trash")
+
+		_, err = runCommand([]string{"delete-note", "-i", noteID})
+		assert.NoError(t, err)
+
+		// the configured retention is 30 days, so nothing is old enough to purge yet
+		actual, err = runCommand([]string{"trash", "purge"})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^Purged 0 notes`, actual)
+
+		_, err = runCommand([]string{"trash", "purge", "--older-than", "now"})
+		assert.NoError(t, err)
+
+		actual, err = runCommand([]string{"trash", "list"})
+		assert.NoError(t, err)
+		assert.Equal(t, "", actual)
+	})
+
+	t.Run("adds and lists notes with structured output, then cleans up", func(t *testing.T) {
+		actual, err := runCommand([]string{"add-note", "-c", "structured note, with a comma", "-o", "json", "-t", "work"})
+		assert.NoError(t, err)
+
+		addedNote := &entities.Note{}
+
+		err = json.Unmarshal([]byte(actual), addedNote)
+		require.NoError(t, err)
+
+		assert.NotZero(t, addedNote.ID)
+		assert.Equal(t, "structured note, with a comma", addedNote.Content)
+		assert.Equal(t, []string{"work"}, addedNote.Tags)
+		assert.WithinDuration(t, time.Now(), addedNote.CreatedAt, time.Minute)
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "--output", "json"})
+		assert.NoError(t, err)
+
+		listedNotes := make([]*entities.Note, 0)
+
+		err = json.Unmarshal([]byte(actual), &listedNotes)
+		require.NoError(t, err)
+
+		require.Equal(t, 1, len(listedNotes))
+		assert.Equal(t, addedNote.ID, listedNotes[0].ID)
+		assert.True(t, addedNote.CreatedAt.Equal(listedNotes[0].CreatedAt))
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "-o", "jsonl"})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^{"id":\d+,"content":"structured note, with a comma","created_at":"[^"]+","tags":\["work"\]}\n$`, actual)
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "-o", "csv"})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^id,content,created_at,updated_at,deleted_at,tags\n\d+,"structured note, with a comma",[^,]+,,,work\n$`, actual)
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "-o", "yaml"})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^- id: \d+\n  content: structured note, with a comma\n  created_at: "[^"]+"\n  tags:\n  - work\n$`, actual)
+
+		actual, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "-o", "template={{.Content}}|{{join .Tags \",\"}}"})
+		assert.NoError(t, err)
+		assert.Equal(t, "structured note, with a comma|work\n", actual)
+
+		_, err = runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now", "-o", "xml"})
+		assert.EqualError(t, err, "unknown output format 'xml', supported formats are: "+
+			"text, json, jsonl, csv, yaml, template=<go template>")
+
+		_, err = runCommand([]string{"delete-note", "-i", strconv.FormatInt(addedNote.ID, 10)})
+		assert.NoError(t, err)
+	})
+
+	t.Run("exports notes in each format, then cleans up", func(t *testing.T) {
+		addedIDs := make([]int, 0)
+
+		for _, content := range []string{"exported note #work", "another exported note"} {
+			actual, err := runCommand([]string{"add-note", "-c", content})
+			require.NoError(t, err)
+
+			noteIDs, _ := getNoteDetails(actual)
+			require.Equal(t, 1, len(noteIDs))
+
+			addedIDs = append(addedIDs, noteIDs[0])
+		}
+
+		actual, err := runCommand([]string{"export", "-f", "jsonl"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(actual, "\n"))
+
+		actual, err = runCommand([]string{"export", "-f", "csv", "-t", "work"})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^id,content,created_at,updated_at,deleted_at,tags\n\d+,exported note #work,[^,]+,,,work\n$`, actual)
+
+		actual, err = runCommand([]string{"export", "-f", "markdown", "-s", "10 minutes ago"})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^# Notes\n\n## \w+, \d{4}-\d{2}-\d{2}\n\n- \d{2}:\d{2}:\d{2} exported note #work\n`+
+			`- \d{2}:\d{2}:\d{2} another exported note\n$`, actual)
+
+		exportFile := filepath.Join(t.TempDir(), "notes.html")
+
+		actual, err = runCommand([]string{"export", "-f", "html", "--file", exportFile})
+		assert.NoError(t, err)
+		assert.Equal(t, "", actual)
+
+		exported, err := os.ReadFile(exportFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(exported), `<span class="content">another exported note</span>`)
+
+		_, err = runCommand([]string{"export", "-f", "pdf"})
+		assert.Equal(t, errors.New("unknown export format 'pdf', supported formats are: json, jsonl, csv, markdown, html"), err)
+
+		for _, noteID := range addedIDs {
+			_, err = runCommand([]string{"delete-note", "-i", strconv.Itoa(noteID)})
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("exports notes and imports them into another DB, skipping duplicates, then cleans up", func(t *testing.T) {
+		addedIDs := make([]int, 0)
+
+		for _, content := range []string{"note to import #work", "another note to import"} {
+			actual, err := runCommand([]string{"add-note", "-c", content})
+			require.NoError(t, err)
+
+			noteIDs, _ := getNoteDetails(actual)
+			require.Equal(t, 1, len(noteIDs))
+
+			addedIDs = append(addedIDs, noteIDs[0])
+		}
+
+		exportDir := t.TempDir()
+		importDB := filepath.Join(exportDir, "import.sqlite")
+
+		for _, format := range []string{"json", "markdown"} {
+			_, err := runCommand([]string{"export", "-f", format, "--file", filepath.Join(exportDir, "notes."+format)})
+			require.NoError(t, err)
+		}
+
+		jsonFile := filepath.Join(exportDir, "notes.json")
+
+		actual, err := runCommand([]string{"import", "--db", importDB, "--dry-run", jsonFile})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^Would import 2 notes, skipping 0 duplicates.\n[\d-]+ [\d:]+ [\w+-]+: note to import #work\n`, actual)
+
+		actual, err = runCommand([]string{"list-notes", "--db", importDB, "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+		assert.Equal(t, "", actual)
+
+		actual, err = runCommand([]string{"import", "--db", importDB, jsonFile})
+		assert.NoError(t, err)
+		assert.Equal(t, "Imported 2 notes, skipped 0 duplicates.\n", actual)
+
+		actual, err = runCommand([]string{"import", "--db", importDB, jsonFile})
+		assert.NoError(t, err)
+		assert.Equal(t, "Imported 0 notes, skipped 2 duplicates.\n", actual)
+
+		actual, err = runCommand([]string{"import", "--db", importDB, filepath.Join(exportDir, "notes.markdown")})
+		assert.NoError(t, err)
+		assert.Equal(t, "Imported 0 notes, skipped 2 duplicates.\n", actual)
+
+		actual, err = runCommand([]string{"list-notes", "--db", importDB, "-s", "10 minutes ago", "-e", "now", "-t", "work"})
+		assert.NoError(t, err)
+
+		_, noteContents := getNoteDetails(actual)
+		assert.Equal(t, []string{"note to import #work"}, noteContents)
+
+		_, err = runCommand([]string{"import", "--db", importDB, "-"})
+		assert.EqualError(t, err, "--format is needed when importing from standard input")
+
+		for _, noteID := range addedIDs {
+			_, err = runCommand([]string{"delete-note", "-i", strconv.Itoa(noteID)})
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("uses the DB given by the db flag over the env var", func(t *testing.T) {
+		flagDB := filepath.Join(t.TempDir(), "flag.sqlite")
+
+		_, err := runCommand([]string{"add-note", "--db", flagDB, "-c", "note in another DB"})
+		assert.NoError(t, err)
+
+		assert.FileExists(t, flagDB)
+
+		actual, err := runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+
+		noteIDs, _ := getNoteDetails(actual)
+		assert.Equal(t, 0, len(noteIDs))
+
+		actual, err = runCommand([]string{"list-notes", "--db", flagDB, "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+
+		_, noteContents := getNoteDetails(actual)
+		require.Equal(t, 1, len(noteContents))
+		assert.Equal(t, "note in another DB", noteContents[0])
+
+		// a ? would otherwise start the store's options
+		oddDB := filepath.Join(t.TempDir(), "what?mode=ro 100%.sqlite")
+
+		_, err = runCommand([]string{"add-note", "--db", oddDB, "-c", "note in an oddly named DB"})
+		assert.NoError(t, err)
+
+		assert.FileExists(t, oddDB)
+	})
+
+	t.Run("keeps notes in the store given by the store flag", func(t *testing.T) {
+		dir := t.TempDir()
+
+		for _, store := range []string{"jsonl://" + filepath.Join(dir, "notes.jsonl"), "dir://" + filepath.Join(dir, "notes")} {
+			_, err := runCommand([]string{"add-note", "--store", store, "-c", "note in another store #work"})
+			assert.NoError(t, err)
+
+			_, err = runCommand([]string{"update-note", "--store", store, "-i", "1", "-c", "updated note #work"})
+			assert.NoError(t, err)
+
+			actual, err := runCommand([]string{"list-notes", "--store", store, "-s", "10 minutes ago", "-e", "now"})
+			assert.NoError(t, err)
+
+			noteIDs, noteContents := getNoteDetails(actual)
+			assert.Equal(t, []int{1}, noteIDs)
+			assert.Equal(t, []string{"updated note #work"}, noteContents)
+
+			actual, err = runCommand([]string{"search-notes", "--store", store, "-q", "updated"})
+			assert.NoError(t, err)
+			assert.Contains(t, actual, "[updated] note #work")
+		}
+
+		assert.FileExists(t, filepath.Join(dir, "notes.jsonl"))
+		assert.FileExists(t, filepath.Join(dir, "notes", "1.md"))
+
+		actual, err := runCommand([]string{"list-notes", "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+		assert.NotContains(t, actual, "updated note")
+
+		_, err = runCommand([]string{"tags", "--store", "nosql://somewhere"})
+		assert.EqualError(t, err, "unknown store 'nosql', it should be one of: dir, jsonl, memory, postgres, postgresql, sqlite")
+	})
+	t.Run("rolls the DB back and migrates it up again", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "migrate.sqlite")
+
+		_, err := runCommand([]string{"add-note", "--db", db, "-c", "note before rolling back"})
+		assert.NoError(t, err)
+
+		actual, err := runCommand([]string{"db", "migrate", "status", "--db", db})
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "6 - add notes deleted_at for the trash: applied")
+
+		actual, err = runCommand([]string{"db", "migrate", "down", "--db", db, "--to", "5", "--dry-run"})
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "-- migration 6 'add notes deleted_at for the trash' (down)")
+		assert.Contains(t, actual, "PRAGMA user_version = 5;")
+
+		actual, err = runCommand([]string{"db", "migrate", "status", "--db", db})
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "6 - add notes deleted_at for the trash: applied")
+
+		actual, err = runCommand([]string{"db", "migrate", "down", "--db", db, "--to", "5"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Migrated the DB from version 7 to 5.\n", actual)
+
+		actual, err = runCommand([]string{"db", "migrate", "status", "--db", db})
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "6 - add notes deleted_at for the trash: pending")
+
+		_, err = runCommand([]string{"db", "migrate", "down", "--db", db})
+		assert.EqualError(t, err, "--to is needed to say which version to roll back to")
+
+		_, err = runCommand([]string{"db", "migrate", "up", "--db", db, "--to", "2"})
+		assert.EqualError(t, err, "the DB is already at version 5, use down to roll back to 2")
+
+		actual, err = runCommand([]string{"db", "migrate", "up", "--db", db})
+		assert.NoError(t, err)
+		assert.Equal(t, "Migrated the DB from version 5 to 7.\n", actual)
+
+		actual, err = runCommand([]string{"list-notes", "--db", db, "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+
+		_, noteContents := getNoteDetails(actual)
+		assert.Equal(t, []string{"note before rolling back"}, noteContents)
+
+		_, err = runCommand([]string{"db", "migrate", "status", "--store", "memory://"})
+		assert.EqualError(t, err, "this only works with a SQLite DB, not the memory store")
+	})
+	t.Run("backs up the DB and restores it", func(t *testing.T) {
+		dir := t.TempDir()
+		db := filepath.Join(dir, "notes.sqlite")
+		backups := filepath.Join(dir, "backups")
+
+		err := os.Mkdir(backups, 0o700)
+		require.NoError(t, err)
+
+		_, err = runCommand([]string{"add-note", "--db", db, "-c", "note in the backup"})
+		assert.NoError(t, err)
+
+		actual, err := runCommand([]string{"db", "backup", "--db", db, "--gzip", "--keep", "2", backups})
+		assert.NoError(t, err)
+		assert.Regexp(t, `^Backed up the DB to .*notes-\d{8}-\d{6}\.sqlite\.gz\.\n$`, actual)
+
+		entries, err := os.ReadDir(backups)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(entries))
+
+		backup := filepath.Join(backups, entries[0].Name())
+
+		_, err = runCommand([]string{"add-note", "--db", db, "-c", "note after the backup"})
+		assert.NoError(t, err)
+
+		actual, err = runCommand([]string{"db", "restore", "--db", db, backup})
+		assert.NoError(t, err)
+		assert.Equal(t, "Restored the DB from "+backup+".\n", actual)
+
+		actual, err = runCommand([]string{"list-notes", "--db", db, "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+
+		_, noteContents := getNoteDetails(actual)
+		assert.Equal(t, []string{"note in the backup"}, noteContents)
+
+		_, err = runCommand([]string{"db", "backup", "--db", db, "--keep", "2", filepath.Join(dir, "backup.sqlite")})
+		assert.EqualError(t, err, "keeping the last 2 backups needs a directory to back up into")
+	})
+	t.Run("checks the DB over with the doctor", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "doctor.sqlite")
+
+		_, err := runCommand([]string{"add-note", "--db", db, "-c", "note for the doctor"})
+		assert.NoError(t, err)
+
+		actual, err := runCommand([]string{"doctor", "--db", db})
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "[ok] integrity: passed\n")
+		assert.Contains(t, actual, "[ok] migrations: the DB is up to date, at version 7\n")
+
+		_, err = runCommand([]string{"db", "migrate", "down", "--db", db, "--to", "5"})
+		assert.NoError(t, err)
+
+		actual, err = runCommand([]string{"doctor", "--db", db})
+		assert.EqualError(t, err, "found 1 problems with the DB")
+		assert.Contains(t, actual, "[problem] migrations: the DB is at version 5, behind the latest (7), run with --fix to migrate it\n")
+
+		actual, err = runCommand([]string{"doctor", "--db", db, "--fix"})
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "[fixed] migrations: migrated the DB from version 5 to 7\n")
+		assert.Contains(t, actual, "[ok] integrity: passed, and the indexes were rebuilt\n")
+	})
+	t.Run("takes a busy timeout for a SQLite store", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		_, err := runCommand([]string{"add-note", "--store", "sqlite://" + db + "?busy_timeout=10s", "-c", "patient note"})
+		assert.NoError(t, err)
+
+		actual, err := runCommand([]string{"list-notes", "--db", db, "-s", "10 minutes ago", "-e", "now"})
+		assert.NoError(t, err)
+
+		_, noteContents := getNoteDetails(actual)
+		assert.Equal(t, []string{"patient note"}, noteContents)
+
+		_, err = runCommand([]string{"tags", "--store", "sqlite://" + db + "?busy_timeout=whenever"})
+		assert.EqualError(t, err, "invalid busy_timeout 'whenever', it should be a duration like 10s")
+	})
+
+	t.Run("lists notes a page at a time", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		for _, content := range []string{"first", "second", "third", "fourth", "fifth"} {
+			_, err := runCommand([]string{"add-note", "--db", db, "-c", content + " paged note"})
+			require.NoError(t, err)
+		}
+
+		listArgs := []string{"list-notes", "--db", db, "-s", "10 minutes ago", "-e", "now"}
+
+		actual, err := runCommand(append(listArgs, "--limit", "2"))
+		assert.NoError(t, err)
+
+		noteIDs, noteContents := getNoteDetails(actual)
+		assert.Equal(t, []string{"first paged note", "second paged note"}, noteContents)
+		assert.Contains(t, actual, fmt.Sprintf("There are more notes, carry on with --after-id %v\n", noteIDs[1]))
+
+		actual, err = runCommand(append(listArgs, "--limit", "2", "--after-id", strconv.Itoa(noteIDs[1])))
+		assert.NoError(t, err)
+
+		_, noteContents = getNoteDetails(actual)
+		assert.Equal(t, []string{"third paged note", "fourth paged note"}, noteContents)
+
+		actual, err = runCommand(append(listArgs, "--limit", "2", "--page", "3"))
+		assert.NoError(t, err)
+
+		_, noteContents = getNoteDetails(actual)
+		assert.Equal(t, []string{"fifth paged note"}, noteContents)
+		assert.NotContains(t, actual, "There are more notes")
+
+		actual, err = runCommand(append(listArgs, "--reverse", "--limit", "2"))
+		assert.NoError(t, err)
+
+		_, noteContents = getNoteDetails(actual)
+		assert.Equal(t, []string{"fifth paged note", "fourth paged note"}, noteContents)
+
+		_, err = runCommand(append(listArgs, "--page", "2"))
+		assert.EqualError(t, err, "--page needs --limit to say how many notes are on a page")
+
+		_, err = runCommand(append(listArgs, "--after-id", "42"))
+		assert.EqualError(t, err, "note 42 does not exist")
+	})
+
+	t.Run("exits with the code for the kind of error", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		stderr, code := executeCommand([]string{"add-note", "--db", db, "-c", "exit code note"})
+		assert.Equal(t, ExitOK, code)
+		assert.Equal(t, "", stderr)
+
+		stderr, code = executeCommand([]string{"show-note", "--db", db, "-i", "42"})
+		assert.Equal(t, ExitNotFound, code)
+		assert.Equal(t, "Error: note 42 does not exist\n", stderr)
+
+		stderr, code = executeCommand([]string{"show-note", "--db", db, "-i", "42", "-o", "json"})
+		assert.Equal(t, ExitNotFound, code)
+		assert.Equal(t, `{"error":"note 42 does not exist","kind":"not_found","exit_code":3}`+"\n", stderr)
+
+		stderr, code = executeCommand([]string{"show-note", "--db", db, "-o", "yaml"})
+		assert.Equal(t, ExitInvalidInput, code)
+		assert.Equal(t, `{"error":"note ID required","kind":"invalid_input","exit_code":2}`+"\n", stderr)
+
+		_, code = executeCommand([]string{"list-notes", "--db", db, "--no-such-flag"})
+		assert.Equal(t, ExitInvalidInput, code)
+
+		_, code = executeCommand([]string{"import", "--db", db})
+		assert.Equal(t, ExitInvalidInput, code)
+
+		_, code = executeCommand([]string{"trash", "restore", "--db", db, "-i", "1"})
+		assert.Equal(t, ExitConflict, code)
+
+		_, code = executeCommand([]string{"trash", "restore", "--db", db, "-i", "99"})
+		assert.Equal(t, ExitNotFound, code)
+
+		if !sqlite.FTS5Enabled {
+			_, code = executeCommand([]string{"search-notes", "--db", db, "-q", "exit"})
+			assert.Equal(t, ExitStorage, code)
+		}
+
+		_, code = executeCommand([]string{"list-notes", "--db", t.TempDir(), "-s", "today", "-e", "now"})
+		assert.Equal(t, ExitStorage, code)
+	})
+
+	t.Run("closes the DB once the command is done, even when it fails", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		// the WAL gets checkpointed into the DB file and removed when the last connection closes
+		_, code := executeCommand([]string{"add-note", "--db", db, "-c", "closed note"})
+		assert.Equal(t, ExitOK, code)
+		assert.NoFileExists(t, db+"-wal")
+
+		_, code = executeCommand([]string{"show-note", "--db", db, "-i", "42"})
+		assert.Equal(t, ExitNotFound, code)
+		assert.NoFileExists(t, db+"-wal")
+	})
+
+	t.Run("shows times in the zone from --tz or the config file", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		_, err := runCommand([]string{"add-note", "--db", db, "-c", "note with a zone"})
+		assert.NoError(t, err)
+
+		listArgs := []string{"list-notes", "--db", db, "-s", "10 minutes ago", "-e", "now"}
+
+		actual, err := runCommand(append(listArgs, "--tz", "UTC"))
+		assert.NoError(t, err)
+		assert.Regexp(t, `^1 - [\d-]+ [\d:]+ UTC: note with a zone\n$`, actual)
+
+		actual, err = runCommand(append(listArgs, "--tz", "Asia/Tokyo"))
+		assert.NoError(t, err)
+		assert.Regexp(t, `^1 - [\d-]+ [\d:]+ JST: note with a zone\n$`, actual)
+
+		filename, err := config.Filename()
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0o755))
+		require.NoError(t, os.WriteFile(filename, []byte("tz: Asia/Tokyo\n"), 0o600))
+
+		t.Cleanup(func() {
+			os.Remove(filename)
+		})
+
+		actual, err = runCommand(listArgs)
+		assert.NoError(t, err)
+		assert.Regexp(t, ` JST: note with a zone\n$`, actual)
+
+		actual, err = runCommand(append(listArgs, "--tz", "UTC"))
+		assert.NoError(t, err)
+		assert.Regexp(t, ` UTC: note with a zone\n$`, actual)
+
+		_, err = runCommand(append(listArgs, "--tz", "Mars/Olympus_Mons"))
+		assert.EqualError(t, err,
+			"unknown time zone 'Mars/Olympus_Mons', use local, original or a name like Europe/London")
+	})
+
+	t.Run("lists today's notes by default, or the period or shortcut given", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		_, err := runCommand([]string{"add-note", "--db", db, "-c", "note from today"})
+		assert.NoError(t, err)
+
+		for _, args := range [][]string{
+			nil,
+			{"today"},
+			{"this week"},
+			{"--today"},
+			{"--week"},
+			{"--month"},
+			{"--last", "1h"},
+			{"-s", "10 minutes ago"},
+			{"-e", "now"},
+		} {
+			actual, err := runCommand(append([]string{"list-notes", "--db", db}, args...))
+			assert.NoError(t, err, args)
+			assert.Regexp(t, `^1 - .*: note from today\n$`, actual, args)
+		}
+
+		for _, args := range [][]string{
+			{"--yesterday"},
+			{"march 2020"},
+			{"last year"},
+			{"-e", "yesterday"},
+		} {
+			actual, err := runCommand(append([]string{"list-notes", "--db", db}, args...))
+			assert.NoError(t, err, args)
+			assert.Empty(t, actual, args)
+		}
+
+		_, err = runCommand([]string{"list-notes", "--db", db, "--today", "--last", "3d"})
+		assert.EqualError(t, err,
+			"only one of a period, --start and --end, --today, --yesterday, --week, --month or --last can be used")
+
+		_, err = runCommand([]string{"list-notes", "--db", db, "yesterday", "-s", "10 minutes ago"})
+		assert.Error(t, err)
+
+		_, err = runCommand([]string{"list-notes", "--db", db, "--last", "3 fortnights"})
+		assert.EqualError(t, err, "unknown unit of time 'fortnights', use m, h, d, w, mo or y")
+
+		_, err = runCommand([]string{"list-notes", "--db", db, "february 30"})
+		assert.EqualError(t, err, "there's no such date as 'february 30'")
+	})
+
+	t.Run("runs under a frozen or advancing clock", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+		start := time.Date(2026, time.March, 14, 9, 30, 0, 0, time.UTC)
+
+		fake := clock.NewFake(start, time.Minute)
+		useClock(t, fake)
+
+		_, err := runCommand([]string{"add-note", "--db", db, "-c", "first on the fake clock"})
+		assert.NoError(t, err)
+
+		_, err = runCommand([]string{"add-note", "--db", db, "-c", "a minute later"})
+		assert.NoError(t, err)
+
+		fake.Set(start.Add(time.Hour))
+
+		listArgs := []string{"list-notes", "--db", db, "--tz", "UTC"}
+		expected := "1 - 2026-03-14 09:30:00 UTC: first on the fake clock\n" +
+			"2 - 2026-03-14 09:31:00 UTC: a minute later\n"
+
+		actual, err := runCommand(listArgs)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+
+		actual, err = runCommand(append(listArgs, "-s", "10 minutes ago"))
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+
+		fake.Advance(24 * time.Hour)
+
+		actual, err = runCommand(listArgs)
+		assert.NoError(t, err)
+		assert.Empty(t, actual)
+
+		actual, err = runCommand(append(listArgs, "--yesterday"))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+
+		actual, err = runCommand(append(listArgs, "--last", "2d"))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("writes a report for a period, through the built-in or the user's templates", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+		fake := clock.NewFake(time.Date(2026, time.March, 9, 9, 15, 0, 0, time.UTC), 0)
+		useClock(t, fake)
+
+		for _, note := range []struct {
+			content string
+			advance time.Duration
+		}{
+			{content: "standup #work"},
+			{content: "coffee", advance: 30 * time.Minute},
+			{content: "planning #work #ci", advance: 49 * time.Hour},
+		} {
+			fake.Advance(note.advance)
+
+			_, err := runCommand([]string{"add-note", "--db", db, "-c", note.content})
+			require.NoError(t, err)
+		}
+
+		reportArgs := []string{"report", "--db", db, "--tz", "UTC"}
+
+		actual, err := runCommand(append(reportArgs, "--week"))
+		assert.NoError(t, err)
+		assert.Contains(t, actual, "Report for Monday, 2026-03-09 to Wednesday, 2026-03-11: 3 notes, "+
+			"from 2026-03-09 09:15 UTC to 2026-03-11 10:45 UTC\n")
+		assert.Contains(t, actual, "  Monday, 2026-03-09: 2 notes, 09:15 to 09:45\n")
+		assert.Contains(t, actual, "By tag:\n  #work: 2 notes\n  #ci: 1 note\n")
+		assert.Contains(t, actual, "Untagged notes:\n  2026-03-09 09:45 UTC coffee\n")
+
+		actual, err = runCommand(append(reportArgs, "2026-03-09", "-f", "markdown"))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(actual, "# Report for Monday, 2026-03-09\n\n2 notes,"), actual)
+
+		actual, err = runCommand(append(reportArgs, "--yesterday", "-o", "json"))
+		assert.NoError(t, err)
+
+		var digest struct {
+			Count int `json:"count"`
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(actual), &digest))
+		assert.Equal(t, 0, digest.Count)
+
+		tmpl := filepath.Join(t.TempDir(), "report.tmpl")
+		require.NoError(t, os.WriteFile(tmpl, []byte(`{{.Count}} notes, {{len .Untagged}} untagged`), 0o600))
+
+		actual, err = runCommand(append(reportArgs, "--week", "--template", tmpl))
+		assert.NoError(t, err)
+		assert.Equal(t, "3 notes, 1 untagged", actual)
+
+		userTemplate, err := config.TemplateFilename("report.md.tmpl")
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Dir(userTemplate), 0o755))
+		require.NoError(t, os.WriteFile(userTemplate, []byte(`busiest: {{day (index .Busiest.Days 0).Date}}`), 0o600))
+
+		t.Cleanup(func() {
+			os.Remove(userTemplate)
+		})
+
+		actual, err = runCommand(append(reportArgs, "--week", "-f", "markdown"))
+		assert.NoError(t, err)
+		assert.Equal(t, "busiest: Monday, 2026-03-09", actual)
+
+		actual, err = runCommand([]string{"report", "--default-template", "-f", "html"})
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(actual, "<!DOCTYPE html>"), actual)
+
+		_, err = runCommand(append(reportArgs, "-f", "pdf"))
+		assert.EqualError(t, err, "unknown report format 'pdf', supported formats are: text, markdown, html")
+	})
+
+	t.Run("shows stats of the notes with streaks, top words and a heatmap", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+		fake := clock.NewFake(time.Date(2026, time.March, 9, 9, 15, 0, 0, time.UTC), 0)
+		useClock(t, fake)
+
+		for _, note := range []struct {
+			content string
+			advance time.Duration
+		}{
+			{content: "deploy the api #work"},
+			{content: "review the deploy", advance: 24 * time.Hour},
+			{content: "deploy again", advance: 24 * time.Hour},
+			{content: "lunch", advance: 48 * time.Hour},
+		} {
+			fake.Advance(note.advance)
+
+			_, err := runCommand([]string{"add-note", "--db", db, "-c", note.content})
+			require.NoError(t, err)
+		}
+
+		statsArgs := []string{"stats", "--db", db, "--tz", "UTC"}
+
+		actual, err := runCommand(append(statsArgs, "--week"))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(actual, "Notes from Monday, 2026-03-09 to Friday, 2026-03-13\n\n"), actual)
+		assert.Contains(t, actual, "    Less ·░▒▓█ More\n")
+		assert.Contains(t, actual, "Notes:          4, on 4 of 5 days\n")
+		assert.Contains(t, actual, "Current streak: 1 day, on Friday, 2026-03-13\n")
+		assert.Contains(t, actual, "Longest streak: 3 days, from Monday, 2026-03-09 to Wednesday, 2026-03-11\n")
+		assert.Contains(t, actual, "Top words:      deploy (3), api (1), lunch (1), review (1)\n")
+
+		actual, err = runCommand(append(statsArgs, "-o", "json", "--top", "1"))
+		assert.NoError(t, err)
+
+		var noteStats struct {
+			Total         int `json:"total"`
+			CurrentStreak struct {
+				Days int `json:"days"`
+			} `json:"current_streak"`
+			TopWords []struct {
+				Word  string `json:"word"`
+				Count int    `json:"count"`
+			} `json:"top_words"`
+			Days []struct {
+				Count int `json:"count"`
+			} `json:"days"`
+		}
+
+		require.NoError(t, json.Unmarshal([]byte(actual), &noteStats))
+		assert.Equal(t, 4, noteStats.Total)
+		assert.Equal(t, 1, noteStats.CurrentStreak.Days)
+		assert.Len(t, noteStats.TopWords, 1)
+		assert.Equal(t, "deploy", noteStats.TopWords[0].Word)
+		assert.Len(t, noteStats.Days, 53*7-2)
+
+		_, err = runCommand(append(statsArgs, "--top", "-1"))
+		assert.EqualError(t, err, "invalid number of top words -1, it can't be negative")
+	})
 }
@@ -3,77 +3,174 @@ package cmd
 import (
 	"context"
 	"errors"
-	"time"
 
-	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
 	"note-logger/internal/repositories/notes"
+	"note-logger/internal/timerange"
 
 	"github.com/spf13/cobra"
-	"github.com/tj/go-naturaldate"
 )
 
 var listNotesCommand = &cobra.Command{
-	Use:   "list-notes",
+	Use:   "list-notes [period]",
 	Short: "Lists the existing notes",
+	Long: `Lists the notes from a window of time, which is today so far unless it's given by one of:
+
+  a period, like "yesterday", "last tuesday", "2026-03-14" or "march 2026", for the whole of that day, week, month or year
+  --start and --end, where a missing start is the first note and a missing end is now
+  --today, --yesterday, --week or --month
+  --last, like 30m, 12h, 3d, 2w, 1mo or 1y, up until now`,
+	Args: inputArgs(cobra.MaximumNArgs(1)),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
-		beginningTimeString, err := cmd.Flags().GetString("start")
+		window, err := timeWindow(cmd, args, timerange.Today)
 		if err != nil {
 			return err
 		}
 
-		if beginningTimeString == "" {
-			err := errors.New("beginning time required")
+		tagFlags, err := cmd.Flags().GetStringSlice("tag")
+		if err != nil {
 			return err
 		}
 
-		endTimeString, err := cmd.Flags().GetString("end")
+		allTags, err := cmd.Flags().GetBool("all-tags")
 		if err != nil {
 			return err
 		}
 
-		if endTimeString == "" {
-			err := errors.New("end time required")
+		opts, err := listOptions(cmd)
+		if err != nil {
 			return err
 		}
 
-		beginningTime, err := naturaldate.Parse(beginningTimeString, time.Now())
+		opts.StartTime = window.Start
+		opts.EndTime = window.End
+		opts.Filter = &notes.TagFilter{
+			Tags:     tagFlags,
+			MatchAll: allTags,
+		}
+
+		format, err := outputFormat(cmd)
 		if err != nil {
 			return err
 		}
 
-		endTime, err := naturaldate.Parse(endTimeString, time.Now())
+		notesRepo, err := currentApp.notesRepo(ctx)
 		if err != nil {
 			return err
 		}
 
-		sqliteDB, err := sqlite.New(ctx)
+		// one more note than the page holds says whether there's another page after it
+		limit := opts.Limit
+		if limit > 0 {
+			opts.Limit++
+		}
+
+		notesRes, err := notesRepo.List(ctx, opts)
 		if err != nil {
 			return err
 		}
 
-		notesRepo, err := notes.NewRepository(&notes.Config{DB: sqliteDB})
+		morePages := limit > 0 && len(notesRes) > limit
+		if morePages {
+			notesRes = notesRes[:limit]
+		}
+
+		closePager, err := startPager(cmd)
 		if err != nil {
 			return err
 		}
 
-		notesRes, err := notesRepo.ListBetween(ctx, beginningTime, endTime)
+		err = writeNotes(cmd, format, notesRes)
+		if closeErr := closePager(); err == nil {
+			err = closeErr
+		}
+
 		if err != nil {
 			return err
 		}
 
-		for _, note := range notesRes {
-			cmd.Printf("%v - %v: %v\n", note.ID, note.CreatedAt.Format(time.Stamp), note.Content)
+		if morePages && format == output.Text {
+			cmd.PrintErrf("There are more notes, carry on with --after-id %v\n", notesRes[len(notesRes)-1].ID)
 		}
 
 		return nil
 	},
 }
 
+func writeNotes(cmd *cobra.Command, format string, notesRes []*entities.Note) error {
+	writer, err := output.NewWriter(cmd.OutOrStdout(), format, textNote)
+	if err != nil {
+		return err
+	}
+
+	for _, note := range notesRes {
+		err = writer.Write(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// listOptions reads the paging flags into the options for listing notes
+func listOptions(cmd *cobra.Command) (*notes.ListOptions, error) {
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return nil, err
+	}
+
+	if limit < 0 {
+		err := noteerrors.InvalidInputf("invalid limit %v, it can't be negative", limit)
+		return nil, err
+	}
+
+	page, err := cmd.Flags().GetInt("page")
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		err := noteerrors.InvalidInputf("invalid page %v, pages start at 1", page)
+		return nil, err
+	}
+
+	if page > 1 && limit == 0 {
+		err := noteerrors.InvalidInput(errors.New("--page needs --limit to say how many notes are on a page"))
+		return nil, err
+	}
+
+	reverse, err := cmd.Flags().GetBool("reverse")
+	if err != nil {
+		return nil, err
+	}
+
+	afterID, err := cmd.Flags().GetInt64("after-id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &notes.ListOptions{
+		Reverse: reverse,
+		AfterID: afterID,
+		Offset:  (page - 1) * limit,
+		Limit:   limit,
+	}, nil
+}
+
 func init() {
 	rootCommand.AddCommand(listNotesCommand)
 
-	listNotesCommand.Flags().StringP("start", "s", "", "Start of the time window")
-	listNotesCommand.Flags().StringP("end", "e", "", "End of the time window")
+	addTimeWindowFlags(listNotesCommand)
+	listNotesCommand.Flags().StringSliceP("tag", "t", nil, "Only list notes with any of these tags")
+	listNotesCommand.Flags().Bool("all-tags", false, "Only list notes with all of the given tags")
+	listNotesCommand.Flags().IntP("limit", "l", 0, "The most notes to list, with 0 listing them all")
+	listNotesCommand.Flags().BoolP("reverse", "r", false, "List the newest notes first")
+	listNotesCommand.Flags().Int64("after-id", 0, "Carry on listing from after the note with this ID")
+	listNotesCommand.Flags().IntP("page", "p", 1, "The page of --limit notes to list")
+	listNotesCommand.Flags().Bool("no-pager", false, "Don't page the notes through $PAGER, even on a terminal")
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"note-logger/internal/pager"
+
+	"github.com/spf13/cobra"
+)
+
+// startPager pages the command's output when it goes to a terminal, giving back what to call once it's all written
+func startPager(cmd *cobra.Command) (func() error, error) {
+	noPager, err := cmd.Flags().GetBool("no-pager")
+	if err != nil {
+		return nil, err
+	}
+
+	if noPager || !isTerminal(cmd) {
+		return func() error { return nil }, nil
+	}
+
+	paged, err := pager.Start(cmd.OutOrStdout())
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.SetOut(paged)
+
+	return func() error {
+		// the command goes back to writing wherever its parent does
+		cmd.SetOut(nil)
+
+		return paged.Close()
+	}, nil
+}
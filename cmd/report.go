@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"note-logger/internal/config"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+	"note-logger/internal/report"
+	"note-logger/internal/repositories/notes"
+	"note-logger/internal/timerange"
+
+	"github.com/spf13/cobra"
+)
+
+var reportCommand = &cobra.Command{
+	Use:   "report [period]",
+	Short: "Writes a digest of the notes from a period, as text, Markdown or HTML",
+	Long: `Writes a digest of the notes from a period, grouped by day, tag and hour of the day, with the busiest days and
+hours, and the notes without any tags. The period is given the same way as for list-notes, and is today so far unless
+it's given.
+
+Each format has a built-in template, which can be swapped for one of your own with --template, or by keeping it in
+$XDG_CONFIG_HOME/note-logger/templates/ under the same name as the built-in one, which --default-template prints.`,
+	Args: inputArgs(cobra.MaximumNArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx := context.Background()
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			return err
+		}
+
+		defaultTemplate, err := cmd.Flags().GetBool("default-template")
+		if err != nil {
+			return err
+		}
+
+		if defaultTemplate {
+			tmpl, err := report.DefaultTemplate(format)
+			if err != nil {
+				return err
+			}
+
+			cmd.Print(tmpl)
+
+			return nil
+		}
+
+		tmpl, err := reportTemplate(cmd, format)
+		if err != nil {
+			return err
+		}
+
+		filename, err := cmd.Flags().GetString("file")
+		if err != nil {
+			return err
+		}
+
+		dataFormat, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		window, err := timeWindow(cmd, args, timerange.Today)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		var reportNotes []*entities.Note
+
+		err = notesRepo.ForEach(ctx, window.Start, window.End, &notes.TagFilter{}, func(note *entities.Note) error {
+			reportNotes = append(reportNotes, note)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		digest := report.Build(reportNotes, window.Start, window.End, displayZone)
+
+		out := cmd.OutOrStdout()
+
+		if filename != "" {
+			file, err := os.Create(filename)
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				closeErr := file.Close()
+				if err == nil {
+					err = closeErr
+				}
+			}()
+
+			out = file
+		}
+
+		buffered := bufio.NewWriter(out)
+
+		// the other output formats are for scripts, which get the digest itself rather than it rendered
+		if dataFormat != output.Text {
+			err = output.WriteOne(buffered, dataFormat, digest, nil)
+		} else {
+			err = report.Render(buffered, format, digest, tmpl)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return buffered.Flush()
+	},
+}
+
+// reportTemplate reads the template from --template or the config directory, or nothing for the built-in one
+func reportTemplate(cmd *cobra.Command, format string) (string, error) {
+	filename, err := cmd.Flags().GetString("template")
+	if err != nil {
+		return "", err
+	}
+
+	if filename != "" {
+		contents, err := os.ReadFile(filename)
+		if err != nil {
+			return "", noteerrors.InvalidInput(err)
+		}
+
+		return string(contents), nil
+	}
+
+	name, err := report.TemplateName(format)
+	if err != nil {
+		return "", err
+	}
+
+	filename, err = config.TemplateFilename(name)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	return string(contents), err
+}
+
+func init() {
+	rootCommand.AddCommand(reportCommand)
+
+	reportCommand.Flags().StringP("format", "f", report.Text, "Report format, one of: "+strings.Join(report.Formats, ", "))
+	reportCommand.Flags().String("template", "", "Template file to render the report with, instead of the built-in one")
+	reportCommand.Flags().Bool("default-template", false, "Print the built-in template for the format, to start one of "+
+		"your own from")
+	reportCommand.Flags().String("file", "", "File to write the report to, instead of printing it")
+	addTimeWindowFlags(reportCommand)
+}
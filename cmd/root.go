@@ -1,19 +1,59 @@
 package cmd
 
 import (
+	"strings"
+
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+
 	"github.com/spf13/cobra"
 )
 
 var rootCommand = &cobra.Command{
 	Use: "note-logger",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		currentApp = newApp(cmd)
+
+		return loadDisplayZone(cmd)
+	},
 }
 
-func Execute() error {
+// Execute runs the command, writing out any error it fails with and giving the exit code for it
+func Execute() int {
 	rootCommand.SilenceUsage = true
+	rootCommand.SilenceErrors = true
 
-	if err := rootCommand.Execute(); err != nil {
-		return err
+	cmd, err := rootCommand.ExecuteC()
+
+	closeErr := currentApp.close()
+	if err == nil {
+		err = closeErr
 	}
 
-	return nil
+	if err != nil {
+		// the output flag might not have been parsed when the error was in the flags themselves
+		format, _ := cmd.Flags().GetString("output")
+
+		writeError(cmd.ErrOrStderr(), format, err)
+
+		return exitCode(err)
+	}
+
+	return ExitOK
+}
+
+func init() {
+	rootCommand.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return noteerrors.InvalidInput(err)
+	})
+
+	rootCommand.PersistentFlags().String("db", "", "Path to the SQLite DB (overrides NOTE_LOGGER_DB and the config file)")
+	rootCommand.PersistentFlags().String("store", "",
+		"Where to keep the notes, one of: sqlite:///path, postgres://..., memory://, jsonl:///path or dir:///path "+
+			"(overrides --db, NOTE_LOGGER_STORE and the config file)")
+	rootCommand.PersistentFlags().StringP("output", "o", output.Text,
+		"Output format, one of: "+strings.Join(output.Formats, ", "))
+	rootCommand.PersistentFlags().String("tz", "",
+		"Time zone to show times in, one of: local, original (where each note was created), or a name like UTC or "+
+			"Europe/London (overrides the config file)")
 }
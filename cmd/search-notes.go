@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/repositories/notes"
+	"note-logger/internal/store"
+
+	"github.com/spf13/cobra"
+)
+
+var searchNotesCommand = &cobra.Command{
+	Use:   "search-notes",
+	Short: "Searches the contents of the existing notes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		dsn, err := storeDSN(cmd)
+		if err != nil {
+			return err
+		}
+
+		// only the SQLite store needs FTS5, the others search without an index
+		if parsed, err := store.Parse(dsn); err == nil && parsed.Scheme == "sqlite" && !sqlite.FTS5Enabled {
+			err := noteerrors.Storage(errors.New(
+				"searching requires a build with FTS5 support, using: go install -tags sqlite_fts5"))
+			return err
+		}
+
+		query, err := cmd.Flags().GetString("query")
+		if err != nil {
+			return err
+		}
+
+		if query == "" {
+			err := noteerrors.Storage(errors.New("search query required"))
+			return err
+		}
+
+		beginningTimeString, err := cmd.Flags().GetString("start")
+		if err != nil {
+			return err
+		}
+
+		endTimeString, err := cmd.Flags().GetString("end")
+		if err != nil {
+			return err
+		}
+
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+
+		beginningTime, err := parseOptionalTime(beginningTimeString)
+		if err != nil {
+			return err
+		}
+
+		endTime, err := parseOptionalTime(endTimeString)
+		if err != nil {
+			return err
+		}
+
+		writer, err := newOutputWriter(cmd, func(value interface{}) string {
+			result := value.(*entities.SearchResult)
+
+			return fmt.Sprintf("%v - %v: %v", result.Note.ID, formatTime(result.Note.CreatedAt), result.Snippet)
+		})
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		highlightStart, highlightEnd := "[", "]"
+		if isTerminal(cmd) {
+			highlightStart, highlightEnd = "\033[1m", "\033[0m"
+		}
+
+		results, err := notesRepo.Search(ctx, query, &notes.SearchOptions{
+			StartTime:      beginningTime,
+			EndTime:        endTime,
+			Limit:          limit,
+			HighlightStart: highlightStart,
+			HighlightEnd:   highlightEnd,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			err = writer.Write(result)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writer.Close()
+	},
+}
+
+// isTerminal reports whether the command's output goes straight to a terminal, rather than a pipe or a file
+func isTerminal(cmd *cobra.Command) bool {
+	file, ok := cmd.OutOrStdout().(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func init() {
+	rootCommand.AddCommand(searchNotesCommand)
+
+	searchNotesCommand.Flags().StringP("query", "q", "", `What to search for, "quoted phrases" and prefix* matches are supported`)
+	searchNotesCommand.Flags().StringP("start", "s", "", "Start of the time window (optional)")
+	searchNotesCommand.Flags().StringP("end", "e", "", "End of the time window (optional)")
+	searchNotesCommand.Flags().IntP("limit", "l", 20, "Maximum number of results, 0 for no limit")
+}
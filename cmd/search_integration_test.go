@@ -0,0 +1,85 @@
+//go:build sqlite_fts5
+
+package cmd
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegration_Search(t *testing.T) {
+	t.Run("adds a few notes and then searches them, then cleans up", func(t *testing.T) {
+		addedIDs := make([]int, 0)
+
+		for _, content := range []string{
+			"deployed the billing service",
+			"reviewed the billing dashboard",
+			"lunch with the deployment team",
+		} {
+			actual, err := runCommand([]string{"add-note", "-c", content})
+			require.NoError(t, err)
+
+			noteIDs, _ := getNoteDetails(actual)
+			require.Equal(t, 1, len(noteIDs))
+
+			addedIDs = append(addedIDs, noteIDs[0])
+		}
+
+		actual, err := runCommand([]string{"search-notes", "-q", "billing"})
+		assert.NoError(t, err)
+
+		noteIDs, noteContents := getNoteDetails(actual)
+		require.Equal(t, 2, len(noteIDs))
+		assert.ElementsMatch(t, []string{"deployed the [billing] service", "reviewed the [billing] dashboard"}, noteContents)
+
+		actual, err = runCommand([]string{"search-notes", "-q", "deploy*"})
+		assert.NoError(t, err)
+
+		_, noteContents = getNoteDetails(actual)
+		assert.ElementsMatch(t, []string{"[deployed] the billing service", "lunch with the [deployment] team"}, noteContents)
+
+		actual, err = runCommand([]string{"search-notes", "-q", `"billing dashboard"`})
+		assert.NoError(t, err)
+
+		_, noteContents = getNoteDetails(actual)
+		assert.Equal(t, []string{"reviewed the [billing dashboard]"}, noteContents)
+
+		actual, err = runCommand([]string{"search-notes", "-q", "billing", "-s", "tomorrow"})
+		assert.NoError(t, err)
+
+		noteIDs, _ = getNoteDetails(actual)
+		assert.Equal(t, 0, len(noteIDs))
+
+		for _, noteID := range addedIDs {
+			_, err = runCommand([]string{"delete-note", "-i", strconv.Itoa(noteID)})
+			assert.NoError(t, err)
+		}
+
+		actual, err = runCommand([]string{"search-notes", "-q", "billing"})
+		assert.NoError(t, err)
+
+		noteIDs, _ = getNoteDetails(actual)
+		assert.Equal(t, 0, len(noteIDs))
+	})
+
+	t.Run("drops dangling operators, and rejects a search starting with NOT", func(t *testing.T) {
+		db := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		_, code := executeCommand([]string{"add-note", "--db", db, "-c", "deployed the billing service"})
+		require.Equal(t, ExitOK, code)
+
+		actual, err := runCommand([]string{"search-notes", "--db", db, "-q", "billing OR"})
+		assert.NoError(t, err)
+
+		_, noteContents := getNoteDetails(actual)
+		assert.Equal(t, []string{"deployed the [billing] service"}, noteContents)
+
+		stderr, code := executeCommand([]string{"search-notes", "--db", db, "-q", "NOT billing"})
+		assert.Equal(t, ExitInvalidInput, code)
+		assert.Equal(t, "Error: a search can't start with NOT, put what to search for first\n", stderr)
+	})
+}
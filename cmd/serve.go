@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"note-logger/internal/config"
+	"note-logger/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+// shutdownTimeout is how long requests that are still going get to finish after being asked to stop
+const shutdownTimeout = 10 * time.Second
+
+var serveCommand = &cobra.Command{
+	Use:   "serve",
+	Short: "Serves a REST API for adding and listing notes over HTTP",
+	Long: `Serves a REST API for adding and listing notes over HTTP, until interrupted.
+
+Every request needs a token, as a bearer token in the Authorization header. The token comes from --token, then the
+NOTE_LOGGER_TOKEN env var, then the config file, and otherwise a random one gets generated and printed at startup.
+The API is described by the OpenAPI document served at /openapi.yaml.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return err
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		if addr == "" {
+			addr = cfg.Serve.Addr
+		}
+
+		if token == "" {
+			token = cfg.Serve.Token
+		}
+
+		if token == "" {
+			token, err = generateToken()
+			if err != nil {
+				return err
+			}
+
+			cmd.Printf("Generated a token for this session: %v\n", token)
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		handler, err := server.NewHandler(&server.Config{
+			Repo:  notesRepo,
+			Token: token,
+			Clock: currentApp.clock,
+		})
+		if err != nil {
+			return err
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Listening on http://%v\n", listener.Addr())
+
+		err = server.Serve(ctx, &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		}, listener, shutdownTimeout)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Server stopped.")
+
+		return nil
+	},
+}
+
+func generateToken() (string, error) {
+	token := make([]byte, 16)
+
+	_, err := rand.Read(token)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+func init() {
+	rootCommand.AddCommand(serveCommand)
+
+	serveCommand.Flags().String("addr", "", "Address to listen on (default "+config.DefaultServeAddr+")")
+	serveCommand.Flags().String("token", "", "Token that requests have to send")
+}
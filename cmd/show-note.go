@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var showNoteCommand = &cobra.Command{
+	Use:   "show-note",
+	Short: "Shows a single note in full, with its times and tags",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		noteID, err := cmd.Flags().GetInt64("id")
+		if err != nil {
+			return err
+		}
+
+		if noteID == 0 {
+			err := noteerrors.InvalidInput(errors.New("note ID required"))
+			return err
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		note, err := notesRepo.Get(ctx, noteID)
+		if err != nil {
+			return err
+		}
+
+		return output.WriteOne(cmd.OutOrStdout(), format, note, func(value interface{}) string {
+			return formatNoteDetails(value.(*entities.Note))
+		})
+	},
+}
+
+// formatNoteDetails formats a note over several lines, with everything known about it above the content
+func formatNoteDetails(note *entities.Note) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Note:    %v\n", note.ID)
+	fmt.Fprintf(&b, "Created: %v\n", inDisplayZone(note.CreatedAt).Format(time.RFC1123))
+
+	if note.UpdatedAt != nil {
+		fmt.Fprintf(&b, "Updated: %v\n", inDisplayZone(*note.UpdatedAt).Format(time.RFC1123))
+	}
+
+	if note.DeletedAt != nil {
+		fmt.Fprintf(&b, "Deleted: %v\n", inDisplayZone(*note.DeletedAt).Format(time.RFC1123))
+	}
+
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags:    %v\n", strings.Join(note.Tags, ", "))
+	}
+
+	b.WriteString("\n" + note.Content)
+
+	return b.String()
+}
+
+func init() {
+	rootCommand.AddCommand(showNoteCommand)
+
+	showNoteCommand.Flags().Int64P("id", "i", 0, "The ID of the note to show.")
+}
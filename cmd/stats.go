@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+	"note-logger/internal/repositories/notes"
+	"note-logger/internal/stats"
+	"note-logger/internal/timerange"
+
+	"github.com/spf13/cobra"
+)
+
+const statsDayLayout string = "Monday, 2006-01-02"
+
+// the heatmap covers a year of whole weeks when no period is given, the same as GitHub's
+const heatmapWeeks int = 52
+
+var statsCommand = &cobra.Command{
+	Use:   "stats [period]",
+	Short: "Shows how often notes get written, with a calendar heatmap",
+	Long: `Shows how many notes get written a day, a week and a month, the current and longest streaks of days with notes,
+how long the notes are and the words that come up most, with a heatmap of the days like GitHub's. The period is given
+the same way as for list-notes, and is the last year unless it's given.`,
+	Args: inputArgs(cobra.MaximumNArgs(1)),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		top, err := cmd.Flags().GetInt("top")
+		if err != nil {
+			return err
+		}
+
+		if top < 0 {
+			err := noteerrors.InvalidInputf("invalid number of top words %v, it can't be negative", top)
+			return err
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		window, err := timeWindow(cmd, args, heatmapYear)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		// the counts only need the times, which come straight from the created_at index
+		createdTimes, err := notesRepo.ListCreatedTimes(ctx, window.Start, window.End)
+		if err != nil {
+			return err
+		}
+
+		words := stats.NewWords()
+
+		err = notesRepo.ForEach(ctx, window.Start, window.End, &notes.TagFilter{}, func(note *entities.Note) error {
+			words.Add(note.Content)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		noteStats := stats.Build(createdTimes, window.Start, window.End, displayZone, words, top)
+
+		return output.WriteOne(cmd.OutOrStdout(), format, noteStats, textStats)
+	},
+}
+
+// heatmapYear is the window from the start of the week a year ago until now
+func heatmapYear(now time.Time) timerange.Range {
+	return timerange.Range{
+		Start: timerange.Week(now).Start.AddDate(0, 0, -7*heatmapWeeks),
+		End:   now,
+	}
+}
+
+func textStats(value interface{}) string {
+	noteStats := value.(*stats.Stats)
+
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "Notes from %v to %v\n\n", noteStats.Start.Format(statsDayLayout),
+		noteStats.End.Format(statsDayLayout))
+	fmt.Fprintf(&text, "%v\n", stats.Heatmap(noteStats))
+	fmt.Fprintf(&text, "Notes:          %v, on %v of %v days\n", noteStats.Total, noteStats.ActiveDays,
+		len(noteStats.Days))
+	fmt.Fprintf(&text, "Per day:        %.1f\n", noteStats.PerDay)
+	fmt.Fprintf(&text, "Per week:       %.1f\n", noteStats.PerWeek)
+	fmt.Fprintf(&text, "Per month:      %.1f\n", noteStats.PerMonth)
+	fmt.Fprintf(&text, "Current streak: %v\n", formatStreak(noteStats.CurrentStreak))
+	fmt.Fprintf(&text, "Longest streak: %v\n", formatStreak(noteStats.LongestStreak))
+	fmt.Fprintf(&text, "Average length: %.0f characters\n", noteStats.AverageLength)
+
+	topWords := make([]string, 0, len(noteStats.TopWords))
+	for _, word := range noteStats.TopWords {
+		topWords = append(topWords, fmt.Sprintf("%v (%v)", word.Word, word.Count))
+	}
+
+	if len(topWords) == 0 {
+		topWords = append(topWords, "none")
+	}
+
+	fmt.Fprintf(&text, "Top words:      %v", strings.Join(topWords, ", "))
+
+	return text.String()
+}
+
+func formatStreak(streak stats.Streak) string {
+	switch streak.Days {
+	case 0:
+		return "none"
+	case 1:
+		return "1 day, on " + streak.Start.Format(statsDayLayout)
+	}
+
+	return fmt.Sprintf("%v days, from %v to %v", streak.Days, streak.Start.Format(statsDayLayout),
+		streak.End.Format(statsDayLayout))
+}
+
+func init() {
+	rootCommand.AddCommand(statsCommand)
+
+	statsCommand.Flags().Int("top", 10, "How many of the words that come up most to show")
+	addTimeWindowFlags(statsCommand)
+}
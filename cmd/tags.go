@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"note-logger/internal/entities"
+
+	"github.com/spf13/cobra"
+)
+
+var tagsCommand = &cobra.Command{
+	Use:   "tags",
+	Short: "Lists every tag, and how many notes use it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		writer, err := newOutputWriter(cmd, func(value interface{}) string {
+			tagCount := value.(*entities.TagCount)
+
+			return fmt.Sprintf("%v: %v", tagCount.Name, tagCount.Count)
+		})
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		tagCounts, err := notesRepo.ListTags(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, tagCount := range tagCounts {
+			err = writer.Write(tagCount)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writer.Close()
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(tagsCommand)
+}
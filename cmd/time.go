@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/timerange"
+
+	"github.com/spf13/cobra"
+)
+
+// parseTime interprets English-friendly times like "beginning of week", "10 minutes ago" or "march 2026"
+func parseTime(value string) (time.Time, error) {
+	return timerange.ParseTime(value, currentApp.clock.Now())
+}
+
+// parseOptionalTime is parseTime, except that an empty value gives a zero time
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return parseTime(value)
+}
+
+// timeWindow works out the window of time that a command works on from whichever one of its time flags is used
+func timeWindow(cmd *cobra.Command, args []string,
+	fallback func(now time.Time) timerange.Range) (timerange.Range, error) {
+	now := currentApp.clock.Now()
+
+	startString, err := cmd.Flags().GetString("start")
+	if err != nil {
+		return timerange.Range{}, err
+	}
+
+	endString, err := cmd.Flags().GetString("end")
+	if err != nil {
+		return timerange.Range{}, err
+	}
+
+	lastString, err := cmd.Flags().GetString("last")
+	if err != nil {
+		return timerange.Range{}, err
+	}
+
+	var windows []func() (timerange.Range, error)
+
+	if len(args) > 0 {
+		windows = append(windows, func() (timerange.Range, error) {
+			return timerange.Parse(args[0], now)
+		})
+	}
+
+	if startString != "" || endString != "" {
+		windows = append(windows, func() (timerange.Range, error) {
+			return startEndWindow(startString, endString, now)
+		})
+	}
+
+	if lastString != "" {
+		windows = append(windows, func() (timerange.Range, error) {
+			return timerange.Last(lastString, now)
+		})
+	}
+
+	for _, shortcut := range []struct {
+		flag   string
+		window func(time.Time) timerange.Range
+	}{
+		{flag: "today", window: timerange.Today},
+		{flag: "yesterday", window: timerange.Yesterday},
+		{flag: "week", window: timerange.ThisWeek},
+		{flag: "month", window: timerange.ThisMonth},
+	} {
+		set, err := cmd.Flags().GetBool(shortcut.flag)
+		if err != nil {
+			return timerange.Range{}, err
+		}
+
+		if set {
+			window := shortcut.window
+			windows = append(windows, func() (timerange.Range, error) {
+				return window(now), nil
+			})
+		}
+	}
+
+	switch len(windows) {
+	case 0:
+		return fallback(now), nil
+	case 1:
+		return windows[0]()
+	default:
+		err := noteerrors.InvalidInput(errors.New(
+			"only one of a period, --start and --end, --today, --yesterday, --week, --month or --last can be used"))
+		return timerange.Range{}, err
+	}
+}
+
+func startEndWindow(startString string, endString string, now time.Time) (timerange.Range, error) {
+	start, err := parseOptionalTime(startString)
+	if err != nil {
+		return timerange.Range{}, err
+	}
+
+	end := now
+	if endString != "" {
+		end, err = parseTime(endString)
+		if err != nil {
+			return timerange.Range{}, err
+		}
+	}
+
+	return timerange.Range{Start: start, End: end}, nil
+}
+
+// addTimeWindowFlags adds the flags that timeWindow reads
+func addTimeWindowFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("start", "s", "", "Start of the time window, from the first note if there's only --end")
+	cmd.Flags().StringP("end", "e", "", "End of the time window, up until now if there's only --start")
+	cmd.Flags().Bool("today", false, "Today so far")
+	cmd.Flags().Bool("yesterday", false, "The whole of yesterday")
+	cmd.Flags().Bool("week", false, "This week so far, from Monday")
+	cmd.Flags().Bool("month", false, "This month so far")
+	cmd.Flags().String("last", "", "From this long ago until now, like 30m, 12h, 3d, 2w or 1mo")
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"note-logger/internal/config"
+	"note-logger/internal/noteerrors"
+
+	"github.com/spf13/cobra"
+)
+
+// the --tz values that aren't IANA zone names
+const (
+	localZone    string = "local"
+	originalZone string = "original"
+)
+
+// displayZone is where times are shown in the plain text output, with nil for each note's own offset
+var displayZone = time.Local
+
+// loadDisplayZone sets displayZone from the --tz flag, falling back to the config file
+func loadDisplayZone(cmd *cobra.Command) error {
+	tz, err := cmd.Flags().GetString("tz")
+	if err != nil {
+		return err
+	}
+
+	if tz == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		tz = cfg.TZ
+	}
+
+	displayZone, err = parseZone(tz)
+
+	return err
+}
+
+// parseZone reads a --tz value: local, original, or an IANA zone name like UTC or Europe/London
+func parseZone(tz string) (*time.Location, error) {
+	switch strings.ToLower(tz) {
+	case "", localZone:
+		return time.Local, nil
+	case originalZone:
+		return nil, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, noteerrors.InvalidInputf("unknown time zone '%v', use %v, %v or a name like Europe/London",
+			tz, localZone, originalZone)
+	}
+
+	return loc, nil
+}
+
+// inDisplayZone gives t in the zone it's to be shown in
+func inDisplayZone(t time.Time) time.Time {
+	if displayZone == nil {
+		return t
+	}
+
+	return t.In(displayZone)
+}
+
+// formatTime formats a time for the plain text output
+func formatTime(t time.Time) string {
+	return inDisplayZone(t).Format(timeLayout)
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"note-logger/internal/config"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+
+	"github.com/spf13/cobra"
+)
+
+var trashCommand = &cobra.Command{
+	Use:   "trash",
+	Short: "Lists, restores, and purges deleted notes",
+}
+
+var trashListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "Lists the notes in the trash",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		writer, err := newOutputWriter(cmd, func(value interface{}) string {
+			note := value.(*entities.Note)
+
+			return fmt.Sprintf("%v (deleted %v)", formatNote(note), formatTime(*note.DeletedAt))
+		})
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		trashedNotes, err := notesRepo.ListTrash(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, note := range trashedNotes {
+			err = writer.Write(note)
+			if err != nil {
+				return err
+			}
+		}
+
+		return writer.Close()
+	},
+}
+
+var trashRestoreCommand = &cobra.Command{
+	Use:   "restore",
+	Short: "Restores a note from the trash",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		noteID, err := cmd.Flags().GetInt64("id")
+		if err != nil {
+			return err
+		}
+
+		if noteID == 0 {
+			err := noteerrors.InvalidInput(errors.New("note ID required"))
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = notesRepo.Restore(ctx, noteID)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Note restored.")
+
+		return nil
+	},
+}
+
+var trashPurgeCommand = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently deletes the notes that have been in the trash for a while",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		olderThanString, err := cmd.Flags().GetString("older-than")
+		if err != nil {
+			return err
+		}
+
+		// without the flag, fall back to the retention from the config file
+		if olderThanString == "" {
+			cfg, err := config.Load()
+			if err != nil {
+				return err
+			}
+
+			olderThanString = cfg.TrashRetention
+		}
+
+		olderThan, err := parseTime(olderThanString)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		purged, err := notesRepo.Purge(ctx, olderThan)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Purged %v notes deleted before %v.\n", purged, formatTime(olderThan))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(trashCommand)
+
+	trashCommand.AddCommand(trashListCommand)
+	trashCommand.AddCommand(trashRestoreCommand)
+	trashCommand.AddCommand(trashPurgeCommand)
+
+	trashRestoreCommand.Flags().Int64P("id", "i", 0, "The ID of the note to restore.")
+	trashPurgeCommand.Flags().String("older-than", "", `Purge notes deleted before this time, e.g. "30 days ago" (defaults to the configured trash retention)`)
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+
+	"note-logger/internal/tui"
+
+	"github.com/spf13/cobra"
+)
+
+var tuiCommand = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse, filter, add, edit and delete notes interactively",
+	Long: `Browse, filter, add, edit and delete notes interactively, in a timeline grouped by day with the newest notes
+at the top. Notes are added and edited in $VISUAL or $EDITOR.
+
+Keys:
+  j/k, up/down    move between notes
+  pgup/pgdown     move half a page
+  g/G             jump to the newest or oldest note
+  /               filter the notes, with words like "standup" or tags like "#work"
+  esc             clear the filter
+  a               add a note
+  e, enter        edit the selected note
+  d               delete the selected note (moves it to the trash)
+  r               reload the notes
+  tab             show or hide the detail pane
+  q, ctrl+c       quit`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		return tui.Run(ctx, &tui.Config{Repo: notesRepo})
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(tuiCommand)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/output"
+	"note-logger/internal/repositories/notes"
+
+	"github.com/spf13/cobra"
+)
+
+var updateNoteCommand = &cobra.Command{
+	Use:   "update-note",
+	Short: "Update the contents or tags of an existing note",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		noteID, err := cmd.Flags().GetInt64("id")
+		if err != nil {
+			return err
+		}
+
+		if noteID == 0 {
+			err := noteerrors.InvalidInput(errors.New("note ID required"))
+			return err
+		}
+
+		noteLine, err := cmd.Flags().GetString("content")
+		if err != nil {
+			return err
+		}
+
+		tagFlags, err := cmd.Flags().GetStringSlice("tag")
+		if err != nil {
+			return err
+		}
+
+		untagFlags, err := cmd.Flags().GetStringSlice("untag")
+		if err != nil {
+			return err
+		}
+
+		// with only tags, the content stays as it is
+		if noteLine == "" && len(tagFlags) == 0 && len(untagFlags) == 0 {
+			err := noteerrors.InvalidInput(errors.New("note content or tags required"))
+			return err
+		}
+
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		notesRepo, err := currentApp.notesRepo(ctx)
+		if err != nil {
+			return err
+		}
+
+		note, err := notesRepo.Update(ctx, &entities.Note{
+			ID:      noteID,
+			Content: noteLine,
+			Tags:    tagFlags,
+		}, &notes.UpdateOptions{RemoveTags: untagFlags})
+		if err != nil {
+			return err
+		}
+
+		return output.WriteOne(cmd.OutOrStdout(), format, note, func(value interface{}) string {
+			return "Note updated:\n" + textNote(value)
+		})
+	},
+}
+
+func init() {
+	rootCommand.AddCommand(updateNoteCommand)
+
+	updateNoteCommand.Flags().Int64P("id", "i", 0, "The ID of the note to update.")
+	updateNoteCommand.Flags().StringP("content", "c", "", "The new note contents.")
+	updateNoteCommand.Flags().StringSliceP("tag", "t", nil, "Tags to add to the note.")
+	updateNoteCommand.Flags().StringSliceP("untag", "u", nil, "Tags to remove from the note.")
+}
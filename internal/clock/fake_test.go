@@ -0,0 +1,33 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2026, time.March, 14, 9, 30, 0, 0, time.UTC)
+
+	t.Run("frozen", func(t *testing.T) {
+		f := NewFake(start, 0)
+		assert.Equal(t, start, f.Now())
+		assert.Equal(t, start, f.Now())
+
+		f.Advance(time.Hour)
+		assert.Equal(t, start.Add(time.Hour), f.Now())
+
+		f.Set(start)
+		assert.Equal(t, start, f.Now())
+	})
+
+	t.Run("advancing", func(t *testing.T) {
+		f := NewFake(start, time.Second)
+		assert.Equal(t, start, f.Now())
+		assert.Equal(t, start.Add(time.Second), f.Now())
+
+		f.Advance(time.Minute)
+		assert.Equal(t, start.Add(time.Minute+2*time.Second), f.Now())
+	})
+}
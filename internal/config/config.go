@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"note-logger/internal/xdg"
+
+	"gopkg.in/yaml.v3"
+)
+
+const appDir string = "note-logger"
+const configFile string = "config.yaml"
+const templatesDir string = "templates"
+
+// DBEnvVar overrides the DB location set in the config file.
+const DBEnvVar string = "NOTE_LOGGER_DB"
+
+// StoreEnvVar overrides the store set in the config file, and the DB location too.
+const StoreEnvVar string = "NOTE_LOGGER_STORE"
+
+// TokenEnvVar overrides the API token for serve set in the config file.
+const TokenEnvVar string = "NOTE_LOGGER_TOKEN"
+
+// DefaultServeAddr is where serve listens, unless the config file says otherwise.
+const DefaultServeAddr string = "127.0.0.1:8080"
+
+// DefaultTrashRetention is how long notes stay in the trash before purging them, unless the config file says otherwise.
+const DefaultTrashRetention string = "30 days ago"
+
+type Config struct {
+	DB             string `yaml:"db"`
+	Store          string `yaml:"store"`
+	TrashRetention string `yaml:"trash_retention"`
+	BusyTimeout    string `yaml:"busy_timeout"`
+	TZ             string `yaml:"tz"`
+	Serve          Serve  `yaml:"serve"`
+}
+
+type Serve struct {
+	Addr  string `yaml:"addr"`
+	Token string `yaml:"token"`
+}
+
+// Load reads the config file, if there is one, and then applies any environment variable overrides.
+func Load() (*Config, error) {
+	cfg := &Config{
+		TrashRetention: DefaultTrashRetention,
+		Serve: Serve{
+			Addr: DefaultServeAddr,
+		},
+	}
+
+	filename, err := Filename()
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(contents, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// the DB env var has to win over a store in the config file, or it'd be ignored whenever one is set
+	if dbEnv := os.Getenv(DBEnvVar); dbEnv != "" {
+		cfg.DB = dbEnv
+		cfg.Store = ""
+	}
+
+	if storeEnv := os.Getenv(StoreEnvVar); storeEnv != "" {
+		cfg.Store = storeEnv
+	}
+
+	if tokenEnv := os.Getenv(TokenEnvVar); tokenEnv != "" {
+		cfg.Serve.Token = tokenEnv
+	}
+
+	cfg.DB, err = ExpandHome(cfg.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Filename is the location of the config file, $XDG_CONFIG_HOME/note-logger/config.yaml.
+func Filename() (string, error) {
+	configHome, err := xdg.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configHome, appDir, configFile), nil
+}
+
+// TemplateFilename is where the user's own version of a built-in template is kept.
+func TemplateFilename(name string) (string, error) {
+	configHome, err := xdg.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configHome, appDir, templatesDir, name), nil
+}
+
+// ExpandHome replaces a leading ~ with the user's home directory.
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Load(t *testing.T) {
+	writeConfig := func(t *testing.T, contents string) {
+		configHome := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", configHome)
+
+		err := os.MkdirAll(filepath.Join(configHome, appDir), 0o700)
+		require.NoError(t, err)
+
+		err = os.WriteFile(filepath.Join(configHome, appDir, configFile), []byte(contents), 0o600)
+		require.NoError(t, err)
+	}
+
+	t.Run("no config file", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		t.Setenv(DBEnvVar, "")
+		t.Setenv(StoreEnvVar, "")
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, &Config{
+			TrashRetention: DefaultTrashRetention,
+			Serve:          Serve{Addr: DefaultServeAddr},
+		}, cfg)
+	})
+
+	t.Run("reads the config file", func(t *testing.T) {
+		writeConfig(t, "db: /some/notes.sqlite\n")
+		t.Setenv(DBEnvVar, "")
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "/some/notes.sqlite", cfg.DB)
+	})
+
+	t.Run("reads the trash retention", func(t *testing.T) {
+		writeConfig(t, "trash_retention: 1 week ago\n")
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "1 week ago", cfg.TrashRetention)
+	})
+
+	t.Run("reads the busy timeout", func(t *testing.T) {
+		writeConfig(t, "busy_timeout: 10s\n")
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "10s", cfg.BusyTimeout)
+	})
+
+	t.Run("reads the serve settings, with the token env var taking precedence", func(t *testing.T) {
+		writeConfig(t, "serve:\n  addr: 0.0.0.0:9000\n  token: from-file\n")
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, Serve{Addr: "0.0.0.0:9000", Token: "from-file"}, cfg.Serve)
+
+		t.Setenv(TokenEnvVar, "from-env")
+
+		cfg, err = Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "from-env", cfg.Serve.Token)
+	})
+
+	t.Run("env var takes precedence over the config file", func(t *testing.T) {
+		writeConfig(t, "db: /some/notes.sqlite\n")
+		t.Setenv(DBEnvVar, "/other/notes.sqlite")
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "/other/notes.sqlite", cfg.DB)
+	})
+
+	t.Run("reads the store, with the env vars taking precedence", func(t *testing.T) {
+		writeConfig(t, "db: /some/notes.sqlite\nstore: jsonl:///some/notes.jsonl\n")
+		t.Setenv(DBEnvVar, "")
+		t.Setenv(StoreEnvVar, "")
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "jsonl:///some/notes.jsonl", cfg.Store)
+
+		t.Setenv(DBEnvVar, "/other/notes.sqlite")
+
+		cfg, err = Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "/other/notes.sqlite", cfg.DB)
+		assert.Equal(t, "", cfg.Store)
+
+		t.Setenv(StoreEnvVar, "dir:///other/notes")
+
+		cfg, err = Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "dir:///other/notes", cfg.Store)
+	})
+
+	t.Run("expands the home directory", func(t *testing.T) {
+		writeConfig(t, "db: ~/notes.sqlite\n")
+		t.Setenv(DBEnvVar, "")
+
+		home, err := os.UserHomeDir()
+		require.NoError(t, err)
+
+		cfg, err := Load()
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(home, "notes.sqlite"), cfg.DB)
+	})
+
+	t.Run("invalid config file", func(t *testing.T) {
+		writeConfig(t, "db: [not, a, string\n")
+
+		_, err := Load()
+		assert.Error(t, err)
+	})
+}
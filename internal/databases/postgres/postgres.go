@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	_ "github.com/lib/pq"
+)
+
+const createMigrationsTableQuery string = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+version INTEGER NOT NULL PRIMARY KEY,
+name TEXT NOT NULL,
+applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+`
+
+// the lock is held until the transaction ends, so machines starting up at the same time take turns migrating
+const lockMigrationsQuery string = `SELECT pg_advisory_xact_lock(4242001);`
+
+const getCurrentMigration string = `SELECT COALESCE(MAX(version), 0) FROM schema_migrations;`
+const setCurrentMigration string = `INSERT INTO schema_migrations (version, name) VALUES ($1, $2);`
+
+const createTableIfNotExistsQuery string = `
+CREATE TABLE IF NOT EXISTS notes (
+id BIGSERIAL NOT NULL PRIMARY KEY,
+created_at TIMESTAMPTZ NOT NULL,
+content TEXT NOT NULL
+);`
+
+const createIndexIfNotExistsQuery string = `
+CREATE INDEX IF NOT EXISTS created_at_index
+ON notes(created_at);
+`
+
+// the simple config doesn't stem words, which keeps the matches the same as the other backends
+const createNotesFTSQuery string = `
+ALTER TABLE notes ADD COLUMN content_tsv tsvector GENERATED ALWAYS AS (to_tsvector('simple', content)) STORED;
+
+CREATE INDEX IF NOT EXISTS notes_content_tsv_index
+ON notes USING GIN(content_tsv);
+`
+
+const createTagsTablesQuery string = `
+CREATE TABLE IF NOT EXISTS tags (
+id BIGSERIAL NOT NULL PRIMARY KEY,
+name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS note_tags (
+note_id BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+tag_id BIGINT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_tags_tag_id_index
+ON note_tags(tag_id);
+`
+
+const createNoteRevisionsQuery string = `
+ALTER TABLE notes ADD COLUMN updated_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS note_revisions (
+id BIGSERIAL NOT NULL PRIMARY KEY,
+note_id BIGINT NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+content TEXT NOT NULL,
+created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS note_revisions_note_id_index
+ON note_revisions(note_id);
+`
+
+const addNotesDeletedAtQuery string = `
+ALTER TABLE notes ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS deleted_at_index
+ON notes(deleted_at);
+`
+
+const addNotesCreatedOffsetQuery string = `
+ALTER TABLE notes ADD COLUMN created_offset INTEGER NOT NULL DEFAULT 0;
+`
+
+type migration struct {
+	migrationName  string
+	migrationQuery string
+}
+
+// migrations mirrors the SQLite migrations one for one, so the versions mean the same thing on both
+var migrations = []migration{
+	{migrationName: "create notes table", migrationQuery: createTableIfNotExistsQuery},
+	{migrationName: "add notes created_at index", migrationQuery: createIndexIfNotExistsQuery},
+	{migrationName: "add notes full-text search", migrationQuery: createNotesFTSQuery},
+	{migrationName: "create tags tables", migrationQuery: createTagsTablesQuery},
+	{migrationName: "add note revisions", migrationQuery: createNoteRevisionsQuery},
+	{migrationName: "add notes deleted_at for the trash", migrationQuery: addNotesDeletedAtQuery},
+	// TIMESTAMPTZ is already kept in UTC, so it's only the offset that's new
+	{
+		migrationName:  "store times in UTC, keeping the offset notes were created at",
+		migrationQuery: addNotesCreatedOffsetQuery,
+	},
+}
+
+// Config takes a lib/pq connection string
+type Config struct {
+	DSN string
+}
+
+func New(ctx context.Context, cfg *Config) (*sql.DB, error) {
+	if cfg.DSN == "" {
+		return nil, errors.New("missing DB connection string")
+	}
+
+	db, err := sql.Open("postgres", cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		//nolint
+		db.Close()
+
+		return nil, err
+	}
+
+	err = migrate(ctx, db)
+	if err != nil {
+		//nolint
+		db.Close()
+
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// migrate runs the migrations that haven't been run yet, all in one transaction under an advisory lock
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, createMigrationsTableQuery)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, lockMigrationsQuery)
+	if err != nil {
+		return err
+	}
+
+	var currentMigration int
+
+	err = tx.QueryRowContext(ctx, getCurrentMigration).Scan(&currentMigration)
+	if err != nil {
+		return err
+	}
+
+	requiredMigration := len(migrations)
+
+	if currentMigration >= requiredMigration {
+		return nil
+	}
+
+	log.Printf("Current DB version: %v, required DB version: %v\n", currentMigration, requiredMigration)
+
+	for migrationNum := currentMigration + 1; migrationNum <= requiredMigration; migrationNum++ {
+		err = execMigration(ctx, tx, migrationNum)
+		if err != nil {
+			log.Printf("Error running migration %v '%v'\n", migrationNum, migrations[migrationNum-1].migrationName)
+
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func execMigration(ctx context.Context, tx *sql.Tx, migrationNum int) error {
+	log.Printf("Running migration %v '%v'\n", migrationNum, migrations[migrationNum-1].migrationName)
+
+	_, err := tx.ExecContext(ctx, migrations[migrationNum-1].migrationQuery)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, setCurrentMigration, migrationNum, migrations[migrationNum-1].migrationName)
+
+	return err
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/tj/assert"
+)
+
+func TestPostgres_Migrate(t *testing.T) {
+	expectLocked := func(mockDB sqlmock.Sqlmock, currentMigration int) {
+		mockDB.ExpectExec(regexp.QuoteMeta(createMigrationsTableQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mockDB.ExpectBegin()
+		mockDB.ExpectExec(regexp.QuoteMeta(lockMigrationsQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		rows := sqlmock.NewRows([]string{"version"}).AddRow(currentMigration)
+
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).WillReturnRows(rows)
+	}
+
+	t.Run("from scratch", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, mockDB, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		expectLocked(mockDB, 0)
+
+		for i := 1; i <= len(migrations); i++ {
+			mockDB.ExpectExec(regexp.QuoteMeta(migrations[i-1].migrationQuery)).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			mockDB.ExpectExec(regexp.QuoteMeta(setCurrentMigration)).
+				WithArgs(i, migrations[i-1].migrationName).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		}
+
+		mockDB.ExpectCommit()
+
+		err = migrate(ctx, db)
+		assert.NoError(t, err)
+
+		err = mockDB.ExpectationsWereMet()
+		assert.NoError(t, err)
+	})
+
+	t.Run("has a rollback", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, mockDB, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		expectLocked(mockDB, 0)
+
+		mockDB.ExpectExec(regexp.QuoteMeta(createTableIfNotExistsQuery)).WillReturnError(errors.New("some sql error"))
+
+		mockDB.ExpectRollback()
+
+		err = migrate(ctx, db)
+		assert.Equal(t, errors.New("some sql error"), err)
+
+		err = mockDB.ExpectationsWereMet()
+		assert.NoError(t, err)
+	})
+
+	t.Run("behind by one", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, mockDB, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		expectLocked(mockDB, len(migrations)-1)
+
+		mockDB.ExpectExec(regexp.QuoteMeta(migrations[len(migrations)-1].migrationQuery)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		mockDB.ExpectExec(regexp.QuoteMeta(setCurrentMigration)).
+			WithArgs(len(migrations), migrations[len(migrations)-1].migrationName).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		mockDB.ExpectCommit()
+
+		err = migrate(ctx, db)
+		assert.NoError(t, err)
+
+		err = mockDB.ExpectationsWereMet()
+		assert.NoError(t, err)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		ctx := context.Background()
+
+		db, mockDB, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		expectLocked(mockDB, len(migrations))
+
+		mockDB.ExpectRollback()
+
+		err = migrate(ctx, db)
+		assert.NoError(t, err)
+
+		err = mockDB.ExpectationsWereMet()
+		assert.NoError(t, err)
+	})
+}
+
+func TestPostgres_MigrationsMirrorSQLite(t *testing.T) {
+	names := []string{
+		"create notes table",
+		"add notes created_at index",
+		"add notes full-text search",
+		"create tags tables",
+		"add note revisions",
+		"add notes deleted_at for the trash",
+		"store times in UTC, keeping the offset notes were created at",
+	}
+
+	assert.Equal(t, len(names), len(migrations))
+
+	for i, name := range names {
+		assert.Equal(t, name, migrations[i].migrationName)
+	}
+}
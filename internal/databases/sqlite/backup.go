@@ -0,0 +1,295 @@
+package sqlite
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const backupQuery string = `VACUUM INTO ?;`
+
+const integrityCheckQuery string = `PRAGMA integrity_check;`
+
+const backupPrefix string = "notes-"
+const backupTimeFormat string = "20060102-150405"
+const dbFileExtension string = ".sqlite"
+const gzipExtension string = ".gz"
+
+// BackupOptions says how to back up the DB. Backups into a directory are named after Time, and rotated with Keep.
+type BackupOptions struct {
+	Gzip bool
+	Keep int
+	Time time.Time
+}
+
+// Backup copies the DB to path with VACUUM INTO, which is safe while it's being written to, giving where it went
+func Backup(ctx context.Context, db *sql.DB, path string, opts *BackupOptions) (string, error) {
+	info, err := os.Stat(path)
+	isDir := err == nil && info.IsDir()
+
+	if opts.Keep > 0 && !isDir {
+		return "", fmt.Errorf("keeping the last %v backups needs a directory to back up into", opts.Keep)
+	}
+
+	filename := path
+
+	if isDir {
+		backupTime := opts.Time
+		if backupTime.IsZero() {
+			backupTime = time.Now()
+		}
+
+		filename = filepath.Join(path, backupPrefix+backupTime.Format(backupTimeFormat)+dbFileExtension)
+	}
+
+	if opts.Gzip && !strings.HasSuffix(filename, gzipExtension) {
+		filename += gzipExtension
+	}
+
+	tempFilename, err := tempFileNextTo(filename)
+	if err != nil {
+		return "", err
+	}
+
+	//nolint
+	defer os.Remove(tempFilename)
+
+	_, err = db.ExecContext(ctx, backupQuery, tempFilename)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Gzip {
+		gzipFilename, err := tempFileNextTo(filename)
+		if err != nil {
+			return "", err
+		}
+
+		//nolint
+		defer os.Remove(gzipFilename)
+
+		err = gzipFile(tempFilename, gzipFilename)
+		if err != nil {
+			return "", err
+		}
+
+		tempFilename = gzipFilename
+	}
+
+	err = os.Rename(tempFilename, filename)
+	if err != nil {
+		return "", err
+	}
+
+	if isDir && opts.Keep > 0 {
+		err = rotateBackups(path, opts.Keep)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return filename, nil
+}
+
+// rotateBackups removes the oldest of the timestamped backups in dir, leaving the newest keep of them
+func rotateBackups(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) {
+			continue
+		}
+
+		if strings.HasSuffix(name, dbFileExtension) || strings.HasSuffix(name, dbFileExtension+gzipExtension) {
+			backups = append(backups, name)
+		}
+	}
+
+	// the timestamps in the names sort oldest first
+	sort.Strings(backups)
+
+	for len(backups) > keep {
+		err = os.Remove(filepath.Join(dir, backups[0]))
+		if err != nil {
+			return err
+		}
+
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// Restore replaces the DB at filename with the backup at path, which can be gzipped, once the backup checks out
+func Restore(ctx context.Context, path string, filename string) error {
+	err := os.MkdirAll(filepath.Dir(filename), 0o700)
+	if err != nil {
+		return err
+	}
+
+	tempFilename, err := tempFileNextTo(filename)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer os.Remove(tempFilename)
+
+	err = copyBackup(path, tempFilename)
+	if err != nil {
+		return err
+	}
+
+	err = validateBackup(ctx, tempFilename)
+	if err != nil {
+		return fmt.Errorf("unable to restore %v: %w", path, err)
+	}
+
+	// a journal left behind by the old DB would get applied to the restored one
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		err = os.Remove(filename + suffix)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return os.Rename(tempFilename, filename)
+}
+
+// validateBackup checks that the file is a note-logger DB this version can work with, and that it isn't corrupt
+func validateBackup(ctx context.Context, filename string) error {
+	db, err := sql.Open("sqlite3", fileURI(filename))
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer db.Close()
+
+	var result string
+
+	err = db.QueryRowContext(ctx, integrityCheckQuery).Scan(&result)
+	if err != nil {
+		return err
+	}
+
+	if result != "ok" {
+		return fmt.Errorf("the backup failed its integrity check: %v", result)
+	}
+
+	version, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		return errors.New("the backup isn't a note-logger DB")
+	}
+
+	if version > LatestVersion() {
+		return fmt.Errorf("the backup is at version %v, which is newer than this version of note-logger knows about (%v)",
+			version, LatestVersion())
+	}
+
+	return nil
+}
+
+// copyBackup copies the backup at src over to dst, decompressing it if it's gzipped
+func copyBackup(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer in.Close()
+
+	reader := bufio.NewReader(in)
+
+	var backup io.Reader = reader
+
+	magic, err := reader.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+
+		//nolint
+		defer gzipReader.Close()
+
+		backup = gzipReader
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, backup)
+	if err != nil {
+		//nolint
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
+
+func gzipFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(out)
+
+	_, err = io.Copy(gzipWriter, in)
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+
+	if err != nil {
+		//nolint
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
+
+// tempFileNextTo creates an empty file in the same directory as filename, for renaming over it
+func tempFileNextTo(filename string) (string, error) {
+	temp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	return temp.Name(), temp.Close()
+}
@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func countNotes(t *testing.T, db *sql.DB) int {
+	var count int
+
+	err := db.QueryRow(`SELECT COUNT(*) FROM notes`).Scan(&count)
+	assert.NoError(t, err)
+
+	return count
+}
+
+func addTestNote(t *testing.T, db *sql.DB, content string) {
+	_, err := db.Exec(`INSERT INTO notes (created_at, content) VALUES(?,?)`, time.Now(), content)
+	assert.NoError(t, err)
+}
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func TestSQLite_Backup(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("backs up to a file and restores it", func(t *testing.T) {
+		db := openTestDB(t)
+		addTestNote(t, db, "first note")
+
+		backup := filepath.Join(t.TempDir(), "backup.sqlite")
+
+		written, err := Backup(ctx, db, backup, &BackupOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, backup, written)
+
+		addTestNote(t, db, "note after the backup")
+
+		filename := filepath.Join(t.TempDir(), dbFile)
+
+		err = Restore(ctx, backup, filename)
+		assert.NoError(t, err)
+
+		restored, err := New(ctx, &Config{Filename: filename})
+		assert.NoError(t, err)
+
+		defer restored.Close()
+
+		assert.Equal(t, 1, countNotes(t, restored))
+	})
+
+	t.Run("gzips the backup", func(t *testing.T) {
+		db := openTestDB(t)
+		addTestNote(t, db, "first note")
+
+		backup := filepath.Join(t.TempDir(), "backup.sqlite")
+
+		written, err := Backup(ctx, db, backup, &BackupOptions{Gzip: true})
+		assert.NoError(t, err)
+		assert.Equal(t, backup+".gz", written)
+
+		contents, err := os.ReadFile(written)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte{0x1f, 0x8b}, contents[:2])
+
+		filename := filepath.Join(t.TempDir(), dbFile)
+
+		err = Restore(ctx, written, filename)
+		assert.NoError(t, err)
+
+		restored, err := New(ctx, &Config{Filename: filename})
+		assert.NoError(t, err)
+
+		defer restored.Close()
+
+		assert.Equal(t, 1, countNotes(t, restored))
+	})
+
+	t.Run("names backups in a directory by time, keeping the newest", func(t *testing.T) {
+		db := openTestDB(t)
+
+		dir := t.TempDir()
+
+		err := os.WriteFile(filepath.Join(dir, "something else.txt"), []byte("not a backup"), 0o600)
+		assert.NoError(t, err)
+
+		start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+
+		for i := 0; i < 4; i++ {
+			_, err = Backup(ctx, db, dir, &BackupOptions{Gzip: i%2 == 1, Keep: 3, Time: start.Add(time.Duration(i) * time.Hour)})
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, []string{
+			"notes-20261018-100000.sqlite.gz",
+			"notes-20261018-110000.sqlite",
+			"notes-20261018-120000.sqlite.gz",
+			"something else.txt",
+		}, listDir(t, dir))
+	})
+
+	t.Run("keeping backups needs a directory", func(t *testing.T) {
+		db := openTestDB(t)
+
+		_, err := Backup(ctx, db, filepath.Join(t.TempDir(), "backup.sqlite"), &BackupOptions{Keep: 2})
+		assert.EqualError(t, err, "keeping the last 2 backups needs a directory to back up into")
+	})
+}
+
+func TestSQLite_Restore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("leaves the DB alone when the backup isn't a DB", func(t *testing.T) {
+		dir := t.TempDir()
+
+		backup := filepath.Join(dir, "backup.sqlite")
+
+		err := os.WriteFile(backup, []byte("definitely not a SQLite DB, but long enough to look at the header of"), 0o600)
+		assert.NoError(t, err)
+
+		filename := filepath.Join(dir, dbFile)
+
+		err = os.WriteFile(filename, []byte("current DB"), 0o600)
+		assert.NoError(t, err)
+
+		err = Restore(ctx, backup, filename)
+		assert.Error(t, err)
+
+		contents, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, "current DB", string(contents))
+
+		assert.Equal(t, []string{"backup.sqlite", dbFile}, listDir(t, dir))
+	})
+
+	t.Run("needs a note-logger DB", func(t *testing.T) {
+		dir := t.TempDir()
+
+		backup := filepath.Join(dir, "backup.sqlite")
+
+		db, err := New(ctx, &Config{Filename: backup, SkipMigrations: true})
+		assert.NoError(t, err)
+
+		_, err = db.Exec(`CREATE TABLE something (id INTEGER)`)
+		assert.NoError(t, err)
+		db.Close()
+
+		err = Restore(ctx, backup, filepath.Join(dir, dbFile))
+		assert.EqualError(t, err, "unable to restore "+backup+": the backup isn't a note-logger DB")
+	})
+
+	t.Run("needs a version this knows about", func(t *testing.T) {
+		dir := t.TempDir()
+
+		backup := filepath.Join(dir, "backup.sqlite")
+
+		db, err := New(ctx, &Config{Filename: backup})
+		assert.NoError(t, err)
+
+		_, err = db.Exec(setVersionQuery(LatestVersion() + 1))
+		assert.NoError(t, err)
+		db.Close()
+
+		err = Restore(ctx, backup, filepath.Join(dir, dbFile))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "which is newer than this version of note-logger knows about")
+	})
+}
@@ -0,0 +1,485 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const foreignKeyCheckQuery string = `PRAGMA foreign_key_check;`
+
+const deleteOrphanQuery string = `DELETE FROM %v WHERE rowid = ?;`
+
+const reindexQuery string = `REINDEX;`
+
+const checkNotesFTSQuery string = `INSERT INTO notes_fts(notes_fts) VALUES ('integrity-check');`
+
+const listTimestampsQuery string = `SELECT id, typeof(created_at), CAST(created_at AS TEXT) FROM %v ORDER BY id ASC;`
+
+const tableExistsQuery string = `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;`
+
+const pageCountQuery string = `PRAGMA page_count;`
+const pageSizeQuery string = `PRAGMA page_size;`
+const freelistCountQuery string = `PRAGMA freelist_count;`
+
+const vacuumQuery string = `VACUUM;`
+
+// fragmentedRatio is the share of free pages past which the DB is worth vacuuming
+const fragmentedRatio float64 = 0.1
+
+// The statuses of a Finding
+const (
+	StatusOK      = "ok"
+	StatusProblem = "problem"
+	StatusFixed   = "fixed"
+)
+
+// Finding is what one of the doctor's checks found
+type Finding struct {
+	Check   string   `json:"check"`
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+// DoctorOptions says whether the doctor should fix what it safely can
+type DoctorOptions struct {
+	Fix bool
+}
+
+// Doctor checks the DB over, reporting what each check finds. Unreadable timestamps only get reported, even with Fix,
+// as there's no telling what they should have been.
+func Doctor(ctx context.Context, db *sql.DB, opts *DoctorOptions) ([]*Finding, error) {
+	checks := []func(ctx context.Context, db *sql.DB, opts *DoctorOptions) (*Finding, error){
+		checkIntegrity,
+		checkForeignKeys,
+		checkVersion,
+		checkTimestamps,
+		checkSearchIndex,
+		checkSize,
+	}
+
+	findings := make([]*Finding, 0, len(checks))
+
+	for _, check := range checks {
+		finding, err := check(ctx, db, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		if finding != nil {
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings, nil
+}
+
+func integrityProblems(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, integrityCheckQuery)
+	if err != nil {
+		return nil, fmt.Errorf("the DB can't be read, and might need restoring from a backup: %w", err)
+	}
+
+	defer rows.Close()
+
+	var problems []string
+
+	for rows.Next() {
+		var result string
+
+		err = rows.Scan(&result)
+		if err != nil {
+			return nil, err
+		}
+
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+
+	return problems, rows.Err()
+}
+
+func checkIntegrity(ctx context.Context, db *sql.DB, opts *DoctorOptions) (*Finding, error) {
+	finding := &Finding{Check: "integrity", Status: StatusOK, Message: "passed"}
+
+	problems, err := integrityProblems(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Fix {
+		// rebuilding the indexes is always safe, and fixes any problems that are only in the indexes
+		_, err = db.ExecContext(ctx, reindexQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		finding.Message = "passed, and the indexes were rebuilt"
+
+		if len(problems) > 0 {
+			problems, err = integrityProblems(ctx, db)
+			if err != nil {
+				return nil, err
+			}
+
+			finding.Status = StatusFixed
+			finding.Message = "fixed by rebuilding the indexes"
+		}
+	}
+
+	if len(problems) > 0 {
+		finding.Status = StatusProblem
+		finding.Message = "failed, restoring a backup with db restore might be the only way to fix it"
+
+		if !opts.Fix {
+			finding.Message = "failed, run with --fix to rebuild the indexes, which might fix it"
+		}
+
+		finding.Details = problems
+	}
+
+	return finding, nil
+}
+
+type orphanRow struct {
+	table  string
+	rowID  int64
+	parent string
+}
+
+func checkForeignKeys(ctx context.Context, db *sql.DB, opts *DoctorOptions) (*Finding, error) {
+	finding := &Finding{Check: "foreign keys", Status: StatusOK, Message: "every row refers to one that exists"}
+
+	rows, err := db.QueryContext(ctx, foreignKeyCheckQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []*orphanRow
+
+	for rows.Next() {
+		var fkID int
+
+		orphan := &orphanRow{}
+
+		err = rows.Scan(&orphan.table, &orphan.rowID, &orphan.parent, &fkID)
+		if err != nil {
+			//nolint
+			rows.Close()
+
+			return nil, err
+		}
+
+		orphans = append(orphans, orphan)
+	}
+
+	//nolint
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(orphans) == 0 {
+		return finding, nil
+	}
+
+	for _, orphan := range orphans {
+		finding.Details = append(finding.Details,
+			fmt.Sprintf("%v row %v refers to a row in %v that's gone", orphan.table, orphan.rowID, orphan.parent))
+	}
+
+	if !opts.Fix {
+		finding.Status = StatusProblem
+		finding.Message = fmt.Sprintf("%v rows refer to rows that are gone, run with --fix to delete them", len(orphans))
+
+		return finding, nil
+	}
+
+	err = deleteOrphans(ctx, db, orphans)
+	if err != nil {
+		return nil, err
+	}
+
+	finding.Status = StatusFixed
+	finding.Message = fmt.Sprintf("deleted %v rows that referred to rows that are gone", len(orphans))
+
+	return finding, nil
+}
+
+// deleteOrphans deletes the rows the foreign keys would have, had they been on
+func deleteOrphans(ctx context.Context, db *sql.DB, orphans []*orphanRow) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	for _, orphan := range orphans {
+		// the table name comes from SQLite itself, so it's safe to put in the query
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(deleteOrphanQuery, orphan.table), orphan.rowID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func checkVersion(ctx context.Context, db *sql.DB, opts *DoctorOptions) (*Finding, error) {
+	finding := &Finding{Check: "migrations", Status: StatusOK}
+
+	version, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case version == LatestVersion():
+		finding.Message = fmt.Sprintf("the DB is up to date, at version %v", version)
+	case version > LatestVersion():
+		finding.Status = StatusProblem
+		finding.Message = fmt.Sprintf("the DB is at version %v, which is newer than this version of note-logger knows "+
+			"about (%v), so it needs upgrading", version, LatestVersion())
+	case opts.Fix:
+		err = migrate(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+
+		finding.Status = StatusFixed
+		finding.Message = fmt.Sprintf("migrated the DB from version %v to %v", version, LatestVersion())
+	default:
+		finding.Status = StatusProblem
+		finding.Message = fmt.Sprintf("the DB is at version %v, behind the latest (%v), run with --fix to migrate it",
+			version, LatestVersion())
+	}
+
+	return finding, nil
+}
+
+func checkTimestamps(ctx context.Context, db *sql.DB, _ *DoctorOptions) (*Finding, error) {
+	finding := &Finding{Check: "timestamps", Status: StatusOK, Message: "every created_at can be read"}
+
+	for _, table := range []string{"notes", "note_revisions"} {
+		exists, err := hasTable(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		details, err := unparseableTimestamps(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+
+		finding.Details = append(finding.Details, details...)
+	}
+
+	if len(finding.Details) > 0 {
+		finding.Status = StatusProblem
+		finding.Message = fmt.Sprintf("%v rows have a created_at that can't be read, which shows up as year 1, and "+
+			"needs fixing by hand", len(finding.Details))
+	}
+
+	return finding, nil
+}
+
+// unparseableTimestamps finds the rows with a created_at the SQLite driver quietly reads as the zero time
+func unparseableTimestamps(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(listTimestampsQuery, table))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var details []string
+
+	for rows.Next() {
+		var id int64
+
+		var valueType string
+
+		var value sql.NullString
+
+		err = rows.Scan(&id, &valueType, &value)
+		if err != nil {
+			return nil, err
+		}
+
+		if !timestampParses(valueType, value.String) {
+			details = append(details, fmt.Sprintf("%v row %v has created_at '%v'", table, id, value.String))
+		}
+	}
+
+	return details, rows.Err()
+}
+
+// timestampParses does what the SQLite driver does when reading a DATETIME column
+func timestampParses(valueType string, value string) bool {
+	_, ok := parseTimestamp(valueType, value, time.UTC)
+
+	return ok
+}
+
+func checkSearchIndex(ctx context.Context, db *sql.DB, opts *DoctorOptions) (*Finding, error) {
+	if !FTS5Enabled {
+		return nil, nil
+	}
+
+	// before the migration that adds it, there's no index to have yet
+	version, err := CurrentVersion(ctx, db)
+	if err != nil || version < searchIndexMigration {
+		return nil, err
+	}
+
+	complete, err := searchIndexComplete(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if !complete {
+		return missingSearchIndex(ctx, db, opts)
+	}
+
+	finding := &Finding{Check: "search index", Status: StatusOK, Message: "matches the notes"}
+
+	_, checkErr := db.ExecContext(ctx, checkNotesFTSQuery)
+
+	if opts.Fix {
+		_, err = db.ExecContext(ctx, rebuildNotesFTSQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		finding.Message = "matches the notes, and was rebuilt"
+
+		if checkErr != nil {
+			finding.Status = StatusFixed
+			finding.Message = "rebuilt, as it didn't match the notes"
+			finding.Details = []string{checkErr.Error()}
+		}
+
+		return finding, nil
+	}
+
+	if checkErr != nil {
+		finding.Status = StatusProblem
+		finding.Message = "doesn't match the notes, run with --fix to rebuild it"
+		finding.Details = []string{checkErr.Error()}
+	}
+
+	return finding, nil
+}
+
+// missingSearchIndex reports a search index that's missing its table or triggers
+func missingSearchIndex(ctx context.Context, db *sql.DB, opts *DoctorOptions) (*Finding, error) {
+	if !opts.Fix {
+		return &Finding{
+			Check:   "search index",
+			Status:  StatusProblem,
+			Message: "is missing, so searching fails or misses notes, run with --fix to create it",
+		}, nil
+	}
+
+	err := createSearchIndex(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Finding{Check: "search index", Status: StatusFixed, Message: "created, as it was missing"}, nil
+}
+
+func checkSize(ctx context.Context, db *sql.DB, opts *DoctorOptions) (*Finding, error) {
+	pageCount, pageSize, freePages, err := pageStats(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	finding := &Finding{Check: "size", Status: StatusOK, Message: describeSize(pageCount, pageSize, freePages)}
+
+	if pageCount == 0 || float64(freePages)/float64(pageCount) < fragmentedRatio {
+		return finding, nil
+	}
+
+	if !opts.Fix {
+		finding.Status = StatusProblem
+		finding.Message += ", run with --fix to vacuum it"
+
+		return finding, nil
+	}
+
+	_, err = db.ExecContext(ctx, vacuumQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	pageCount, pageSize, freePages, err = pageStats(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	finding.Status = StatusFixed
+	finding.Message = "vacuumed, and now " + describeSize(pageCount, pageSize, freePages)
+
+	return finding, nil
+}
+
+func pageStats(ctx context.Context, db *sql.DB) (int64, int64, int64, error) {
+	var pageCount, pageSize, freePages int64
+
+	for query, dest := range map[string]*int64{
+		pageCountQuery:     &pageCount,
+		pageSizeQuery:      &pageSize,
+		freelistCountQuery: &freePages,
+	} {
+		err := db.QueryRowContext(ctx, query).Scan(dest)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	return pageCount, pageSize, freePages, nil
+}
+
+func describeSize(pageCount int64, pageSize int64, freePages int64) string {
+	free := 0.0
+	if pageCount > 0 {
+		free = float64(freePages) / float64(pageCount) * 100
+	}
+
+	return fmt.Sprintf("%v in %v pages, %.0f%% of them free", formatBytes(pageCount*pageSize), pageCount, free)
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%v B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func hasTable(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var count int
+
+	err := db.QueryRowContext(ctx, tableExistsQuery, name).Scan(&count)
+
+	return count > 0, err
+}
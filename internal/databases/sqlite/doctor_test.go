@@ -0,0 +1,203 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func findingsByCheck(findings []*Finding) map[string]*Finding {
+	byCheck := make(map[string]*Finding)
+	for _, finding := range findings {
+		byCheck[finding.Check] = finding
+	}
+
+	return byCheck
+}
+
+func TestSQLite_Doctor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a healthy DB", func(t *testing.T) {
+		db := openTestDB(t)
+		addTestNote(t, db, "first note")
+
+		findings, err := Doctor(ctx, db, &DoctorOptions{})
+		assert.NoError(t, err)
+
+		for _, finding := range findings {
+			assert.Equal(t, StatusOK, finding.Status, finding.Check)
+		}
+
+		byCheck := findingsByCheck(findings)
+		assert.Equal(t, "passed", byCheck["integrity"].Message)
+		assert.Equal(t, "the DB is up to date, at version 7", byCheck["migrations"].Message)
+		assert.Regexp(t, `^\d+\.\d KiB in \d+ pages, 0% of them free$`, byCheck["size"].Message)
+
+		_, hasSearchIndex := byCheck["search index"]
+		assert.Equal(t, FTS5Enabled, hasSearchIndex)
+	})
+
+	t.Run("deletes rows referring to missing notes", func(t *testing.T) {
+		db := openTestDB(t)
+
+		// the orphans can only get in with the foreign keys off, which is per connection
+		db.SetMaxOpenConns(1)
+
+		_, err := db.Exec(`PRAGMA foreign_keys = OFF`)
+		assert.NoError(t, err)
+
+		_, err = db.Exec(`INSERT INTO tags (name) VALUES ('work')`)
+		assert.NoError(t, err)
+
+		_, err = db.Exec(`INSERT INTO note_tags (note_id, tag_id) VALUES (42, 1)`)
+		assert.NoError(t, err)
+
+		findings, err := Doctor(ctx, db, &DoctorOptions{})
+		assert.NoError(t, err)
+
+		finding := findingsByCheck(findings)["foreign keys"]
+		assert.Equal(t, StatusProblem, finding.Status)
+		assert.Equal(t, "1 rows refer to rows that are gone, run with --fix to delete them", finding.Message)
+		assert.Equal(t, []string{"note_tags row 1 refers to a row in notes that's gone"}, finding.Details)
+
+		findings, err = Doctor(ctx, db, &DoctorOptions{Fix: true})
+		assert.NoError(t, err)
+
+		finding = findingsByCheck(findings)["foreign keys"]
+		assert.Equal(t, StatusFixed, finding.Status)
+		assert.Equal(t, "deleted 1 rows that referred to rows that are gone", finding.Message)
+
+		findings, err = Doctor(ctx, db, &DoctorOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, StatusOK, findingsByCheck(findings)["foreign keys"].Status)
+	})
+
+	t.Run("migrates a DB that's behind", func(t *testing.T) {
+		db := openTestDB(t)
+
+		err := Migrate(ctx, db, &MigrateOptions{Target: 5})
+		assert.NoError(t, err)
+
+		findings, err := Doctor(ctx, db, &DoctorOptions{})
+		assert.NoError(t, err)
+
+		finding := findingsByCheck(findings)["migrations"]
+		assert.Equal(t, StatusProblem, finding.Status)
+		assert.Equal(t, "the DB is at version 5, behind the latest (7), run with --fix to migrate it", finding.Message)
+
+		findings, err = Doctor(ctx, db, &DoctorOptions{Fix: true})
+		assert.NoError(t, err)
+
+		finding = findingsByCheck(findings)["migrations"]
+		assert.Equal(t, StatusFixed, finding.Status)
+		assert.Equal(t, "migrated the DB from version 5 to 7", finding.Message)
+	})
+
+	t.Run("reports created_at values that can't be read", func(t *testing.T) {
+		db := openTestDB(t)
+		addTestNote(t, db, "first note")
+
+		_, err := db.Exec(`INSERT INTO notes (created_at, content) VALUES ('last tuesday', 'broken note')`)
+		assert.NoError(t, err)
+
+		for _, fix := range []bool{false, true} {
+			findings, err := Doctor(ctx, db, &DoctorOptions{Fix: fix})
+			assert.NoError(t, err)
+
+			finding := findingsByCheck(findings)["timestamps"]
+			assert.Equal(t, StatusProblem, finding.Status)
+			assert.Equal(t, []string{"notes row 2 has created_at 'last tuesday'"}, finding.Details)
+		}
+	})
+
+	t.Run("creates a missing search index", func(t *testing.T) {
+		if !FTS5Enabled {
+			t.Skip("needs the sqlite_fts5 build tag")
+		}
+
+		db := openTestDB(t)
+		addTestNote(t, db, "first note")
+
+		_, err := db.Exec(dropNotesFTSTriggersQuery + dropNotesFTSTableQuery)
+		assert.NoError(t, err)
+
+		addTestNote(t, db, "second note")
+
+		findings, err := Doctor(ctx, db, &DoctorOptions{})
+		assert.NoError(t, err)
+
+		finding := findingsByCheck(findings)["search index"]
+		assert.Equal(t, StatusProblem, finding.Status)
+		assert.Equal(t, "is missing, so searching fails or misses notes, run with --fix to create it", finding.Message)
+
+		findings, err = Doctor(ctx, db, &DoctorOptions{Fix: true})
+		assert.NoError(t, err)
+
+		finding = findingsByCheck(findings)["search index"]
+		assert.Equal(t, StatusFixed, finding.Status)
+		assert.Equal(t, "created, as it was missing", finding.Message)
+
+		assert.Equal(t, []int64{1, 2}, searchIDs(t, db, "note"))
+
+		findings, err = Doctor(ctx, db, &DoctorOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, StatusOK, findingsByCheck(findings)["search index"].Status)
+	})
+
+	t.Run("vacuums a fragmented DB", func(t *testing.T) {
+		db := openTestDB(t)
+
+		for i := 0; i < 200; i++ {
+			addTestNote(t, db, strings.Repeat("a long note ", 100))
+		}
+
+		_, err := db.Exec(`DELETE FROM notes`)
+		assert.NoError(t, err)
+
+		findings, err := Doctor(ctx, db, &DoctorOptions{})
+		assert.NoError(t, err)
+
+		finding := findingsByCheck(findings)["size"]
+		assert.Equal(t, StatusProblem, finding.Status)
+		assert.True(t, strings.HasSuffix(finding.Message, ", run with --fix to vacuum it"), finding.Message)
+
+		findings, err = Doctor(ctx, db, &DoctorOptions{Fix: true})
+		assert.NoError(t, err)
+
+		finding = findingsByCheck(findings)["size"]
+		assert.Equal(t, StatusFixed, finding.Status)
+		assert.Regexp(t, `^vacuumed, and now .* 0% of them free$`, finding.Message)
+	})
+
+	t.Run("a file that isn't a DB", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), dbFile)
+
+		err := os.WriteFile(filename, []byte(strings.Repeat("definitely not a SQLite DB ", 10)), 0o600)
+		assert.NoError(t, err)
+
+		_, err = New(ctx, &Config{Filename: filename})
+		assert.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "unable to open the DB at "+filename+", note-logger doctor might help"))
+
+		db, err := sql.Open("sqlite3", filename)
+		assert.NoError(t, err)
+
+		defer db.Close()
+
+		_, err = Doctor(ctx, db, &DoctorOptions{})
+		assert.Error(t, err)
+		assert.True(t, strings.HasPrefix(err.Error(), "the DB can't be read, and might need restoring from a backup"))
+	})
+}
+
+func TestSQLite_FormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "4.0 KiB", formatBytes(4096))
+	assert.Equal(t, "1.5 MiB", formatBytes(1536*1024))
+}
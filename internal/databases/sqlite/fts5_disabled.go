@@ -0,0 +1,6 @@
+//go:build !sqlite_fts5
+
+package sqlite
+
+// FTS5Enabled reports whether the SQLite driver was built with the sqlite_fts5 tag
+const FTS5Enabled = false
@@ -0,0 +1,451 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const createMigrationsTableQuery string = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+version INTEGER NOT NULL PRIMARY KEY,
+name TEXT NOT NULL,
+checksum TEXT NOT NULL,
+applied_at DATETIME NOT NULL
+);
+`
+
+const listAppliedMigrationsQuery string = `
+SELECT version, checksum, applied_at FROM schema_migrations ORDER BY version ASC
+`
+
+const insertAppliedMigrationQuery string = `
+INSERT OR REPLACE INTO schema_migrations (version, name, checksum, applied_at) VALUES(?,?,?,?);
+`
+
+const deleteAppliedMigrationQuery string = `
+DELETE FROM schema_migrations WHERE version = ?;
+`
+
+type migration struct {
+	migrationName  string
+	migrationQuery string
+	rollbackQuery  string
+	// migrationFunc can't be checksummed, so migrationFuncVersion has to be bumped whenever it changes
+	migrationFunc        func(ctx context.Context, tx *sql.Tx) error
+	migrationFuncVersion string
+	rollbackFunc         func(ctx context.Context, tx *sql.Tx) error
+	// only run in builds with FTS5
+	searchQuery         string
+	rollbackSearchQuery string
+}
+
+// upQuery is the SQL the migration runs in this build
+func (m *migration) upQuery() string {
+	if FTS5Enabled {
+		return m.migrationQuery + m.searchQuery
+	}
+
+	return m.migrationQuery
+}
+
+// downQuery is the SQL the rollback runs in this build
+func (m *migration) downQuery() string {
+	if FTS5Enabled {
+		return m.rollbackQuery + m.rollbackSearchQuery
+	}
+
+	return m.rollbackQuery
+}
+
+func (m *migration) reversible() bool {
+	return m.rollbackQuery != "" || m.rollbackFunc != nil
+}
+
+// checksum covers what the migration did to the DB when it was applied, for noticing it being changed since
+func (m *migration) checksum() string {
+	content := m.migrationName + "\x00" + m.migrationQuery
+
+	// the search SQL counts whether or not this build runs it, so the checksum is the same for every build
+	if m.searchQuery != "" {
+		content += "\x00" + m.searchQuery
+	}
+
+	if m.migrationFunc != nil {
+		content += "\x00func " + m.migrationFuncVersion
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+}
+
+// MigrationStatus is where the DB is at with one of the migrations
+type MigrationStatus struct {
+	Version    int        `json:"version"`
+	Name       string     `json:"name"`
+	Applied    bool       `json:"applied"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty"`
+	Reversible bool       `json:"reversible"`
+	// Changed is set when the migration no longer matches the checksum recorded when it was applied
+	Changed bool `json:"changed"`
+}
+
+// MigrateOptions says which version to migrate to, up or down, writing the SQL to Out instead with DryRun set
+type MigrateOptions struct {
+	Target int
+	DryRun bool
+	Out    io.Writer
+}
+
+// ErrMigrationChanged is returned when a migration that was already applied has been edited since
+var ErrMigrationChanged = errors.New("migration has been changed since it was applied")
+
+// LatestVersion is the version of a DB with every migration applied
+func LatestVersion() int {
+	return len(migrations)
+}
+
+// CurrentVersion is the number of migrations that have been applied to the DB
+func CurrentVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var currentMigration int
+
+	err := db.QueryRowContext(ctx, getCurrentMigration).Scan(&currentMigration)
+
+	return currentMigration, err
+}
+
+func migrate(ctx context.Context, db *sql.DB) error {
+	return Migrate(ctx, db, &MigrateOptions{Target: LatestVersion()})
+}
+
+// Migrate runs the migrations up or down to the target version, after checking the applied ones haven't changed
+func Migrate(ctx context.Context, db *sql.DB, opts *MigrateOptions) error {
+	if opts.Target < 0 || opts.Target > LatestVersion() {
+		return fmt.Errorf("there is no migration %v, the latest is %v", opts.Target, LatestVersion())
+	}
+
+	currentMigration, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if currentMigration > LatestVersion() {
+		return fmt.Errorf("the DB is at version %v, which is newer than this version of note-logger knows about (%v)",
+			currentMigration, LatestVersion())
+	}
+
+	// a rollback that can't get all the way to the target would leave the DB part way there
+	for migrationNum := currentMigration; migrationNum > opts.Target; migrationNum-- {
+		m := &migrations[migrationNum-1]
+
+		if !m.reversible() {
+			return fmt.Errorf("migration %v '%v' can't be rolled back", migrationNum, m.migrationName)
+		}
+	}
+
+	if opts.DryRun {
+		return printMigrations(currentMigration, opts)
+	}
+
+	err = checkMigrations(ctx, db, currentMigration)
+	if err != nil {
+		return err
+	}
+
+	if currentMigration != opts.Target {
+		log.Printf("Current DB version: %v, required DB version: %v\n", currentMigration, opts.Target)
+	}
+
+	up := currentMigration < opts.Target
+
+	for currentMigration != opts.Target {
+		nextMigration := currentMigration - 1
+
+		if up {
+			nextMigration = currentMigration + 1
+
+			err = execMigration(ctx, db, nextMigration, currentMigration)
+		} else {
+			err = execRollback(ctx, db, currentMigration)
+		}
+
+		// another process migrated the DB in the meantime, so carry on from wherever it got to
+		if errors.Is(err, errVersionChanged) {
+			currentMigration, err = CurrentVersion(ctx, db)
+			if err != nil {
+				return err
+			}
+
+			if currentMigration > LatestVersion() || (up && currentMigration > opts.Target) ||
+				(!up && currentMigration < opts.Target) {
+				return fmt.Errorf("another process migrated the DB to version %v while migrating it to %v",
+					currentMigration, opts.Target)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		currentMigration = nextMigration
+	}
+
+	return nil
+}
+
+// printMigrations writes out what migrating would do, without doing it
+func printMigrations(currentMigration int, opts *MigrateOptions) error {
+	for migrationNum := currentMigration + 1; migrationNum <= opts.Target; migrationNum++ {
+		m := &migrations[migrationNum-1]
+
+		err := printStep(opts.Out, migrationNum, "up", m.upQuery(), m.migrationFunc != nil, migrationNum)
+		if err != nil {
+			return err
+		}
+	}
+
+	for migrationNum := currentMigration; migrationNum > opts.Target; migrationNum-- {
+		m := &migrations[migrationNum-1]
+
+		err := printStep(opts.Out, migrationNum, "down", m.downQuery(), m.rollbackFunc != nil, migrationNum-1)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkMigrations compares the applied migrations with their recorded checksums, recording any that are missing
+func checkMigrations(ctx context.Context, db *sql.DB, currentMigration int) error {
+	_, err := db.ExecContext(ctx, createMigrationsTableQuery)
+	if err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for migrationNum := 1; migrationNum <= currentMigration; migrationNum++ {
+		m := &migrations[migrationNum-1]
+
+		record, ok := applied[migrationNum]
+		if !ok {
+			_, err = db.ExecContext(ctx, insertAppliedMigrationQuery, migrationNum, m.migrationName, m.checksum(),
+				time.Now())
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if record.checksum != m.checksum() {
+			return fmt.Errorf("%w: %v '%v'", ErrMigrationChanged, migrationNum, m.migrationName)
+		}
+	}
+
+	return nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+func appliedMigrations(ctx context.Context, db *sql.DB) (map[int]*appliedMigration, error) {
+	rows, err := db.QueryContext(ctx, listAppliedMigrationsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[int]*appliedMigration)
+
+	for rows.Next() {
+		var version int
+
+		record := &appliedMigration{}
+
+		err = rows.Scan(&version, &record.checksum, &record.appliedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		applied[version] = record
+	}
+
+	return applied, rows.Err()
+}
+
+// Statuses gives every migration along with whether it has been applied to the DB
+func Statuses(ctx context.Context, db *sql.DB) ([]*MigrationStatus, error) {
+	currentMigration, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.ExecContext(ctx, createMigrationsTableQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(migrations))
+
+	for i := range migrations {
+		m := &migrations[i]
+
+		status := &MigrationStatus{
+			Version:    i + 1,
+			Name:       m.migrationName,
+			Applied:    i+1 <= currentMigration,
+			Reversible: m.reversible(),
+		}
+
+		if record, ok := applied[i+1]; ok && status.Applied {
+			appliedAt := record.appliedAt
+			status.AppliedAt = &appliedAt
+			status.Changed = record.checksum != m.checksum()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// errVersionChanged is returned for a migration step when the DB is no longer at the version the step starts from
+var errVersionChanged = errors.New("the DB version changed while migrating")
+
+func execMigration(ctx context.Context, db *sql.DB, migrationNum int, currentMigration int) error {
+	m := &migrations[migrationNum-1]
+
+	log.Printf("Running migration %v '%v'\n", migrationNum, m.migrationName)
+
+	err := execStep(ctx, db, currentMigration, migrationNum, m.upQuery(), m.migrationFunc, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, insertAppliedMigrationQuery, migrationNum, m.migrationName, m.checksum(),
+			time.Now())
+
+		return err
+	})
+	if err != nil && !errors.Is(err, errVersionChanged) {
+		log.Printf("Error running migration %v '%v'\n", migrationNum, m.migrationName)
+	}
+
+	return err
+}
+
+func execRollback(ctx context.Context, db *sql.DB, migrationNum int) error {
+	m := &migrations[migrationNum-1]
+
+	if !m.reversible() {
+		return fmt.Errorf("migration %v '%v' can't be rolled back", migrationNum, m.migrationName)
+	}
+
+	log.Printf("Rolling back migration %v '%v'\n", migrationNum, m.migrationName)
+
+	err := execStep(ctx, db, migrationNum, migrationNum-1, m.downQuery(), m.rollbackFunc, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, deleteAppliedMigrationQuery, migrationNum)
+
+		return err
+	})
+	if err != nil && !errors.Is(err, errVersionChanged) {
+		log.Printf("Error rolling back migration %v '%v'\n", migrationNum, m.migrationName)
+	}
+
+	return err
+}
+
+// execStep runs a migration step and records the new version in one transaction, which takes the write lock as it
+// begins, so two processes can't both run the same step
+func execStep(ctx context.Context, db *sql.DB, oldVersion int, newVersion int, query string,
+	fn func(ctx context.Context, tx *sql.Tx) error, record func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	var version int
+
+	err = tx.QueryRowContext(ctx, getCurrentMigration).Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	if version != oldVersion {
+		return errVersionChanged
+	}
+
+	if query != "" {
+		_, err = tx.ExecContext(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	if fn != nil {
+		err = fn(ctx, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, setVersionQuery(newVersion))
+	if err != nil {
+		return err
+	}
+
+	err = record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func setVersionQuery(version int) string {
+	return strings.Replace(setCurrentMigration, "?", strconv.Itoa(version), 1)
+}
+
+func printStep(out io.Writer, migrationNum int, direction string, query string, hasFunc bool, newVersion int) error {
+	m := &migrations[migrationNum-1]
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "-- migration %v '%v' (%v)\n", migrationNum, m.migrationName, direction)
+	b.WriteString("BEGIN;\n")
+
+	if strings.TrimSpace(query) != "" {
+		b.WriteString(strings.TrimSpace(query) + "\n")
+	}
+
+	if hasFunc {
+		b.WriteString("-- followed by Go code, which can't be shown here\n")
+	}
+
+	b.WriteString(strings.TrimSpace(setVersionQuery(newVersion)) + "\n")
+	b.WriteString("COMMIT;\n\n")
+
+	_, err := io.WriteString(out, b.String())
+
+	return err
+}
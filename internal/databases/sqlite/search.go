@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+// the notes_fts table is an external content table, so the triggers have to keep it in sync with notes
+const createNotesFTSTableQuery string = `
+CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(content, content='notes', content_rowid='id');
+`
+
+const createNotesFTSTriggersQuery string = `
+CREATE TRIGGER IF NOT EXISTS notes_fts_after_insert AFTER INSERT ON notes BEGIN
+  INSERT INTO notes_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS notes_fts_after_delete AFTER DELETE ON notes BEGIN
+  INSERT INTO notes_fts(notes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS notes_fts_after_update AFTER UPDATE OF content ON notes BEGIN
+  INSERT INTO notes_fts(notes_fts, rowid, content) VALUES ('delete', old.id, old.content);
+  INSERT INTO notes_fts(rowid, content) VALUES (new.id, new.content);
+END;
+`
+
+const rebuildNotesFTSQuery string = `INSERT INTO notes_fts(notes_fts) VALUES ('rebuild');`
+
+const createNotesFTSQuery string = createNotesFTSTableQuery + createNotesFTSTriggersQuery + rebuildNotesFTSQuery
+
+// the triggers can be dropped without FTS5, but the table itself can't
+const dropNotesFTSTriggersQuery string = `
+DROP TRIGGER IF EXISTS notes_fts_after_insert;
+DROP TRIGGER IF EXISTS notes_fts_after_delete;
+DROP TRIGGER IF EXISTS notes_fts_after_update;
+`
+
+const dropNotesFTSTableQuery string = `
+DROP TABLE IF EXISTS notes_fts;
+`
+
+const countSearchIndexObjectsQuery string = `
+SELECT COUNT(*) FROM sqlite_master
+WHERE (type = 'table' AND name = 'notes_fts') OR (type = 'trigger' AND name LIKE 'notes_fts_after_%');
+`
+
+// searchIndexObjects is how many objects make up a complete search index, the table and its three triggers
+const searchIndexObjects int = 4
+
+// searchIndexMigration is the migration that adds the search index
+const searchIndexMigration int = 3
+
+// searchIndexComplete reports whether notes_fts and all of its triggers are there
+func searchIndexComplete(ctx context.Context, db *sql.DB) (bool, error) {
+	var count int
+
+	err := db.QueryRowContext(ctx, countSearchIndexObjectsQuery).Scan(&count)
+
+	return count == searchIndexObjects, err
+}
+
+// syncSearchIndex makes the search index match the build. Without FTS5 every write would fail on the triggers, and
+// with it a missing index gets rebuilt.
+func syncSearchIndex(ctx context.Context, db *sql.DB) error {
+	version, err := CurrentVersion(ctx, db)
+	if err != nil || version < searchIndexMigration {
+		return err
+	}
+
+	if !FTS5Enabled {
+		_, err = db.ExecContext(ctx, dropNotesFTSTriggersQuery)
+
+		return err
+	}
+
+	complete, err := searchIndexComplete(ctx, db)
+	if err != nil || complete {
+		return err
+	}
+
+	return createSearchIndex(ctx, db)
+}
+
+// createSearchIndex creates whatever is missing of the search index and fills it from the notes
+func createSearchIndex(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, createNotesFTSQuery)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
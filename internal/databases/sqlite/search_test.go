@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func searchIDs(t *testing.T, db *sql.DB, query string) []int64 {
+	rows, err := db.Query(`SELECT rowid FROM notes_fts WHERE notes_fts MATCH ? ORDER BY rowid`, query)
+	assert.NoError(t, err)
+
+	defer rows.Close()
+
+	var ids []int64
+
+	for rows.Next() {
+		var id int64
+
+		assert.NoError(t, rows.Scan(&id))
+
+		ids = append(ids, id)
+	}
+
+	assert.NoError(t, rows.Err())
+
+	return ids
+}
+
+func countSearchTriggers(t *testing.T, db *sql.DB) int {
+	var count int
+
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'notes_fts_%'`).
+		Scan(&count)
+	assert.NoError(t, err)
+
+	return count
+}
+
+// the DBs in testdata were made with and without FTS5, each with the notes 'first note' and 'second note'
+func TestSQLite_SearchIndex(t *testing.T) {
+	ctx := context.Background()
+
+	for _, fixture := range []string{"fts5.sqlite", "plain.sqlite"} {
+		fixture := fixture
+
+		t.Run("opens a DB made by the "+fixture+" build", func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", fixture))
+			assert.NoError(t, err)
+
+			filename := filepath.Join(t.TempDir(), dbFile)
+			assert.NoError(t, os.WriteFile(filename, data, 0o600))
+
+			db := openTestDBAt(t, filename)
+
+			addTestNote(t, db, "third note")
+
+			_, err = db.Exec(`UPDATE notes SET content = 'first edited' WHERE id = 1`)
+			assert.NoError(t, err)
+
+			if !FTS5Enabled {
+				assert.Equal(t, 0, countSearchTriggers(t, db))
+
+				return
+			}
+
+			assert.Equal(t, 3, countSearchTriggers(t, db))
+			assert.Equal(t, []int64{1}, searchIDs(t, db, "edited"))
+			assert.Equal(t, []int64{2}, searchIDs(t, db, "second"))
+			assert.Equal(t, []int64{3}, searchIDs(t, db, "third"))
+			assert.Empty(t, searchIDs(t, db, "first note"))
+
+			_, err = db.ExecContext(ctx, checkNotesFTSQuery)
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("rebuilds an index that missed writes", func(t *testing.T) {
+		if !FTS5Enabled {
+			t.Skip("needs the sqlite_fts5 build tag")
+		}
+
+		filename := filepath.Join(t.TempDir(), dbFile)
+
+		db := openTestDBAt(t, filename)
+		addTestNote(t, db, "first note")
+
+		// what a build without FTS5 does on opening the DB, before writing to it
+		_, err := db.Exec(dropNotesFTSTriggersQuery)
+		assert.NoError(t, err)
+
+		addTestNote(t, db, "second note")
+		assert.NoError(t, db.Close())
+
+		db = openTestDBAt(t, filename)
+		assert.Equal(t, []int64{2}, searchIDs(t, db, "second"))
+	})
+}
@@ -3,13 +3,21 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
+	"time"
+
+	"note-logger/internal/xdg"
+
+	_ "github.com/mattn/go-sqlite3"
 )
 
+const appDir string = "note-logger"
 const dbFile string = "notes.sqlite"
 
 const getCurrentMigration string = `PRAGMA user_version;`
@@ -27,18 +35,121 @@ CREATE INDEX IF NOT EXISTS created_at_index
 ON notes(created_at);
 `
 
-type migration struct {
-	migrationName  string
-	migrationQuery string
-}
+const createTagsTablesQuery string = `
+CREATE TABLE IF NOT EXISTS tags (
+id INTEGER NOT NULL PRIMARY KEY,
+name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS note_tags (
+note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+PRIMARY KEY (note_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS note_tags_tag_id_index
+ON note_tags(tag_id);
+`
+
+const createNoteRevisionsQuery string = `
+ALTER TABLE notes ADD COLUMN updated_at DATETIME;
+
+CREATE TABLE IF NOT EXISTS note_revisions (
+id INTEGER NOT NULL PRIMARY KEY,
+note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE,
+content TEXT,
+created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS note_revisions_note_id_index
+ON note_revisions(note_id);
+`
+
+const addNotesDeletedAtQuery string = `
+ALTER TABLE notes ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS deleted_at_index
+ON notes(deleted_at);
+`
+
+const dropIndexQuery string = `
+DROP INDEX IF EXISTS created_at_index;
+`
+
+const dropTagsTablesQuery string = `
+DROP TABLE note_tags;
+DROP TABLE tags;
+`
+
+const dropNoteRevisionsQuery string = `
+DROP TABLE note_revisions;
+ALTER TABLE notes DROP COLUMN updated_at;
+`
+
+const dropNotesDeletedAtQuery string = `
+DROP INDEX IF EXISTS deleted_at_index;
+ALTER TABLE notes DROP COLUMN deleted_at;
+`
 
 var migrations = []migration{
-	{migrationName: "create notes table", migrationQuery: createTableIfNotExistsQuery},
-	{migrationName: "add notes created_at index", migrationQuery: createIndexIfNotExistsQuery},
+	{
+		// there's no rolling back to an empty DB, as it would take every note with it
+		migrationName:  "create notes table",
+		migrationQuery: createTableIfNotExistsQuery,
+	},
+	{
+		migrationName:  "add notes created_at index",
+		migrationQuery: createIndexIfNotExistsQuery,
+		rollbackQuery:  dropIndexQuery,
+	},
+	{
+		migrationName:       "add notes full-text search",
+		searchQuery:         createNotesFTSQuery,
+		rollbackQuery:       dropNotesFTSTriggersQuery,
+		rollbackSearchQuery: dropNotesFTSTableQuery,
+	},
+	{
+		migrationName:  "create tags tables",
+		migrationQuery: createTagsTablesQuery,
+		rollbackQuery:  dropTagsTablesQuery,
+	},
+	{
+		migrationName:  "add note revisions",
+		migrationQuery: createNoteRevisionsQuery,
+		rollbackQuery:  dropNoteRevisionsQuery,
+	},
+	{
+		migrationName:  "add notes deleted_at for the trash",
+		migrationQuery: addNotesDeletedAtQuery,
+		rollbackQuery:  dropNotesDeletedAtQuery,
+	},
+	{
+		migrationName:        "store times in UTC, keeping the offset notes were created at",
+		migrationQuery:       addNotesCreatedOffsetQuery,
+		migrationFunc:        timestampsToUTC,
+		migrationFuncVersion: "1",
+		rollbackFunc:         timestampsToLocal,
+	},
+}
+
+// DefaultBusyTimeout is how long a write waits for another process to finish with the DB before giving up
+const DefaultBusyTimeout = 5 * time.Second
+
+// Config opens the DB as it is with SkipMigrations set, and waits DefaultBusyTimeout without a BusyTimeout
+type Config struct {
+	Filename       string
+	SkipMigrations bool
+	BusyTimeout    time.Duration
 }
 
-func New(ctx context.Context) (*sql.DB, error) {
-	filename, err := DBFilename()
+func New(ctx context.Context, cfg *Config) (*sql.DB, error) {
+	if cfg.Filename == "" {
+		return nil, errors.New("missing DB filename")
+	}
+
+	filename := cfg.Filename
+
+	err := os.MkdirAll(filepath.Dir(filename), 0o700)
 	if err != nil {
 		return nil, err
 	}
@@ -48,87 +159,134 @@ func New(ctx context.Context) (*sql.DB, error) {
 		return nil, err
 	}
 
-	db, err := sql.Open("sqlite3", filename)
+	busyTimeout := cfg.BusyTimeout
+	if busyTimeout <= 0 {
+		busyTimeout = DefaultBusyTimeout
+	}
+
+	// foreign keys clean up the tags of deleted notes, and with transactions taking the write lock as they begin the
+	// busy timeout covers every write
+	db, err := sql.Open("sqlite3", fmt.Sprintf("%v?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=%v&_txlock=immediate",
+		fileURI(filename), busyTimeout.Milliseconds()))
 	if err != nil {
 		return nil, err
 	}
 
+	if cfg.SkipMigrations {
+		return db, nil
+	}
+
 	err = migrate(ctx, db)
 	if err != nil {
-		return nil, err
+		//nolint
+		db.Close()
+
+		return nil, fmt.Errorf("unable to open the DB at %v, note-logger doctor might help: %w", filename, err)
+	}
+
+	err = syncSearchIndex(ctx, db)
+	if err != nil {
+		//nolint
+		db.Close()
+
+		return nil, fmt.Errorf("unable to set up searching the DB at %v: %w", filename, err)
 	}
 
 	return db, nil
 }
 
-func migrate(ctx context.Context, db *sql.DB) error {
-	var currentMigration int
-
-	row := db.QueryRowContext(ctx, getCurrentMigration)
+// fileURI turns filename into a URI for the driver, escaping any ? or % in it
+func fileURI(filename string) string {
+	return "file:" + (&url.URL{Path: filename}).EscapedPath()
+}
 
-	err := row.Scan(&currentMigration)
+// DefaultFilename is where the DB lives when no other location is configured, $XDG_DATA_HOME/note-logger/notes.sqlite.
+func DefaultFilename() (string, error) {
+	dataHome, err := xdg.DataHome()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	requiredMigration := len(migrations)
-
-	if currentMigration < requiredMigration {
-		log.Printf("Current DB version: %v, required DB version: %v\n", currentMigration, requiredMigration)
-
-		for migrationNum := currentMigration + 1; migrationNum <= requiredMigration; migrationNum++ {
-			err = execMigration(ctx, db, migrationNum)
-			if err != nil {
-				log.Printf("Error running migration %v '%v'\n", migrationNum, migrations[migrationNum-1].migrationName)
+	return filepath.Join(dataHome, appDir, dbFile), nil
+}
 
-				return err
-			}
-		}
+// LegacyFilename is where older versions kept the DB, right next to the binary.
+func LegacyFilename() (string, error) {
+	ex, err := os.Executable()
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return filepath.Join(filepath.Dir(ex), dbFile), nil
 }
 
-func execMigration(ctx context.Context, db *sql.DB, migrationNum int) error {
-	log.Printf("Running migration %v '%v'\n", migrationNum, migrations[migrationNum-1].migrationName)
-
-	tx, err := db.BeginTx(ctx, nil)
+// MoveLegacyDB moves a DB left next to the binary over to filename, if there's nothing there yet, reporting if it did
+func MoveLegacyDB(filename string) (bool, error) {
+	legacyFilename, err := LegacyFilename()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	//nolint
-	defer tx.Rollback()
+	if legacyFilename == filename || !fileExists(legacyFilename) || fileExists(filename) {
+		return false, nil
+	}
 
-	_, err = tx.ExecContext(ctx, migrations[migrationNum-1].migrationQuery)
+	err = os.MkdirAll(filepath.Dir(filename), 0o700)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	setQuery := strings.Replace(setCurrentMigration, "?", strconv.Itoa(migrationNum), 1)
+	// a plain rename fails across filesystems, so fall back to copying
+	err = os.Rename(legacyFilename, filename)
+	if err == nil {
+		return true, nil
+	}
 
-	_, err = tx.ExecContext(ctx, setQuery)
+	err = copyFile(legacyFilename, filename)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	err = tx.Commit()
+	// the binary might be installed somewhere read-only, in which case the old copy just stays behind
+	err = os.Remove(legacyFilename)
 	if err != nil {
-		return err
+		log.Printf("Unable to remove old DB at %v: %v\n", legacyFilename, err)
 	}
 
-	return nil
+	return true, nil
 }
 
-func DBFilename() (string, error) {
-	ex, err := os.Executable()
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+
+	return err == nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	exPath := filepath.Dir(ex)
+	//nolint
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		//nolint
+		out.Close()
+		//nolint
+		os.Remove(dst)
+
+		return err
+	}
 
-	return exPath + "/" + dbFile, nil
+	return out.Close()
 }
 
 func touchDBFile(filename string) error {
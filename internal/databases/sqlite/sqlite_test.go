@@ -2,90 +2,129 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/tj/assert"
 )
 
 func TestSQLite_Migrate(t *testing.T) {
-	t.Run("from scratch", func(t *testing.T) {
+	t.Run("has a rollback", func(t *testing.T) {
 		ctx := context.Background()
 
 		db, mockDB, err := sqlmock.New()
 		assert.NoError(t, err)
 
-		rows := sqlmock.NewRows([]string{"user_version"}).AddRow(0)
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(0))
 
-		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).WillReturnRows(rows)
+		mockDB.ExpectExec(regexp.QuoteMeta(createMigrationsTableQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
 
-		for i := 1; i <= len(migrations); i++ {
-			mockDB.ExpectBegin()
+		mockDB.ExpectQuery(regexp.QuoteMeta(listAppliedMigrationsQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}))
 
-			mockDB.ExpectExec(regexp.QuoteMeta(migrations[i-1].migrationQuery)).
-				WillReturnResult(sqlmock.NewResult(1, 1))
+		mockDB.ExpectBegin()
 
-			setQuery := strings.Replace(setCurrentMigration, "?", strconv.Itoa(i), 1)
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(0))
 
-			mockDB.ExpectExec(regexp.QuoteMeta(setQuery)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mockDB.ExpectExec(regexp.QuoteMeta(createTableIfNotExistsQuery)).WillReturnError(errors.New("some sql error"))
 
-			mockDB.ExpectCommit()
-		}
+		mockDB.ExpectRollback()
 
 		err = migrate(ctx, db)
-		assert.NoError(t, err)
+		assert.Equal(t, errors.New("some sql error"), err)
 
 		err = mockDB.ExpectationsWereMet()
 		assert.NoError(t, err)
 	})
 
-	t.Run("has a rollback", func(t *testing.T) {
+	t.Run("behind by one", func(t *testing.T) {
 		ctx := context.Background()
 
 		db, mockDB, err := sqlmock.New()
 		assert.NoError(t, err)
 
-		rows := sqlmock.NewRows([]string{"user_version"}).AddRow(0)
+		latest := len(migrations)
+
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(latest - 1))
+
+		mockDB.ExpectExec(regexp.QuoteMeta(createMigrationsTableQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied := sqlmock.NewRows([]string{"version", "checksum", "applied_at"})
+		for i := 1; i < latest; i++ {
+			applied.AddRow(i, migrations[i-1].checksum(), time.Now())
+		}
 
-		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).WillReturnRows(rows)
+		mockDB.ExpectQuery(regexp.QuoteMeta(listAppliedMigrationsQuery)).WillReturnRows(applied)
 
 		mockDB.ExpectBegin()
 
-		mockDB.ExpectExec(regexp.QuoteMeta(createTableIfNotExistsQuery)).WillReturnError(errors.New("some sql error"))
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(latest - 1))
 
-		mockDB.ExpectRollback()
+		mockDB.ExpectExec(regexp.QuoteMeta(migrations[latest-1].migrationQuery)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		// the latest migration's Go code, on an empty DB
+		for _, c := range timestampColumns {
+			mockDB.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(listColumnTimestampsQuery, c.table, c.column))).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "typeof", "value"}))
+		}
+
+		mockDB.ExpectExec(regexp.QuoteMeta(setVersionQuery(latest))).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		mockDB.ExpectExec(regexp.QuoteMeta(insertAppliedMigrationQuery)).
+			WithArgs(latest, migrations[latest-1].migrationName, migrations[latest-1].checksum(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		mockDB.ExpectCommit()
 
 		err = migrate(ctx, db)
-		assert.Equal(t, errors.New("some sql error"), err)
+		assert.NoError(t, err)
 
 		err = mockDB.ExpectationsWereMet()
 		assert.NoError(t, err)
 	})
 
-	t.Run("behind by one", func(t *testing.T) {
+	t.Run("another process migrated it first", func(t *testing.T) {
 		ctx := context.Background()
 
 		db, mockDB, err := sqlmock.New()
 		assert.NoError(t, err)
 
-		rows := sqlmock.NewRows([]string{"user_version"}).AddRow(len(migrations) - 1)
+		latest := len(migrations)
 
-		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).WillReturnRows(rows)
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(latest - 1))
 
-		mockDB.ExpectBegin()
+		mockDB.ExpectExec(regexp.QuoteMeta(createMigrationsTableQuery)).WillReturnResult(sqlmock.NewResult(0, 0))
 
-		mockDB.ExpectExec(regexp.QuoteMeta(migrations[len(migrations)-1].migrationQuery)).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		applied := sqlmock.NewRows([]string{"version", "checksum", "applied_at"})
+		for i := 1; i < latest; i++ {
+			applied.AddRow(i, migrations[i-1].checksum(), time.Now())
+		}
 
-		setQuery := strings.Replace(setCurrentMigration, "?", strconv.Itoa(len(migrations)), 1)
+		mockDB.ExpectQuery(regexp.QuoteMeta(listAppliedMigrationsQuery)).WillReturnRows(applied)
 
-		mockDB.ExpectExec(regexp.QuoteMeta(setQuery)).WillReturnResult(sqlmock.NewResult(1, 1))
+		mockDB.ExpectBegin()
 
-		mockDB.ExpectCommit()
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(latest))
+
+		mockDB.ExpectRollback()
+
+		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_version"}).AddRow(latest))
 
 		err = migrate(ctx, db)
 		assert.NoError(t, err)
@@ -93,21 +132,394 @@ func TestSQLite_Migrate(t *testing.T) {
 		err = mockDB.ExpectationsWereMet()
 		assert.NoError(t, err)
 	})
+}
 
-	t.Run("up to date", func(t *testing.T) {
-		ctx := context.Background()
+// openTestDB opens a fresh DB, migrated all the way up
+func openTestDB(t *testing.T) *sql.DB {
+	return openTestDBAt(t, filepath.Join(t.TempDir(), dbFile))
+}
 
-		db, mockDB, err := sqlmock.New()
+func openTestDBAt(t *testing.T, filename string) *sql.DB {
+	db, err := New(context.Background(), &Config{Filename: filename})
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var count int
+
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	assert.NoError(t, err)
+
+	return count > 0
+}
+
+func TestSQLite_Migrations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("records every migration", func(t *testing.T) {
+		db := openTestDB(t)
+
+		version, err := CurrentVersion(ctx, db)
+		assert.NoError(t, err)
+		assert.Equal(t, LatestVersion(), version)
+
+		statuses, err := Statuses(ctx, db)
+		assert.NoError(t, err)
+		assert.Equal(t, LatestVersion(), len(statuses))
+
+		for i, status := range statuses {
+			assert.Equal(t, i+1, status.Version)
+			assert.Equal(t, migrations[i].migrationName, status.Name)
+			assert.True(t, status.Applied)
+			assert.NotNil(t, status.AppliedAt)
+			assert.Equal(t, i > 0, status.Reversible, status.Name)
+			assert.False(t, status.Changed)
+		}
+	})
+
+	t.Run("rolls back and migrates up again", func(t *testing.T) {
+		db := openTestDB(t)
+
+		err := Migrate(ctx, db, &MigrateOptions{Target: 3})
+		assert.NoError(t, err)
+
+		version, err := CurrentVersion(ctx, db)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, version)
+
+		assert.True(t, tableExists(t, db, "notes"))
+		assert.False(t, tableExists(t, db, "tags"))
+		assert.False(t, tableExists(t, db, "note_revisions"))
+
+		statuses, err := Statuses(ctx, db)
+		assert.NoError(t, err)
+		assert.True(t, statuses[2].Applied)
+		assert.False(t, statuses[3].Applied)
+		assert.Nil(t, statuses[3].AppliedAt)
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: 0})
+		assert.EqualError(t, err, "migration 1 'create notes table' can't be rolled back")
+
+		version, err = CurrentVersion(ctx, db)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, version)
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: 1})
+		assert.NoError(t, err)
+		assert.True(t, tableExists(t, db, "notes"))
+		assert.False(t, tableExists(t, db, "notes_fts"))
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: LatestVersion()})
+		assert.NoError(t, err)
+		assert.True(t, tableExists(t, db, "note_revisions"))
+
+		_, err = db.Exec(`INSERT INTO notes (content, created_at, deleted_at) VALUES ('works', ?, NULL)`, time.Now())
+		assert.NoError(t, err)
+	})
+
+	t.Run("dry run prints the SQL without running it", func(t *testing.T) {
+		db := openTestDB(t)
+
+		out := new(strings.Builder)
+
+		err := Migrate(ctx, db, &MigrateOptions{Target: LatestVersion() - 2, DryRun: true, Out: out})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "-- migration 7 'store times in UTC, keeping the offset notes were created at' (down)\nBEGIN;\n"+
+			"-- followed by Go code, which can't be shown here\nPRAGMA user_version = 6;\nCOMMIT;\n\n"+
+			"-- migration 6 'add notes deleted_at for the trash' (down)\nBEGIN;\n"+
+			"DROP INDEX IF EXISTS deleted_at_index;\nALTER TABLE notes DROP COLUMN deleted_at;\nPRAGMA user_version = 5;\n"+
+			"COMMIT;\n\n", out.String())
+
+		version, err := CurrentVersion(ctx, db)
+		assert.NoError(t, err)
+		assert.Equal(t, LatestVersion(), version)
+
+		out.Reset()
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: 3})
+		assert.NoError(t, err)
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: 4, DryRun: true, Out: out})
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), "-- migration 4 'create tags tables' (up)\nBEGIN;\nCREATE TABLE IF NOT EXISTS tags")
+		assert.False(t, tableExists(t, db, "tags"))
+	})
+
+	t.Run("stores times in UTC, keeping the offset notes were created at", func(t *testing.T) {
+		db := openTestDB(t)
+
+		err := Migrate(ctx, db, &MigrateOptions{Target: 6})
+		assert.NoError(t, err)
+
+		created := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("", 2*60*60))
+
+		_, err = db.Exec(`INSERT INTO notes (content, created_at, updated_at) VALUES ('offset', ?, ?)`,
+			created, created.Add(time.Hour))
+		assert.NoError(t, err)
+
+		_, err = db.Exec(`INSERT INTO notes (content, created_at) VALUES ('unix', ?)`, created.Unix())
+		assert.NoError(t, err)
+
+		_, err = db.Exec(`INSERT INTO notes (content, created_at) VALUES ('broken', 'last tuesday')`)
+		assert.NoError(t, err)
+
+		storedTimes := func() []string {
+			rows, err := db.Query(`SELECT CAST(created_at AS TEXT) || ' ' || IFNULL(updated_at, '-') FROM notes ORDER BY id`)
+			assert.NoError(t, err)
+
+			defer rows.Close()
+
+			var stored []string
+
+			for rows.Next() {
+				var s string
+				assert.NoError(t, rows.Scan(&s))
+
+				stored = append(stored, s)
+			}
+
+			return stored
+		}
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: 7})
 		assert.NoError(t, err)
 
-		rows := sqlmock.NewRows([]string{"user_version"}).AddRow(len(migrations))
+		assert.Equal(t, []string{
+			"2026-03-01 07:30:00+00:00 2026-03-01 08:30:00+00:00",
+			"2026-03-01 07:30:00+00:00 -",
+			"last tuesday -",
+		}, storedTimes())
+
+		var offset int
 
-		mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentMigration)).WillReturnRows(rows)
+		err = db.QueryRow(`SELECT created_offset FROM notes WHERE id = 1`).Scan(&offset)
+		assert.NoError(t, err)
+		assert.Equal(t, 2*60*60, offset)
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: 6})
+		assert.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(storedTimes()[0], "2026-03-01 09:30:00+02:00 "), storedTimes()[0])
+	})
+
+	t.Run("detects a changed migration", func(t *testing.T) {
+		db := openTestDB(t)
+
+		_, err := db.Exec(`UPDATE schema_migrations SET checksum = 'edited' WHERE version = 2`)
+		assert.NoError(t, err)
 
 		err = migrate(ctx, db)
+		assert.True(t, errors.Is(err, ErrMigrationChanged))
+		assert.EqualError(t, err, "migration has been changed since it was applied: 2 'add notes created_at index'")
+
+		statuses, err := Statuses(ctx, db)
 		assert.NoError(t, err)
+		assert.False(t, statuses[0].Changed)
+		assert.True(t, statuses[1].Changed)
+	})
 
-		err = mockDB.ExpectationsWereMet()
+	t.Run("detects a changed search migration in either build", func(t *testing.T) {
+		db := openTestDB(t)
+
+		search := &migrations[searchIndexMigration-1]
+		searchQuery := search.searchQuery
+
+		t.Cleanup(func() {
+			search.searchQuery = searchQuery
+		})
+
+		search.searchQuery = strings.Replace(searchQuery, "fts5(content,", "fts5(content, tokenize='porter',", 1)
+
+		err := migrate(ctx, db)
+		assert.True(t, errors.Is(err, ErrMigrationChanged))
+		assert.EqualError(t, err, "migration has been changed since it was applied: 3 'add notes full-text search'")
+	})
+
+	t.Run("detects a changed Go migration by its version", func(t *testing.T) {
+		for i, m := range migrations {
+			if m.migrationFunc != nil {
+				assert.NotEmpty(t, m.migrationFuncVersion, "migration %v needs a migrationFuncVersion", i+1)
+			}
+		}
+
+		db := openTestDB(t)
+
+		utc := &migrations[LatestVersion()-1]
+		funcVersion := utc.migrationFuncVersion
+
+		t.Cleanup(func() {
+			utc.migrationFuncVersion = funcVersion
+		})
+
+		utc.migrationFuncVersion = funcVersion + "-edited"
+
+		err := migrate(ctx, db)
+		assert.True(t, errors.Is(err, ErrMigrationChanged))
+	})
+
+	t.Run("records checksums for DBs from before there were any", func(t *testing.T) {
+		db := openTestDB(t)
+
+		_, err := db.Exec(`DROP TABLE schema_migrations`)
+		assert.NoError(t, err)
+
+		err = migrate(ctx, db)
+		assert.NoError(t, err)
+
+		var count int
+
+		err = db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count)
+		assert.NoError(t, err)
+		assert.Equal(t, LatestVersion(), count)
+	})
+
+	t.Run("runs Go code in the same transaction", func(t *testing.T) {
+		db := openTestDB(t)
+
+		original := migrations
+		defer func() {
+			migrations = original
+		}()
+
+		migrations = append(append([]migration{}, original...), migration{
+			migrationName:  "add a note, then fail",
+			migrationQuery: `CREATE TABLE scratch (id INTEGER);`,
+			migrationFunc: func(ctx context.Context, tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `INSERT INTO notes (content, created_at) VALUES ('from Go', ?)`, time.Now())
+				assert.NoError(t, err)
+
+				return errors.New("some go error")
+			},
+		})
+
+		err := migrate(ctx, db)
+		assert.Equal(t, errors.New("some go error"), err)
+
+		var count int
+
+		err = db.QueryRow(`SELECT COUNT(*) FROM notes`).Scan(&count)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		assert.False(t, tableExists(t, db, "scratch"))
+
+		version, err := CurrentVersion(ctx, db)
+		assert.NoError(t, err)
+		assert.Equal(t, LatestVersion()-1, version)
+
+		// without a rollback the migration is one way
+		migrations[len(migrations)-1].migrationFunc = nil
+
+		err = migrate(ctx, db)
+		assert.NoError(t, err)
+
+		err = Migrate(ctx, db, &MigrateOptions{Target: 1})
+		assert.EqualError(t, err, "migration 8 'add a note, then fail' can't be rolled back")
+	})
+
+	t.Run("migrates once when opened by several processes at once", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), dbFile)
+
+		const openers = 8
+
+		errs := make(chan error, openers)
+
+		for i := 0; i < openers; i++ {
+			go func() {
+				db, err := New(ctx, &Config{Filename: filename})
+				if err == nil {
+					err = db.Close()
+				}
+
+				errs <- err
+			}()
+		}
+
+		for i := 0; i < openers; i++ {
+			assert.NoError(t, <-errs)
+		}
+
+		db := openTestDBAt(t, filename)
+
+		statuses, err := Statuses(ctx, db)
+		assert.NoError(t, err)
+
+		for _, status := range statuses {
+			assert.True(t, status.Applied)
+			assert.False(t, status.Changed)
+		}
+	})
+
+	t.Run("rejects versions that don't exist", func(t *testing.T) {
+		db := openTestDB(t)
+
+		err := Migrate(ctx, db, &MigrateOptions{Target: LatestVersion() + 1})
+		assert.EqualError(t, err, "there is no migration 8, the latest is 7")
+
+		_, err = db.Exec(`PRAGMA user_version = 99`)
+		assert.NoError(t, err)
+
+		err = migrate(ctx, db)
+		assert.EqualError(t, err,
+			"the DB is at version 99, which is newer than this version of note-logger knows about (7)")
+	})
+}
+
+func TestSQLite_MoveLegacyDB(t *testing.T) {
+	legacyFilename, err := LegacyFilename()
+	assert.NoError(t, err)
+
+	t.Run("nothing to move", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "note-logger", dbFile)
+
+		moved, err := MoveLegacyDB(filename)
+		assert.NoError(t, err)
+		assert.False(t, moved)
+	})
+
+	t.Run("moves the DB next to the binary", func(t *testing.T) {
+		err := os.WriteFile(legacyFilename, []byte("legacy DB"), 0o600)
+		assert.NoError(t, err)
+
+		defer os.Remove(legacyFilename)
+
+		filename := filepath.Join(t.TempDir(), "note-logger", dbFile)
+
+		moved, err := MoveLegacyDB(filename)
+		assert.NoError(t, err)
+		assert.True(t, moved)
+
+		contents, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, "legacy DB", string(contents))
+
+		_, err = os.Stat(legacyFilename)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("leaves an existing DB alone", func(t *testing.T) {
+		err := os.WriteFile(legacyFilename, []byte("legacy DB"), 0o600)
+		assert.NoError(t, err)
+
+		defer os.Remove(legacyFilename)
+
+		filename := filepath.Join(t.TempDir(), dbFile)
+
+		err = os.WriteFile(filename, []byte("current DB"), 0o600)
+		assert.NoError(t, err)
+
+		moved, err := MoveLegacyDB(filename)
+		assert.NoError(t, err)
+		assert.False(t, moved)
+
+		contents, err := os.ReadFile(filename)
 		assert.NoError(t, err)
+		assert.Equal(t, "current DB", string(contents))
 	})
 }
@@ -0,0 +1,213 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+const addNotesCreatedOffsetQuery string = `
+ALTER TABLE notes ADD COLUMN created_offset INTEGER NOT NULL DEFAULT 0;
+`
+
+const dropNotesCreatedOffsetQuery string = `
+ALTER TABLE notes DROP COLUMN created_offset;
+`
+
+const listColumnTimestampsQuery string = `
+SELECT id, typeof(%[2]v), CAST(%[2]v AS TEXT) FROM %[1]v WHERE %[2]v IS NOT NULL ORDER BY id ASC;
+`
+
+const updateColumnTimestampQuery string = `
+UPDATE %v SET %v = ? WHERE id = ?;
+`
+
+const listCreatedOffsetsQuery string = `
+SELECT id, created_offset FROM notes;
+`
+
+const updateCreatedOffsetQuery string = `
+UPDATE notes SET created_offset = ? WHERE id = ?;
+`
+
+// timestampColumns are all the columns with times in them
+var timestampColumns = []struct {
+	table  string
+	column string
+}{
+	{table: "notes", column: "created_at"},
+	{table: "notes", column: "updated_at"},
+	{table: "notes", column: "deleted_at"},
+	{table: "note_revisions", column: "created_at"},
+}
+
+// timestampsToUTC rewrites every time in UTC, keeping the offset each note was created at. Times without an offset were
+// written in local time.
+func timestampsToUTC(ctx context.Context, tx *sql.Tx) error {
+	for _, c := range timestampColumns {
+		createdAt := c.table == "notes" && c.column == "created_at"
+
+		err := rewriteTimestamps(ctx, tx, c.table, c.column, func(id int64, t time.Time) (time.Time, error) {
+			if createdAt {
+				_, offset := t.Zone()
+
+				_, err := tx.ExecContext(ctx, updateCreatedOffsetQuery, offset, id)
+				if err != nil {
+					return time.Time{}, err
+				}
+			}
+
+			return t.UTC(), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// timestampsToLocal undoes timestampsToUTC
+func timestampsToLocal(ctx context.Context, tx *sql.Tx) error {
+	offsets, err := createdOffsets(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range timestampColumns {
+		createdAt := c.table == "notes" && c.column == "created_at"
+
+		err = rewriteTimestamps(ctx, tx, c.table, c.column, func(id int64, t time.Time) (time.Time, error) {
+			if createdAt {
+				return t.In(time.FixedZone("", offsets[id])), nil
+			}
+
+			return t.Local(), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, dropNotesCreatedOffsetQuery)
+
+	return err
+}
+
+func createdOffsets(ctx context.Context, tx *sql.Tx) (map[int64]int, error) {
+	rows, err := tx.QueryContext(ctx, listCreatedOffsetsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	offsets := make(map[int64]int)
+
+	for rows.Next() {
+		var id int64
+		var offset int
+
+		err = rows.Scan(&id, &offset)
+		if err != nil {
+			return nil, err
+		}
+
+		offsets[id] = offset
+	}
+
+	return offsets, rows.Err()
+}
+
+// rewriteTimestamps converts every time in a column, leaving the unreadable ones for the doctor to report
+func rewriteTimestamps(ctx context.Context, tx *sql.Tx, table string, column string,
+	convert func(id int64, t time.Time) (time.Time, error)) error {
+	type storedTime struct {
+		id int64
+		t  time.Time
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(listColumnTimestampsQuery, table, column))
+	if err != nil {
+		return err
+	}
+
+	// every row gets read before any are updated, as the updates would disturb the query
+	var stored []storedTime
+
+	for rows.Next() {
+		var id int64
+
+		var valueType string
+
+		var value sql.NullString
+
+		err = rows.Scan(&id, &valueType, &value)
+		if err != nil {
+			//nolint
+			rows.Close()
+
+			return err
+		}
+
+		t, ok := parseTimestamp(valueType, value.String, time.Local)
+		if ok {
+			stored = append(stored, storedTime{id: id, t: t})
+		}
+	}
+
+	err = rows.Close()
+	if err != nil {
+		return err
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stored {
+		converted, err := convert(s.id, s.t)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(updateColumnTimestampQuery, table, column), converted, s.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseTimestamp reads a time the way the SQLite driver does, except that text without an offset is in loc
+func parseTimestamp(valueType string, value string, loc *time.Location) (time.Time, bool) {
+	switch valueType {
+	case "integer", "real":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return time.Unix(0, int64(seconds*float64(time.Second))).In(loc), true
+	case "text":
+		if strings.HasSuffix(value, "Z") {
+			value = strings.TrimSuffix(value, "Z")
+			loc = time.UTC
+		}
+
+		for _, format := range sqlite3.SQLiteTimestampFormats {
+			if t, err := time.ParseInLocation(format, value, loc); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
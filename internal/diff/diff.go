@@ -0,0 +1,106 @@
+package diff
+
+import "strings"
+
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+type Edit struct {
+	Kind Kind
+	Text string
+}
+
+// Compute finds the edits that turn a into b, using the longest common subsequence of the two.
+func Compute(a []string, b []string) []Edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, Edit{Kind: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, Edit{Kind: Delete, Text: a[i]})
+			i++
+		default:
+			edits = append(edits, Edit{Kind: Insert, Text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		edits = append(edits, Edit{Kind: Delete, Text: a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		edits = append(edits, Edit{Kind: Insert, Text: b[j]})
+	}
+
+	return edits
+}
+
+// Lines diffs two texts line by line, prefixing removed lines with "- ", added lines with "+ ", and the rest with "  ".
+func Lines(a string, b string) string {
+	var builder strings.Builder
+
+	for _, edit := range Compute(strings.Split(a, "\n"), strings.Split(b, "\n")) {
+		switch edit.Kind {
+		case Equal:
+			builder.WriteString("  ")
+		case Delete:
+			builder.WriteString("- ")
+		case Insert:
+			builder.WriteString("+ ")
+		}
+
+		builder.WriteString(edit.Text)
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// Words diffs two texts word by word, in the same style as git's --word-diff, so [-removed-] and {+added+}.
+func Words(a string, b string) string {
+	words := make([]string, 0)
+
+	for _, edit := range Compute(strings.Fields(a), strings.Fields(b)) {
+		switch edit.Kind {
+		case Equal:
+			words = append(words, edit.Text)
+		case Delete:
+			words = append(words, "[-"+edit.Text+"-]")
+		case Insert:
+			words = append(words, "{+"+edit.Text+"+}")
+		}
+	}
+
+	return strings.Join(words, " ")
+}
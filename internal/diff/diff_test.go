@@ -0,0 +1,38 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff_Compute(t *testing.T) {
+	edits := Compute([]string{"a", "b", "c"}, []string{"a", "c", "d"})
+
+	assert.Equal(t, []Edit{
+		{Kind: Equal, Text: "a"},
+		{Kind: Delete, Text: "b"},
+		{Kind: Equal, Text: "c"},
+		{Kind: Insert, Text: "d"},
+	}, edits)
+}
+
+func TestDiff_Lines(t *testing.T) {
+	actual := Lines("first line\nsecond line\nthird line", "first line\n2nd line\nthird line")
+
+	assert.Equal(t, "  first line\n- second line\n+ 2nd line\n  third line\n", actual)
+}
+
+func TestDiff_Words(t *testing.T) {
+	t.Run("changed words", func(t *testing.T) {
+		actual := Words("fixed the teh build", "fixed the flaky build")
+
+		assert.Equal(t, "fixed the [-teh-] {+flaky+} build", actual)
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		actual := Words("fixed the build", "fixed  the build")
+
+		assert.Equal(t, "fixed the build", actual)
+	})
+}
@@ -0,0 +1,130 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Fallback is the editor used when neither $VISUAL nor $EDITOR is set
+const Fallback string = "vi"
+
+// Scissors marks the start of the template's comments, as a note's first line could well start with a #hashtag
+const Scissors string = "# ------------------------ >8 ------------------------"
+
+const template string = "\n\n" + Scissors + `
+# Write the note above this line. Everything from the line above down is
+# ignored, and leaving the note empty aborts. Any #hashtags become tags.
+`
+
+// ErrEmpty is returned when the note was left empty in the editor
+var ErrEmpty = errors.New("aborting, the note is empty")
+
+// ErrUnchanged is returned when the editor was closed without changing anything
+var ErrUnchanged = errors.New("aborting, the note was not changed")
+
+// Command creates the command for editing a file in the user's $VISUAL or $EDITOR, which can include arguments
+func Command(filename string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{Fallback}
+	}
+
+	//nolint:gosec
+	return exec.Command(args[0], append(args[1:], filename)...)
+}
+
+// Edit opens the user's editor on the content and the template, giving back the cleaned up note once it exits
+func Edit(content string) (string, error) {
+	filename, err := Prepare(content)
+	if err != nil {
+		return "", err
+	}
+
+	//nolint
+	defer os.Remove(filename)
+
+	cmd := Command(filename)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return "", err
+	}
+
+	return Read(filename, content)
+}
+
+// Prepare writes the content and the template to a temp file for the caller to run the editor on, and remove
+func Prepare(content string) (string, error) {
+	file, err := os.CreateTemp("", "note-*.md")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = file.WriteString(content + template)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		//nolint
+		os.Remove(file.Name())
+
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// Read reads back the cleaned up note from a file written by Prepare, or ErrUnchanged or ErrEmpty
+func Read(filename string, original string) (string, error) {
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	edited := string(contents)
+	if scissors := strings.Index(edited, Scissors); scissors != -1 {
+		edited = edited[:scissors]
+	}
+
+	note := Clean(edited)
+
+	if note == "" {
+		return "", ErrEmpty
+	}
+
+	if note == Clean(original) {
+		return "", ErrUnchanged
+	}
+
+	return note, nil
+}
+
+// Clean removes the Windows line endings, trailing whitespace and blank lines at either end from a note
+func Clean(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n")
+}
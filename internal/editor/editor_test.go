@@ -0,0 +1,100 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	for name, tc := range map[string]struct {
+		visual   string
+		editor   string
+		expected []string
+	}{
+		"visual first":   {visual: "nano", editor: "vim", expected: []string{"nano", "note.md"}},
+		"editor":         {editor: "vim", expected: []string{"vim", "note.md"}},
+		"with arguments": {editor: "code --wait", expected: []string{"code", "--wait", "note.md"}},
+		"fallback":       {visual: " ", expected: []string{Fallback, "note.md"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("VISUAL", tc.visual)
+			t.Setenv("EDITOR", tc.editor)
+
+			assert.Equal(t, tc.expected, Command("note.md").Args)
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	assert.Equal(t, "First line\n\n  indented line", Clean("\r\n\n  \nFirst line  \r\n\n  indented line\t\n\n"))
+	assert.Equal(t, "", Clean(" \n\t\n"))
+}
+
+// editorScript makes the editor a script that puts the given line above whatever is in the file
+func editorScript(t *testing.T, line string) {
+	script := filepath.Join(t.TempDir(), "editor.sh")
+
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+{ printf '%s\n' "`+line+`"; cat "$1"; } > "$1.new" && mv "$1.new" "$1"
+`), 0o700)
+	require.NoError(t, err)
+
+	t.Setenv("VISUAL", "sh "+script)
+}
+
+func TestEdit(t *testing.T) {
+	t.Run("gives back what was written above the template", func(t *testing.T) {
+		editorScript(t, "New note #work")
+
+		note, err := Edit("")
+		assert.NoError(t, err)
+		assert.Equal(t, "New note #work", note)
+	})
+
+	t.Run("keeps the original content", func(t *testing.T) {
+		editorScript(t, "A first line")
+
+		note, err := Edit("Existing note")
+		assert.NoError(t, err)
+		assert.Equal(t, "A first line\nExisting note", note)
+	})
+
+	t.Run("aborts when left empty", func(t *testing.T) {
+		t.Setenv("VISUAL", "true")
+
+		_, err := Edit("")
+		assert.ErrorIs(t, err, ErrEmpty)
+	})
+
+	t.Run("aborts when unchanged", func(t *testing.T) {
+		t.Setenv("VISUAL", "true")
+
+		_, err := Edit("Existing note")
+		assert.ErrorIs(t, err, ErrUnchanged)
+	})
+
+	t.Run("fails when the editor does", func(t *testing.T) {
+		t.Setenv("VISUAL", "false")
+
+		_, err := Edit("")
+		assert.Error(t, err)
+	})
+}
+
+func TestRead_IgnoresEverythingFromTheScissors(t *testing.T) {
+	filename, err := Prepare("")
+	require.NoError(t, err)
+
+	defer os.Remove(filename)
+
+	err = os.WriteFile(filename, []byte("#standup notes\n\n"+Scissors+"\n# a comment\nnot part of the note\n"), 0o600)
+	require.NoError(t, err)
+
+	note, err := Read(filename, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "#standup notes", note)
+}
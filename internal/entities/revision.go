@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// Revision is one version of a note's content. CreatedAt is when that version was written.
+type Revision struct {
+	Number    int       `json:"number"`
+	NoteID    int64     `json:"note_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
@@ -0,0 +1,7 @@
+package entities
+
+type SearchResult struct {
+	Note    *Note   `json:"note"`
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
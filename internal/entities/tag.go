@@ -0,0 +1,6 @@
+package entities
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
@@ -0,0 +1,188 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/output"
+	"note-logger/internal/tags"
+)
+
+const (
+	JSON     string = output.JSON
+	JSONL    string = output.JSONL
+	CSV      string = output.CSV
+	Markdown string = "markdown"
+	HTML     string = "html"
+)
+
+var Formats = []string{JSON, JSONL, CSV, Markdown, HTML}
+
+// an import reads the times back from the day headings and the times of the notes under them
+const (
+	DayLayout  string = "Monday, 2006-01-02"
+	TimeLayout string = "15:04:05"
+)
+
+// NewWriter creates a writer for exporting notes, which have to be written oldest first
+func NewWriter(w io.Writer, format string) (output.Writer, error) {
+	switch format {
+	case JSON, JSONL, CSV:
+		return output.NewWriter(w, format, nil)
+	case Markdown:
+		return &markdownWriter{w: w}, nil
+	case HTML:
+		return &htmlWriter{w: w}, nil
+	}
+
+	return nil, fmt.Errorf("unknown export format '%v', supported formats are: %v", format, strings.Join(Formats, ", "))
+}
+
+func dayOf(note *entities.Note) string {
+	return note.CreatedAt.Format(DayLayout)
+}
+
+// markdownWriter gives each day a heading, with the day's notes as a list underneath
+type markdownWriter struct {
+	w          io.Writer
+	currentDay string
+	written    int
+}
+
+func (writer *markdownWriter) Write(value interface{}) error {
+	note, ok := value.(*entities.Note)
+	if !ok {
+		return fmt.Errorf("can only export notes, not %T", value)
+	}
+
+	if writer.written == 0 {
+		_, err := fmt.Fprint(writer.w, "# Notes\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.written++
+
+	if day := dayOf(note); day != writer.currentDay {
+		writer.currentDay = day
+
+		_, err := fmt.Fprintf(writer.w, "\n## %v\n\n", day)
+		if err != nil {
+			return err
+		}
+	}
+
+	// lines after the first are indented to stay in the same list item
+	content := strings.ReplaceAll(tags.AppendMissing(note.Content, note.Tags), "\n", "\n  ")
+
+	_, err := fmt.Fprintf(writer.w, "- %v %v\n", note.CreatedAt.Format(TimeLayout), content)
+
+	return err
+}
+
+func (writer *markdownWriter) Close() error {
+	if writer.written == 0 {
+		_, err := fmt.Fprint(writer.w, "# Notes\n")
+
+		return err
+	}
+
+	return nil
+}
+
+var htmlTemplates = template.Must(template.New("header").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Notes</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+h1 { border-bottom: 1px solid #d0d7de; padding-bottom: .3rem; }
+h2 { font-size: 1.1rem; margin-top: 2rem; color: #57606a; }
+ul { list-style: none; padding: 0; }
+li { display: flex; gap: 1rem; padding: .4rem 0; border-bottom: 1px solid #eaeef2; }
+time { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; color: #57606a; white-space: nowrap; }
+.content { white-space: pre-wrap; }
+.tag { background: #ddf4ff; color: #0969da; border-radius: 1rem; padding: 0 .5rem; font-size: .85rem; margin-left: .3rem; }
+.empty { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>Notes</h1>
+`))
+
+func init() {
+	template.Must(htmlTemplates.New("day").Parse(`{{if .Open}}</ul>
+</section>
+{{end}}<section>
+<h2>{{.Day}}</h2>
+<ul>
+`))
+
+	template.Must(htmlTemplates.New("note").Parse(`<li><time datetime="{{.CreatedAt.Format "2006-01-02T15:04:05Z07:00"}}">` +
+		`{{.CreatedAt.Format "15:04:05"}}</time><span class="content">{{.Content}}` +
+		`{{range .Tags}}<span class="tag">#{{.}}</span>{{end}}</span></li>
+`))
+
+	template.Must(htmlTemplates.New("footer").Parse(`{{if .Open}}</ul>
+</section>
+{{else}}<p class="empty">No notes.</p>
+{{end}}</body>
+</html>
+`))
+}
+
+// htmlWriter writes a single page, with the styles inline
+type htmlWriter struct {
+	w          io.Writer
+	currentDay string
+	written    int
+}
+
+func (writer *htmlWriter) Write(value interface{}) error {
+	note, ok := value.(*entities.Note)
+	if !ok {
+		return fmt.Errorf("can only export notes, not %T", value)
+	}
+
+	if writer.written == 0 {
+		err := htmlTemplates.ExecuteTemplate(writer.w, "header", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if day := dayOf(note); day != writer.currentDay {
+		err := htmlTemplates.ExecuteTemplate(writer.w, "day", map[string]interface{}{
+			"Open": writer.written > 0,
+			"Day":  day,
+		})
+		if err != nil {
+			return err
+		}
+
+		writer.currentDay = day
+	}
+
+	writer.written++
+
+	return htmlTemplates.ExecuteTemplate(writer.w, "note", note)
+}
+
+func (writer *htmlWriter) Close() error {
+	if writer.written == 0 {
+		err := htmlTemplates.ExecuteTemplate(writer.w, "header", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return htmlTemplates.ExecuteTemplate(writer.w, "footer", map[string]interface{}{
+		"Open": writer.written > 0,
+	})
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"note-logger/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNotes = []*entities.Note{
+	{
+		ID:        1,
+		Content:   "First note #work",
+		CreatedAt: time.Date(2022, time.April, 12, 16, 26, 19, 0, time.UTC),
+		Tags:      []string{"ci", "work"},
+	},
+	{
+		ID:        2,
+		Content:   "Second note\nwith <two> lines",
+		CreatedAt: time.Date(2022, time.April, 12, 16, 37, 16, 0, time.UTC),
+	},
+	{
+		ID:        3,
+		Content:   "Third note",
+		CreatedAt: time.Date(2022, time.April, 13, 9, 1, 2, 0, time.UTC),
+	},
+}
+
+func exportAll(t *testing.T, format string, values []*entities.Note) string {
+	buffer := new(bytes.Buffer)
+
+	writer, err := NewWriter(buffer, format)
+	require.NoError(t, err)
+
+	for _, value := range values {
+		err = writer.Write(value)
+		require.NoError(t, err)
+	}
+
+	err = writer.Close()
+	require.NoError(t, err)
+
+	return buffer.String()
+}
+
+func TestExport_Markdown(t *testing.T) {
+	t.Run("groups notes by day", func(t *testing.T) {
+		assert.Equal(t, `# Notes
+
+## Tuesday, 2022-04-12
+
+- 16:26:19 First note #work #ci
+- 16:37:16 Second note
+  with <two> lines
+
+## Wednesday, 2022-04-13
+
+- 09:01:02 Third note
+`, exportAll(t, Markdown, testNotes))
+	})
+
+	t.Run("no notes", func(t *testing.T) {
+		assert.Equal(t, "# Notes\n", exportAll(t, Markdown, nil))
+	})
+}
+
+func TestExport_HTML(t *testing.T) {
+	t.Run("groups notes by day", func(t *testing.T) {
+		actual := exportAll(t, HTML, testNotes)
+
+		assert.Contains(t, actual, "<!DOCTYPE html>")
+		assert.Contains(t, actual, "<style>")
+		assert.Contains(t, actual, `<section>
+<h2>Tuesday, 2022-04-12</h2>
+<ul>
+<li><time datetime="2022-04-12T16:26:19Z">16:26:19</time><span class="content">First note #work`+
+			`<span class="tag">#ci</span><span class="tag">#work</span></span></li>
+<li><time datetime="2022-04-12T16:37:16Z">16:37:16</time><span class="content">Second note
+with &lt;two&gt; lines</span></li>
+</ul>
+</section>
+<section>
+<h2>Wednesday, 2022-04-13</h2>`)
+		assert.Regexp(t, `</ul>\n</section>\n</body>\n</html>\n$`, actual)
+	})
+
+	t.Run("no notes", func(t *testing.T) {
+		actual := exportAll(t, HTML, nil)
+
+		assert.Contains(t, actual, "<!DOCTYPE html>")
+		assert.Contains(t, actual, `<p class="empty">No notes.</p>`)
+	})
+}
+
+func TestExport_NewWriter(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		assert.Regexp(t, `^\[\n  {\n    "id": 1,`, exportAll(t, JSON, testNotes))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := NewWriter(new(bytes.Buffer), "yaml")
+		assert.Equal(t, errors.New("unknown export format 'yaml', supported formats are: "+
+			"json, jsonl, csv, markdown, html"), err)
+	})
+}
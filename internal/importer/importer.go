@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/export"
+	"note-logger/internal/tags"
+)
+
+var Formats = []string{export.JSON, export.JSONL, export.CSV, export.Markdown}
+
+// FormatFromFilename guesses the format of a file from its extension
+func FormatFromFilename(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return export.JSON, nil
+	case ".jsonl", ".ndjson":
+		return export.JSONL, nil
+	case ".csv":
+		return export.CSV, nil
+	case ".md", ".markdown":
+		return export.Markdown, nil
+	}
+
+	return "", fmt.Errorf("unable to tell the format of '%v' from its extension, set it with --format", filename)
+}
+
+// Read parses notes in any of the formats that an export produces, other than HTML
+func Read(r io.Reader, format string) ([]*entities.Note, error) {
+	switch format {
+	case export.JSON:
+		return readJSON(r)
+	case export.JSONL:
+		return readJSONL(r)
+	case export.CSV:
+		return readCSV(r)
+	case export.Markdown:
+		return readMarkdown(r)
+	}
+
+	return nil, fmt.Errorf("unknown import format '%v', supported formats are: %v", format, strings.Join(Formats, ", "))
+}
+
+func readJSON(r io.Reader) ([]*entities.Note, error) {
+	notes := make([]*entities.Note, 0)
+
+	err := json.NewDecoder(r).Decode(&notes)
+	if err != nil {
+		return nil, err
+	}
+
+	return notes, nil
+}
+
+func readJSONL(r io.Reader) ([]*entities.Note, error) {
+	notes := make([]*entities.Note, 0)
+
+	decoder := json.NewDecoder(r)
+
+	for {
+		note := &entities.Note{}
+
+		err := decoder.Decode(note)
+		if errors.Is(err, io.EOF) {
+			return notes, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", len(notes)+1, err)
+		}
+
+		notes = append(notes, note)
+	}
+}
+
+func readCSV(r io.Reader) ([]*entities.Note, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return []*entities.Note{}, nil
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		columns[header] = i
+	}
+
+	for _, required := range []string{"content", "created_at"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing '%v' column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok {
+			return ""
+		}
+
+		return record[i]
+	}
+
+	notes := make([]*entities.Note, 0, len(records)-1)
+
+	for i, record := range records[1:] {
+		note := &entities.Note{
+			Content: field(record, "content"),
+		}
+
+		if id := field(record, "id"); id != "" {
+			note.ID, err = strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %v: %w", i+1, err)
+			}
+		}
+
+		note.CreatedAt, err = time.Parse(time.RFC3339Nano, field(record, "created_at"))
+		if err != nil {
+			return nil, fmt.Errorf("row %v: %w", i+1, err)
+		}
+
+		if updatedAt := field(record, "updated_at"); updatedAt != "" {
+			parsed, err := time.Parse(time.RFC3339Nano, updatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("row %v: %w", i+1, err)
+			}
+
+			note.UpdatedAt = &parsed
+		}
+
+		if noteTags := field(record, "tags"); noteTags != "" {
+			note.Tags = strings.Split(noteTags, ",")
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+// readMarkdown reads the notes back out of a Markdown export, with their tags from the #hashtags
+func readMarkdown(r io.Reader) ([]*entities.Note, error) {
+	notes := make([]*entities.Note, 0)
+
+	var day time.Time
+	var current *entities.Note
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNum++
+
+		switch {
+		case strings.HasPrefix(line, "## "):
+			parsed, err := time.ParseInLocation(export.DayLayout, strings.TrimPrefix(line, "## "), time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %w", lineNum, err)
+			}
+
+			day = parsed
+			current = nil
+		case strings.HasPrefix(line, "- "):
+			if day.IsZero() {
+				return nil, fmt.Errorf("line %v: note before any day heading", lineNum)
+			}
+
+			item := strings.TrimPrefix(line, "- ")
+
+			timeString, content, _ := strings.Cut(item, " ")
+
+			parsed, err := time.Parse(export.TimeLayout, timeString)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %w", lineNum, err)
+			}
+
+			current = &entities.Note{
+				Content: content,
+				CreatedAt: time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0,
+					time.Local),
+			}
+
+			notes = append(notes, current)
+		case strings.HasPrefix(line, "  ") && current != nil:
+			current.Content += "\n" + strings.TrimPrefix(line, "  ")
+		case strings.TrimSpace(line) == "":
+			current = nil
+		}
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, note := range notes {
+		if extracted := tags.Extract(note.Content); len(extracted) > 0 {
+			note.Tags = extracted
+		}
+	}
+
+	return notes, nil
+}
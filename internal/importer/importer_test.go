@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/export"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportNotes(t *testing.T, format string, notes []*entities.Note) string {
+	buffer := new(bytes.Buffer)
+
+	writer, err := export.NewWriter(buffer, format)
+	require.NoError(t, err)
+
+	for _, note := range notes {
+		require.NoError(t, writer.Write(note))
+	}
+
+	require.NoError(t, writer.Close())
+
+	return buffer.String()
+}
+
+func TestRead_RoundTrip(t *testing.T) {
+	updatedAt := time.Date(2022, time.April, 13, 8, 0, 0, 0, time.UTC)
+
+	notes := []*entities.Note{
+		{
+			ID:        1,
+			Content:   "First note #work",
+			CreatedAt: time.Date(2022, time.April, 12, 16, 26, 19, 123000000, time.UTC),
+			UpdatedAt: &updatedAt,
+			Tags:      []string{"ci", "work"},
+		},
+		{
+			ID:        2,
+			Content:   "Second note, with \"quotes\"\nand two lines",
+			CreatedAt: time.Date(2022, time.April, 12, 16, 37, 16, 0, time.UTC),
+		},
+	}
+
+	for _, format := range []string{export.JSON, export.JSONL, export.CSV} {
+		t.Run(format, func(t *testing.T) {
+			res, err := Read(strings.NewReader(exportNotes(t, format, notes)), format)
+			require.NoError(t, err)
+
+			require.Len(t, res, len(notes))
+
+			for i := range notes {
+				assert.Equal(t, notes[i].ID, res[i].ID)
+				assert.Equal(t, notes[i].Content, res[i].Content)
+				assert.True(t, notes[i].CreatedAt.Equal(res[i].CreatedAt))
+				assert.Equal(t, notes[i].Tags, res[i].Tags)
+				assert.Equal(t, notes[i].UpdatedAt == nil, res[i].UpdatedAt == nil)
+			}
+		})
+	}
+}
+
+func TestRead_Markdown(t *testing.T) {
+	notes := []*entities.Note{
+		{
+			Content:   "First note #work",
+			CreatedAt: time.Date(2022, time.April, 12, 16, 26, 19, 123000000, time.Local),
+			Tags:      []string{"ci", "work"},
+		},
+		{
+			Content:   "Second note\nwith two lines",
+			CreatedAt: time.Date(2022, time.April, 12, 16, 37, 16, 0, time.Local),
+		},
+		{
+			Content:   "Third note",
+			CreatedAt: time.Date(2022, time.April, 13, 9, 1, 2, 0, time.Local),
+		},
+	}
+
+	res, err := Read(strings.NewReader(exportNotes(t, export.Markdown, notes)), export.Markdown)
+	require.NoError(t, err)
+
+	assert.Equal(t, []*entities.Note{
+		{
+			Content:   "First note #work #ci",
+			CreatedAt: time.Date(2022, time.April, 12, 16, 26, 19, 0, time.Local),
+			Tags:      []string{"work", "ci"},
+		},
+		{
+			Content:   "Second note\nwith two lines",
+			CreatedAt: time.Date(2022, time.April, 12, 16, 37, 16, 0, time.Local),
+		},
+		{
+			Content:   "Third note",
+			CreatedAt: time.Date(2022, time.April, 13, 9, 1, 2, 0, time.Local),
+		},
+	}, res)
+}
+
+func TestRead_Errors(t *testing.T) {
+	_, err := Read(strings.NewReader("- 10:00:00 No heading"), export.Markdown)
+	assert.EqualError(t, err, "line 1: note before any day heading")
+
+	_, err = Read(strings.NewReader("content,tags\nSome note,\n"), export.CSV)
+	assert.EqualError(t, err, "missing 'created_at' column")
+
+	_, err = Read(strings.NewReader(""), export.HTML)
+	assert.EqualError(t, err, "unknown import format 'html', supported formats are: json, jsonl, csv, markdown")
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	for filename, expected := range map[string]string{
+		"notes.json":     export.JSON,
+		"notes.JSONL":    export.JSONL,
+		"backup/n.csv":   export.CSV,
+		"notes.markdown": export.Markdown,
+		"notes.md":       export.Markdown,
+	} {
+		format, err := FormatFromFilename(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, format, filename)
+	}
+
+	_, err := FormatFromFilename("notes.html")
+	assert.Error(t, err)
+}
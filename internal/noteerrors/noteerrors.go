@@ -0,0 +1,91 @@
+// Package noteerrors has the kinds of error note-logger fails with, for exit codes and status codes.
+package noteerrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The kinds of error that note-logger can fail with, to check for with errors.Is
+var (
+	// ErrNotFound is for a note, revision or file that doesn't exist
+	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidInput is for a flag, argument or piece of a note that can't be used as it is
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrConflict is for something that can't be done to a note in the state it's in
+	ErrConflict = errors.New("conflict")
+
+	// ErrStorage is for the DB, or the files the notes are kept in, failing to be read or written
+	ErrStorage = errors.New("storage error")
+)
+
+// Kinds are all the kinds of error, in the order they're checked for.
+var Kinds = []error{ErrNotFound, ErrInvalidInput, ErrConflict, ErrStorage}
+
+// Error is an error of one of the kinds above, wrapping what caused it
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func wrap(kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: kind, Err: err}
+}
+
+// NotFound wraps err as an ErrNotFound, leaving nil as it is.
+func NotFound(err error) error {
+	return wrap(ErrNotFound, err)
+}
+
+// InvalidInput wraps err as an ErrInvalidInput, leaving nil as it is.
+func InvalidInput(err error) error {
+	return wrap(ErrInvalidInput, err)
+}
+
+// InvalidInputf creates an ErrInvalidInput from a message, formatted like fmt.Errorf.
+func InvalidInputf(format string, args ...interface{}) error {
+	return InvalidInput(fmt.Errorf(format, args...))
+}
+
+// Conflict wraps err as an ErrConflict, leaving nil as it is.
+func Conflict(err error) error {
+	return wrap(ErrConflict, err)
+}
+
+// Storage wraps err as an ErrStorage, leaving nil, and errors that already are one of the kinds, as they are.
+func Storage(err error) error {
+	if err == nil || KindOf(err) != nil {
+		return err
+	}
+
+	return wrap(ErrStorage, err)
+}
+
+// KindOf gives the kind of err, or nil when it isn't one of them.
+func KindOf(err error) error {
+	for _, kind := range Kinds {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package noteerrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNoteErrors_Kinds(t *testing.T) {
+	cause := errors.New("the cause")
+
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{err: NotFound(cause), kind: ErrNotFound},
+		{err: InvalidInput(cause), kind: ErrInvalidInput},
+		{err: Conflict(cause), kind: ErrConflict},
+		{err: Storage(cause), kind: ErrStorage},
+		{err: fmt.Errorf("wrapped again: %w", Conflict(cause)), kind: ErrConflict},
+		{err: cause, kind: nil},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.kind, KindOf(test.err), test.err.Error())
+		assert.True(t, errors.Is(test.err, cause), test.err.Error())
+	}
+
+	assert.EqualError(t, NotFound(cause), "the cause")
+	assert.EqualError(t, InvalidInputf("bad value %v", 42), "bad value 42")
+}
+
+func TestNoteErrors_Nil(t *testing.T) {
+	assert.Nil(t, NotFound(nil))
+	assert.Nil(t, InvalidInput(nil))
+	assert.Nil(t, Conflict(nil))
+	assert.Nil(t, Storage(nil))
+}
+
+func TestNoteErrors_StorageKeepsKind(t *testing.T) {
+	err := Storage(NotFound(errors.New("note 5 does not exist")))
+
+	assert.Equal(t, ErrNotFound, KindOf(err))
+	assert.False(t, errors.Is(err, ErrStorage))
+}
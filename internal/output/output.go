@@ -0,0 +1,374 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Text     string = "text"
+	JSON     string = "json"
+	JSONL    string = "jsonl"
+	CSV      string = "csv"
+	YAML     string = "yaml"
+	Template string = "template"
+)
+
+// Formats lists the supported formats, for help messages
+var Formats = []string{Text, JSON, JSONL, CSV, YAML, Template + "=<go template>"}
+
+// TextFunc formats a value for the plain text output
+type TextFunc func(value interface{}) string
+
+// Writer writes a stream of values in one of the formats, one at a time, and has to be closed afterwards
+type Writer interface {
+	Write(value interface{}) error
+	Close() error
+}
+
+// NewWriter creates a writer for a list of values in one of the Formats, like json or template={{.Field}}
+func NewWriter(w io.Writer, format string, text TextFunc) (Writer, error) {
+	switch {
+	case format == Text || format == "":
+		return &textWriter{w: w, text: text}, nil
+	case format == JSON:
+		return &jsonWriter{w: w}, nil
+	case format == JSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case format == CSV:
+		return &csvWriter{csv: csv.NewWriter(w)}, nil
+	case format == YAML:
+		return &yamlWriter{w: w}, nil
+	case strings.HasPrefix(format, Template+"="):
+		tmpl, err := template.New("output").Funcs(templateFuncs).Parse(strings.TrimPrefix(format, Template+"="))
+		if err != nil {
+			return nil, err
+		}
+
+		return &templateWriter{w: w, tmpl: tmpl}, nil
+	}
+
+	return nil, fmt.Errorf("unknown output format '%v', supported formats are: %v", format, strings.Join(Formats, ", "))
+}
+
+// Validate checks that a format is supported, before doing any work that would get wasted.
+func Validate(format string) error {
+	_, err := NewWriter(io.Discard, format, nil)
+
+	return err
+}
+
+// WriteOne writes a single value, where JSON and YAML give an object rather than a list.
+func WriteOne(w io.Writer, format string, value interface{}, text TextFunc) error {
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	case YAML:
+		node, err := toYAMLNode(value)
+		if err != nil {
+			return err
+		}
+
+		return writeYAML(w, node)
+	}
+
+	writer, err := NewWriter(w, format, text)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Write(value)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(value interface{}) (string, error) {
+		marshalled, err := json.Marshal(value)
+
+		return string(marshalled), err
+	},
+}
+
+type textWriter struct {
+	w    io.Writer
+	text TextFunc
+}
+
+func (writer *textWriter) Write(value interface{}) error {
+	_, err := fmt.Fprintln(writer.w, writer.text(value))
+
+	return err
+}
+
+func (writer *textWriter) Close() error {
+	return nil
+}
+
+// jsonWriter writes a JSON array, one element at a time
+type jsonWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (writer *jsonWriter) Write(value interface{}) error {
+	marshalled, err := json.MarshalIndent(value, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if writer.written == 0 {
+		separator = "[\n  "
+	}
+
+	writer.written++
+
+	_, err = fmt.Fprintf(writer.w, "%s%s", separator, marshalled)
+
+	return err
+}
+
+func (writer *jsonWriter) Close() error {
+	if writer.written == 0 {
+		_, err := fmt.Fprintln(writer.w, "[]")
+
+		return err
+	}
+
+	_, err := fmt.Fprintln(writer.w, "\n]")
+
+	return err
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (writer *jsonlWriter) Write(value interface{}) error {
+	return writer.encoder.Encode(value)
+}
+
+func (writer *jsonlWriter) Close() error {
+	return nil
+}
+
+// csvWriter writes a header row from the JSON names of the first value's fields, followed by a row for each value
+type csvWriter struct {
+	csv          *csv.Writer
+	wroteHeaders bool
+}
+
+func (writer *csvWriter) Write(value interface{}) error {
+	fields := flatten(reflect.ValueOf(value), "")
+
+	if !writer.wroteHeaders {
+		headers := make([]string, 0, len(fields))
+
+		for _, field := range fields {
+			headers = append(headers, field.name)
+		}
+
+		err := writer.csv.Write(headers)
+		if err != nil {
+			return err
+		}
+
+		writer.wroteHeaders = true
+	}
+
+	record := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		record = append(record, field.value)
+	}
+
+	return writer.csv.Write(record)
+}
+
+func (writer *csvWriter) Close() error {
+	writer.csv.Flush()
+
+	return writer.csv.Error()
+}
+
+type csvField struct {
+	name  string
+	value string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// flatten turns a struct into CSV fields named after their json tags, like note.id for nested ones
+func flatten(value reflect.Value, prefix string) []csvField {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	fields := make([]csvField, 0)
+
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+
+		name := strings.Split(structField.Tag.Get("json"), ",")[0]
+		if name == "-" || !structField.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = structField.Name
+		}
+
+		fieldValue := value.Field(i)
+
+		fieldType := structField.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && fieldType != timeType {
+			fields = append(fields, flatten(fieldValue, prefix+name+".")...)
+			continue
+		}
+
+		fields = append(fields, csvField{name: prefix + name, value: csvValue(fieldValue)})
+	}
+
+	return fields
+}
+
+func csvValue(value reflect.Value) string {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+
+		value = value.Elem()
+	}
+
+	switch typed := value.Interface().(type) {
+	case time.Time:
+		return typed.Format(time.RFC3339Nano)
+	case []string:
+		return strings.Join(typed, ",")
+	}
+
+	return fmt.Sprint(value.Interface())
+}
+
+// yamlWriter writes a YAML sequence, one element at a time
+type yamlWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (writer *yamlWriter) Write(value interface{}) error {
+	node, err := toYAMLNode(value)
+	if err != nil {
+		return err
+	}
+
+	writer.written++
+
+	return writeYAML(writer.w, &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{node}})
+}
+
+func (writer *yamlWriter) Close() error {
+	if writer.written == 0 {
+		_, err := fmt.Fprintln(writer.w, "[]")
+
+		return err
+	}
+
+	return nil
+}
+
+// toYAMLNode goes by way of JSON, which is valid YAML, to use the json tags and keep the field order
+func toYAMLNode(value interface{}) (*yaml.Node, error) {
+	marshalled, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &yaml.Node{}
+
+	err = yaml.Unmarshal(marshalled, document)
+	if err != nil {
+		return nil, err
+	}
+
+	node := document.Content[0]
+	blockStyle(node)
+
+	return node, nil
+}
+
+// blockStyle undoes the flow style and quoting that nodes parsed from JSON come with
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+func writeYAML(w io.Writer, node *yaml.Node) error {
+	buffer := new(bytes.Buffer)
+
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(node)
+	if err != nil {
+		return err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buffer.Bytes())
+
+	return err
+}
+
+type templateWriter struct {
+	w    io.Writer
+	tmpl *template.Template
+}
+
+func (writer *templateWriter) Write(value interface{}) error {
+	err := writer.tmpl.Execute(writer.w, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(writer.w)
+
+	return err
+}
+
+func (writer *templateWriter) Close() error {
+	return nil
+}
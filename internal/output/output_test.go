@@ -0,0 +1,180 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"note-logger/internal/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updatedAt = time.Date(2022, time.April, 12, 17, 0, 0, 0, time.UTC)
+
+var testNotes = []*entities.Note{
+	{
+		ID:        1,
+		Content:   "First note, with a comma",
+		CreatedAt: time.Date(2022, time.April, 12, 16, 26, 19, 0, time.UTC),
+	},
+	{
+		ID:        2,
+		Content:   "Second note #work",
+		CreatedAt: time.Date(2022, time.April, 12, 16, 37, 16, 0, time.UTC),
+		UpdatedAt: &updatedAt,
+		Tags:      []string{"ci", "work"},
+	},
+}
+
+func textNote(value interface{}) string {
+	note := value.(*entities.Note)
+
+	return fmt.Sprintf("%v: %v", note.ID, note.Content)
+}
+
+func writeAll(t *testing.T, format string, values []*entities.Note) string {
+	buffer := new(bytes.Buffer)
+
+	writer, err := NewWriter(buffer, format, textNote)
+	require.NoError(t, err)
+
+	for _, value := range values {
+		err = writer.Write(value)
+		require.NoError(t, err)
+	}
+
+	err = writer.Close()
+	require.NoError(t, err)
+
+	return buffer.String()
+}
+
+func TestOutput_NewWriter(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		assert.Equal(t, "1: First note, with a comma\n2: Second note #work\n", writeAll(t, Text, testNotes))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		assert.Equal(t, `[
+  {
+    "id": 1,
+    "content": "First note, with a comma",
+    "created_at": "2022-04-12T16:26:19Z"
+  },
+  {
+    "id": 2,
+    "content": "Second note #work",
+    "created_at": "2022-04-12T16:37:16Z",
+    "updated_at": "2022-04-12T17:00:00Z",
+    "tags": [
+      "ci",
+      "work"
+    ]
+  }
+]
+`, writeAll(t, JSON, testNotes))
+	})
+
+	t.Run("empty json", func(t *testing.T) {
+		assert.Equal(t, "[]\n", writeAll(t, JSON, nil))
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		assert.Equal(t, `{"id":1,"content":"First note, with a comma","created_at":"2022-04-12T16:26:19Z"}
+{"id":2,"content":"Second note #work","created_at":"2022-04-12T16:37:16Z","updated_at":"2022-04-12T17:00:00Z","tags":["ci","work"]}
+`, writeAll(t, JSONL, testNotes))
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		assert.Equal(t, `id,content,created_at,updated_at,deleted_at,tags
+1,"First note, with a comma",2022-04-12T16:26:19Z,,,
+2,Second note #work,2022-04-12T16:37:16Z,2022-04-12T17:00:00Z,,"ci,work"
+`, writeAll(t, CSV, testNotes))
+	})
+
+	t.Run("csv with nested structs", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+
+		writer, err := NewWriter(buffer, CSV, nil)
+		require.NoError(t, err)
+
+		err = writer.Write(&entities.SearchResult{Note: testNotes[0], Snippet: "[First] note", Rank: -1.5})
+		require.NoError(t, err)
+
+		err = writer.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, `note.id,note.content,note.created_at,note.updated_at,note.deleted_at,note.tags,snippet,rank
+1,"First note, with a comma",2022-04-12T16:26:19Z,,,,[First] note,-1.5
+`, buffer.String())
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		assert.Equal(t, `- id: 1
+  content: First note, with a comma
+  created_at: "2022-04-12T16:26:19Z"
+- id: 2
+  content: 'Second note #work'
+  created_at: "2022-04-12T16:37:16Z"
+  updated_at: "2022-04-12T17:00:00Z"
+  tags:
+  - ci
+  - work
+`, writeAll(t, YAML, testNotes))
+	})
+
+	t.Run("template", func(t *testing.T) {
+		actual := writeAll(t, "template={{.ID}} [{{join .Tags \",\"}}] {{.Content}}", testNotes)
+
+		assert.Equal(t, "1 [] First note, with a comma\n2 [ci,work] Second note #work\n", actual)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		_, err := NewWriter(new(bytes.Buffer), "template={{.ID", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := NewWriter(new(bytes.Buffer), "xml", nil)
+		assert.Equal(t, errors.New("unknown output format 'xml', supported formats are: "+
+			"text, json, jsonl, csv, yaml, template=<go template>"), err)
+	})
+}
+
+func TestOutput_WriteOne(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+
+		err := WriteOne(buffer, JSON, testNotes[0], textNote)
+		assert.NoError(t, err)
+		assert.Equal(t, `{
+  "id": 1,
+  "content": "First note, with a comma",
+  "created_at": "2022-04-12T16:26:19Z"
+}
+`, buffer.String())
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+
+		err := WriteOne(buffer, YAML, testNotes[0], textNote)
+		assert.NoError(t, err)
+		assert.Equal(t, `id: 1
+content: First note, with a comma
+created_at: "2022-04-12T16:26:19Z"
+`, buffer.String())
+	})
+
+	t.Run("text", func(t *testing.T) {
+		buffer := new(bytes.Buffer)
+
+		err := WriteOne(buffer, Text, testNotes[0], textNote)
+		assert.NoError(t, err)
+		assert.Equal(t, "1: First note, with a comma\n", buffer.String())
+	})
+}
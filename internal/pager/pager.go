@@ -0,0 +1,96 @@
+package pager
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Fallback is the pager used when $PAGER isn't set
+const Fallback string = "less"
+
+// lessOptions are used when $LESS isn't set, the same as git does
+const lessOptions string = "FRX"
+
+// Command creates the command for paging through output from $PAGER, or nil when it's set to nothing or cat
+func Command() *exec.Cmd {
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = Fallback
+	}
+
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+
+	//nolint:gosec
+	cmd := exec.Command(args[0], args[1:]...)
+
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS="+lessOptions)
+	}
+
+	return cmd
+}
+
+// Start runs the pager on out, giving back the writer to page through, or out itself without a pager that starts
+func Start(out io.Writer) (io.WriteCloser, error) {
+	cmd := Command()
+	if cmd == nil {
+		return nopCloser{out}, nil
+	}
+
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		//nolint
+		stdin.Close()
+
+		return nopCloser{out}, nil
+	}
+
+	return &pagerWriter{stdin: stdin, cmd: cmd}, nil
+}
+
+type pagerWriter struct {
+	stdin io.WriteCloser
+	cmd   *exec.Cmd
+}
+
+// Write ignores the pager having been quit before everything was written
+func (w *pagerWriter) Write(p []byte) (int, error) {
+	n, err := w.stdin.Write(p)
+	if errors.Is(err, syscall.EPIPE) {
+		return len(p), nil
+	}
+
+	return n, err
+}
+
+func (w *pagerWriter) Close() error {
+	err := w.stdin.Close()
+	if err != nil && !errors.Is(err, syscall.EPIPE) {
+		return err
+	}
+
+	return w.cmd.Wait()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
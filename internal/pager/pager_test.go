@@ -0,0 +1,81 @@
+package pager
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	for name, tc := range map[string]struct {
+		pager    string
+		expected []string
+	}{
+		"pager":          {pager: "more", expected: []string{"more"}},
+		"with arguments": {pager: "less -S", expected: []string{"less", "-S"}},
+		"set to nothing": {pager: " ", expected: nil},
+		"set to cat":     {pager: "cat", expected: nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("PAGER", tc.pager)
+
+			cmd := Command()
+			if tc.expected == nil {
+				assert.Nil(t, cmd)
+				return
+			}
+
+			require.NotNil(t, cmd)
+			assert.Equal(t, tc.expected, cmd.Args)
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	t.Run("pages through the pager", func(t *testing.T) {
+		t.Setenv("PAGER", "sed s/^/paged:/")
+
+		out := new(bytes.Buffer)
+
+		w, err := Start(out)
+		require.NoError(t, err)
+
+		_, err = io.WriteString(w, "first\nsecond\n")
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+		assert.Equal(t, "paged:first\npaged:second\n", out.String())
+	})
+
+	t.Run("writes straight out without a pager", func(t *testing.T) {
+		t.Setenv("PAGER", "")
+
+		out := new(bytes.Buffer)
+
+		w, err := Start(out)
+		require.NoError(t, err)
+
+		_, err = io.WriteString(w, "unpaged\n")
+		require.NoError(t, err)
+
+		require.NoError(t, w.Close())
+		assert.Equal(t, "unpaged\n", out.String())
+	})
+
+	t.Run("ignores the pager being quit early", func(t *testing.T) {
+		t.Setenv("PAGER", "true")
+
+		w, err := Start(new(bytes.Buffer))
+		require.NoError(t, err)
+
+		for i := 0; i < 1000; i++ {
+			_, err = io.WriteString(w, "more output than the pager wants to see\n")
+			require.NoError(t, err)
+		}
+
+		assert.NoError(t, w.Close())
+	})
+}
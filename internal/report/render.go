@@ -0,0 +1,168 @@
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/tags"
+)
+
+const (
+	Text     string = "text"
+	Markdown string = "markdown"
+	HTML     string = "html"
+)
+
+var Formats = []string{Text, Markdown, HTML}
+
+// the widest the bars in the text report get, for the busiest hour
+const barWidth int = 20
+
+//go:embed templates
+var templates embed.FS
+
+// templateNames are the built-in templates, which are also the names of the files that override them
+var templateNames = map[string]string{
+	Text:     "report.txt.tmpl",
+	Markdown: "report.md.tmpl",
+	HTML:     "report.html.tmpl",
+}
+
+var templateFuncs = map[string]interface{}{
+	"day": func(t interface{}) string {
+		return asTime(t).Format("Monday, 2006-01-02")
+	},
+	"clock": func(t interface{}) string {
+		return asTime(t).Format("15:04")
+	},
+	"datetime": func(t interface{}) string {
+		return asTime(t).Format("2006-01-02 15:04 MST")
+	},
+	"hour": func(hour int) string {
+		return fmt.Sprintf("%02d:00", hour)
+	},
+	"period": period,
+	"plural": func(count int, word string) string {
+		if count == 1 {
+			return fmt.Sprintf("%v %v", count, word)
+		}
+
+		return fmt.Sprintf("%v %vs", count, word)
+	},
+	"summary": summary,
+	"bar":     bar,
+	"join":    strings.Join,
+}
+
+// TemplateName gives the name of the built-in template for the format, and of the file to override it with
+func TemplateName(format string) (string, error) {
+	name, ok := templateNames[format]
+	if !ok {
+		return "", noteerrors.InvalidInputf("unknown report format '%v', supported formats are: %v",
+			format, strings.Join(Formats, ", "))
+	}
+
+	return name, nil
+}
+
+// DefaultTemplate gives the built-in template for the format, as a starting point for one of the user's own
+func DefaultTemplate(format string) (string, error) {
+	name, err := TemplateName(format)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := templates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", err
+	}
+
+	return string(contents), nil
+}
+
+// Render writes the report in the format, with tmpl as the template, or the built-in one if it's empty
+func Render(w io.Writer, format string, report *Report, tmpl string) error {
+	_, err := TemplateName(format)
+	if err != nil {
+		return err
+	}
+
+	if tmpl == "" {
+		tmpl, err = DefaultTemplate(format)
+		if err != nil {
+			return err
+		}
+	}
+
+	var parsed interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+
+	if format == HTML {
+		parsed, err = htmltemplate.New(format).Funcs(templateFuncs).Parse(tmpl)
+	} else {
+		parsed, err = template.New(format).Funcs(templateFuncs).Parse(tmpl)
+	}
+
+	if err != nil {
+		return noteerrors.InvalidInputf("invalid report template: %w", err)
+	}
+
+	return parsed.Execute(w, report)
+}
+
+// asTime lets the template functions take the report's optional times as well as the ones that are always there
+func asTime(t interface{}) time.Time {
+	switch value := t.(type) {
+	case time.Time:
+		return value
+	case *time.Time:
+		if value != nil {
+			return *value
+		}
+	}
+
+	return time.Time{}
+}
+
+// period describes the days from start to end, like "Saturday, 2026-03-14"
+func period(start time.Time, end time.Time) string {
+	const layout = "Monday, 2006-01-02"
+
+	switch {
+	case start.IsZero():
+		return "everything up to " + end.Format(layout)
+	case start.Format(layout) == end.Format(layout):
+		return start.Format(layout)
+	}
+
+	return start.Format(layout) + " to " + end.Format(layout)
+}
+
+// summary is the first line of a note, with its tags
+func summary(note *entities.Note) string {
+	firstLine, _, _ := strings.Cut(note.Content, "\n")
+
+	return tags.AppendMissing(firstLine, note.Tags)
+}
+
+// bar draws count as a bar, scaled for the most there is to fill barWidth
+func bar(count int, most int) string {
+	if most <= 0 {
+		return ""
+	}
+
+	width := count * barWidth / most
+	if width == 0 && count > 0 {
+		width = 1
+	}
+
+	return strings.Repeat("#", width)
+}
@@ -0,0 +1,196 @@
+// Package report works out a digest of the notes from a period, and renders it as text, Markdown or HTML.
+package report
+
+import (
+	"sort"
+	"time"
+
+	"note-logger/internal/entities"
+)
+
+// how many of the busiest days and hours a report picks out
+const busiestCount int = 3
+
+// Report is the digest of the notes from Start to End
+type Report struct {
+	Start    time.Time        `json:"start"`
+	End      time.Time        `json:"end"`
+	Count    int              `json:"count"`
+	FirstAt  *time.Time       `json:"first_at,omitempty"`
+	LastAt   *time.Time       `json:"last_at,omitempty"`
+	Days     []*Day           `json:"days"`
+	Tags     []*Tag           `json:"tags"`
+	Hours    []*Hour          `json:"hours"`
+	Untagged []*entities.Note `json:"untagged"`
+	Busiest  Busiest          `json:"busiest"`
+}
+
+// Day is the notes from one day, oldest first
+type Day struct {
+	Date    time.Time        `json:"date"`
+	Count   int              `json:"count"`
+	FirstAt time.Time        `json:"first_at"`
+	LastAt  time.Time        `json:"last_at"`
+	Notes   []*entities.Note `json:"notes"`
+}
+
+// Tag is the notes with a tag, where notes with several tags are under each of them
+type Tag struct {
+	Name  string           `json:"name"`
+	Count int              `json:"count"`
+	Notes []*entities.Note `json:"notes"`
+}
+
+// Hour is how many notes were written in an hour of the day, across every day in the report
+type Hour struct {
+	Hour  int `json:"hour"`
+	Count int `json:"count"`
+}
+
+// Busiest picks out the days and hours of the day with the most notes
+type Busiest struct {
+	Days  []*Day  `json:"days"`
+	Hours []*Hour `json:"hours"`
+}
+
+// Build works out the report for notes given oldest first, with the days and hours in loc, or each note's own offset
+func Build(notes []*entities.Note, start time.Time, end time.Time, loc *time.Location) *Report {
+	report := &Report{
+		Start:    in(start, loc),
+		End:      in(end, loc),
+		Days:     make([]*Day, 0),
+		Tags:     make([]*Tag, 0),
+		Hours:    make([]*Hour, 0),
+		Untagged: make([]*entities.Note, 0),
+	}
+
+	byTag := make(map[string]*Tag)
+	byHour := make(map[int]*Hour)
+
+	for _, original := range notes {
+		// a copy, as the caller's notes keep their own times
+		note := *original
+		note.CreatedAt = in(note.CreatedAt, loc)
+
+		report.add(&note, byTag, byHour)
+	}
+
+	for _, tag := range byTag {
+		report.Tags = append(report.Tags, tag)
+	}
+
+	sort.Slice(report.Tags, func(i, j int) bool {
+		if report.Tags[i].Count != report.Tags[j].Count {
+			return report.Tags[i].Count > report.Tags[j].Count
+		}
+
+		return report.Tags[i].Name < report.Tags[j].Name
+	})
+
+	for _, hour := range byHour {
+		report.Hours = append(report.Hours, hour)
+	}
+
+	sort.Slice(report.Hours, func(i, j int) bool {
+		return report.Hours[i].Hour < report.Hours[j].Hour
+	})
+
+	report.Busiest = Busiest{
+		Days:  busiestDays(report.Days),
+		Hours: busiestHours(report.Hours),
+	}
+
+	return report
+}
+
+func (report *Report) add(note *entities.Note, byTag map[string]*Tag, byHour map[int]*Hour) {
+	createdAt := note.CreatedAt
+
+	report.Count++
+
+	if report.FirstAt == nil {
+		report.FirstAt = &createdAt
+	}
+
+	report.LastAt = &createdAt
+
+	date := time.Date(createdAt.Year(), createdAt.Month(), createdAt.Day(), 0, 0, 0, 0, createdAt.Location())
+
+	// with each note in its own offset, midnight on the same date isn't the same instant
+	var day *Day
+	if len(report.Days) > 0 && sameDate(report.Days[len(report.Days)-1].Date, date) {
+		day = report.Days[len(report.Days)-1]
+	} else {
+		day = &Day{Date: date, FirstAt: createdAt}
+		report.Days = append(report.Days, day)
+	}
+
+	day.Count++
+	day.LastAt = createdAt
+	day.Notes = append(day.Notes, note)
+
+	for _, name := range note.Tags {
+		tag, ok := byTag[name]
+		if !ok {
+			tag = &Tag{Name: name}
+			byTag[name] = tag
+		}
+
+		tag.Count++
+		tag.Notes = append(tag.Notes, note)
+	}
+
+	if len(note.Tags) == 0 {
+		report.Untagged = append(report.Untagged, note)
+	}
+
+	hour, ok := byHour[createdAt.Hour()]
+	if !ok {
+		hour = &Hour{Hour: createdAt.Hour()}
+		byHour[createdAt.Hour()] = hour
+	}
+
+	hour.Count++
+}
+
+func sameDate(a time.Time, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
+
+// busiestDays gives the days with the most notes, with the earlier day first when there's a tie
+func busiestDays(days []*Day) []*Day {
+	busiest := append([]*Day(nil), days...)
+
+	sort.SliceStable(busiest, func(i, j int) bool {
+		return busiest[i].Count > busiest[j].Count
+	})
+
+	if len(busiest) > busiestCount {
+		busiest = busiest[:busiestCount]
+	}
+
+	return busiest
+}
+
+// busiestHours gives the hours of the day with the most notes, with the earlier hour first when there's a tie
+func busiestHours(hours []*Hour) []*Hour {
+	busiest := append([]*Hour(nil), hours...)
+
+	sort.SliceStable(busiest, func(i, j int) bool {
+		return busiest[i].Count > busiest[j].Count
+	})
+
+	if len(busiest) > busiestCount {
+		busiest = busiest[:busiestCount]
+	}
+
+	return busiest
+}
+
+func in(t time.Time, loc *time.Location) time.Time {
+	if loc == nil || t.IsZero() {
+		return t
+	}
+
+	return t.In(loc)
+}
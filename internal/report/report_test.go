@@ -0,0 +1,202 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	start = time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC)
+	end   = time.Date(2026, time.March, 15, 23, 59, 59, 0, time.UTC)
+)
+
+var testNotes = []*entities.Note{
+	{
+		ID:        1,
+		Content:   "Standup #work",
+		CreatedAt: time.Date(2026, time.March, 9, 9, 15, 0, 0, time.UTC),
+		Tags:      []string{"work"},
+	},
+	{
+		ID:        2,
+		Content:   "Fixed the <flaky> build\nit was the clock",
+		CreatedAt: time.Date(2026, time.March, 9, 9, 45, 0, 0, time.UTC),
+		Tags:      []string{"ci", "work"},
+	},
+	{
+		ID:        3,
+		Content:   "Lunch",
+		CreatedAt: time.Date(2026, time.March, 9, 12, 30, 0, 0, time.UTC),
+	},
+	{
+		ID:        4,
+		Content:   "Planning #work",
+		CreatedAt: time.Date(2026, time.March, 11, 9, 5, 0, 0, time.UTC),
+		Tags:      []string{"work"},
+	},
+}
+
+func render(t *testing.T, format string, report *Report, tmpl string) string {
+	buffer := new(bytes.Buffer)
+
+	err := Render(buffer, format, report, tmpl)
+	require.NoError(t, err)
+
+	return buffer.String()
+}
+
+func TestBuild(t *testing.T) {
+	t.Run("groups notes by day, tag and hour", func(t *testing.T) {
+		report := Build(testNotes, start, end, nil)
+
+		assert.Equal(t, 4, report.Count)
+		assert.Equal(t, testNotes[0].CreatedAt, *report.FirstAt)
+		assert.Equal(t, testNotes[3].CreatedAt, *report.LastAt)
+
+		require.Len(t, report.Days, 2)
+		assert.Equal(t, time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC), report.Days[0].Date)
+		assert.Equal(t, 3, report.Days[0].Count)
+		assert.Equal(t, testNotes[0].CreatedAt, report.Days[0].FirstAt)
+		assert.Equal(t, testNotes[2].CreatedAt, report.Days[0].LastAt)
+		assert.Equal(t, 1, report.Days[1].Count)
+
+		require.Len(t, report.Tags, 2)
+		assert.Equal(t, "work", report.Tags[0].Name)
+		assert.Equal(t, 3, report.Tags[0].Count)
+		assert.Equal(t, "ci", report.Tags[1].Name)
+
+		assert.Equal(t, []*Hour{{Hour: 9, Count: 3}, {Hour: 12, Count: 1}}, report.Hours)
+		assert.Equal(t, []*Hour{{Hour: 9, Count: 3}, {Hour: 12, Count: 1}}, report.Busiest.Hours)
+		assert.Equal(t, []*Day{report.Days[0], report.Days[1]}, report.Busiest.Days)
+
+		require.Len(t, report.Untagged, 1)
+		assert.Equal(t, "Lunch", report.Untagged[0].Content)
+	})
+
+	t.Run("works out the days and hours in the zone given", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+
+		report := Build(testNotes, start, end, tokyo)
+
+		require.Len(t, report.Days, 2)
+		assert.Equal(t, time.Date(2026, time.March, 9, 0, 0, 0, 0, tokyo), report.Days[0].Date)
+		assert.Equal(t, []*Hour{{Hour: 18, Count: 3}, {Hour: 21, Count: 1}}, report.Hours)
+
+		// the notes that were given keep their own times
+		assert.Equal(t, time.UTC, testNotes[0].CreatedAt.Location())
+	})
+
+	t.Run("keeps a date together across offsets", func(t *testing.T) {
+		mixed := []*entities.Note{
+			{ID: 1, Content: "Early", CreatedAt: time.Date(2026, time.March, 9, 8, 0, 0, 0, time.FixedZone("", 2*60*60))},
+			{ID: 2, Content: "Late", CreatedAt: time.Date(2026, time.March, 9, 9, 0, 0, 0, time.FixedZone("", -5*60*60))},
+		}
+
+		report := Build(mixed, start, end, nil)
+
+		require.Len(t, report.Days, 1)
+		assert.Equal(t, 2, report.Days[0].Count)
+		assert.Equal(t, []*Hour{{Hour: 8, Count: 1}, {Hour: 9, Count: 1}}, report.Hours)
+	})
+
+	t.Run("no notes", func(t *testing.T) {
+		report := Build(nil, start, end, nil)
+
+		assert.Equal(t, 0, report.Count)
+		assert.Nil(t, report.FirstAt)
+		assert.Empty(t, report.Days)
+		assert.Empty(t, report.Busiest.Hours)
+	})
+}
+
+func TestRender_Text(t *testing.T) {
+	assert.Equal(t, `Report for Monday, 2026-03-09 to Sunday, 2026-03-15: 4 notes, from 2026-03-09 09:15 UTC to 2026-03-11 09:05 UTC
+
+By day:
+  Monday, 2026-03-09: 3 notes, 09:15 to 12:30
+    09:15 Standup #work
+    09:45 Fixed the <flaky> build #ci #work
+    12:30 Lunch
+  Wednesday, 2026-03-11: 1 note, 09:05 to 09:05
+    09:05 Planning #work
+
+By tag:
+  #work: 3 notes
+  #ci: 1 note
+
+By hour:
+  09:00 #################### 3
+  12:00 ######               1
+
+Busiest days:
+  Monday, 2026-03-09: 3 notes
+  Wednesday, 2026-03-11: 1 note
+
+Busiest hours:
+  09:00: 3 notes
+  12:00: 1 note
+
+Untagged notes:
+  2026-03-09 12:30 UTC Lunch
+`, render(t, Text, Build(testNotes, start, end, nil), ""))
+
+	assert.Equal(t, "Report for Saturday, 2026-03-14: no notes\n",
+		render(t, Text, Build(nil, start.AddDate(0, 0, 5), start.AddDate(0, 0, 5), nil), ""))
+}
+
+func TestRender_Markdown(t *testing.T) {
+	actual := render(t, Markdown, Build(testNotes, start, end, nil), "")
+
+	assert.Contains(t, actual, "# Report for Monday, 2026-03-09 to Sunday, 2026-03-15\n\n4 notes,")
+	assert.Contains(t, actual, "### Monday, 2026-03-09\n\n3 notes, 09:15 to 12:30.\n\n- 09:15 Standup #work\n")
+	assert.Contains(t, actual, "| 09:00 | 3 |\n| 12:00 | 1 |\n")
+	assert.Contains(t, actual, "## Untagged Notes\n\n- 2026-03-09 12:30 UTC Lunch\n")
+
+	assert.Equal(t, "# Report for Monday, 2026-03-09 to Sunday, 2026-03-15\n\nNo notes.\n",
+		render(t, Markdown, Build(nil, start, end, nil), ""))
+}
+
+func TestRender_HTML(t *testing.T) {
+	actual := render(t, HTML, Build(testNotes, start, end, nil), "")
+
+	assert.Contains(t, actual, "<title>Report for Monday, 2026-03-09 to Sunday, 2026-03-15</title>")
+	assert.Contains(t, actual, "Fixed the &lt;flaky&gt; build\nit was the clock")
+	assert.Contains(t, actual, `<span class="bar" style="width: 20rem"></span>`)
+	assert.NotContains(t, actual, "<flaky>")
+}
+
+func TestRender_Template(t *testing.T) {
+	t.Run("the user's own", func(t *testing.T) {
+		report := Build(testNotes, start, end, nil)
+
+		assert.Equal(t, "4 notes: work=3 ci=1",
+			render(t, Text, report, `{{plural .Count "note"}}:{{range .Tags}} {{.Name}}={{.Count}}{{end}}`))
+		assert.Equal(t, "<b>Fixed the &lt;flaky&gt; build</b>",
+			render(t, HTML, report, `<b>{{index (index .Days 0).Notes 1 | summary | printf "%.23s"}}</b>`))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := Render(new(bytes.Buffer), Text, Build(nil, start, end, nil), "{{.Count")
+		assert.True(t, errors.Is(err, noteerrors.ErrInvalidInput), err)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		err := Render(new(bytes.Buffer), "pdf", Build(nil, start, end, nil), "")
+		assert.EqualError(t, err, "unknown report format 'pdf', supported formats are: text, markdown, html")
+	})
+
+	t.Run("default", func(t *testing.T) {
+		tmpl, err := DefaultTemplate(Markdown)
+		require.NoError(t, err)
+		assert.Contains(t, tmpl, "## By Day")
+	})
+}
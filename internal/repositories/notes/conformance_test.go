@@ -0,0 +1,112 @@
+package notes_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"note-logger/internal/databases/postgres"
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/repositories/notes"
+	"note-logger/internal/repositories/notes/notestest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance_SQLite(t *testing.T) {
+	notestest.Run(t, &notestest.Backend{
+		Open: func(t *testing.T, dir string) notes.Repository {
+			db, err := sqlite.New(context.Background(), &sqlite.Config{Filename: filepath.Join(dir, "notes.sqlite")})
+			require.NoError(t, err)
+
+			t.Cleanup(func() {
+				//nolint
+				db.Close()
+			})
+
+			repo, err := notes.NewRepository(&notes.Config{DB: db})
+			require.NoError(t, err)
+
+			return repo
+		},
+		Persistent: true,
+		Search:     sqlite.FTS5Enabled,
+	})
+}
+
+// postgresTestEnvVar holds a connection string for a Postgres DB that the tests are free to empty out
+const postgresTestEnvVar string = "NOTE_LOGGER_TEST_POSTGRES"
+
+func TestConformance_Postgres(t *testing.T) {
+	dsn := os.Getenv(postgresTestEnvVar)
+	if dsn == "" {
+		t.Skipf("set %v to a test DB's connection string to run the Postgres conformance tests", postgresTestEnvVar)
+	}
+
+	// each test starts with an empty DB, while reopening it for the same dir keeps the notes
+	openedDirs := make(map[string]bool)
+
+	notestest.Run(t, &notestest.Backend{
+		Open: func(t *testing.T, dir string) notes.Repository {
+			ctx := context.Background()
+
+			db, err := postgres.New(ctx, &postgres.Config{DSN: dsn})
+			require.NoError(t, err)
+
+			t.Cleanup(func() {
+				//nolint
+				db.Close()
+			})
+
+			if !openedDirs[dir] {
+				openedDirs[dir] = true
+
+				_, err = db.ExecContext(ctx, "TRUNCATE notes, tags, note_tags, note_revisions RESTART IDENTITY")
+				require.NoError(t, err)
+			}
+
+			repo, err := notes.NewPostgresRepository(&notes.Config{DB: db})
+			require.NoError(t, err)
+
+			return repo
+		},
+		Persistent: true,
+		Search:     true,
+	})
+}
+
+func TestConformance_Memory(t *testing.T) {
+	notestest.Run(t, &notestest.Backend{
+		Open: func(t *testing.T, dir string) notes.Repository {
+			return notes.NewMemoryRepository(&notes.MemoryConfig{})
+		},
+		Search: true,
+	})
+}
+
+func TestConformance_JSONL(t *testing.T) {
+	notestest.Run(t, &notestest.Backend{
+		Open: func(t *testing.T, dir string) notes.Repository {
+			repo, err := notes.NewJSONLRepository(&notes.JSONLConfig{Filename: filepath.Join(dir, "notes.jsonl")})
+			require.NoError(t, err)
+
+			return repo
+		},
+		Persistent: true,
+		Search:     true,
+	})
+}
+
+func TestConformance_MarkdownDir(t *testing.T) {
+	notestest.Run(t, &notestest.Backend{
+		Open: func(t *testing.T, dir string) notes.Repository {
+			repo, err := notes.NewMarkdownDirRepository(&notes.MarkdownDirConfig{Dir: filepath.Join(dir, "notes")})
+			require.NoError(t, err)
+
+			return repo
+		},
+		Persistent: true,
+		Search:     true,
+	})
+}
@@ -11,6 +11,58 @@ import (
 
 type Repository interface {
 	Create(ctx context.Context, note *entities.Note) (*entities.Note, error)
-	ListBetween(ctx context.Context, startTime time.Time, endTime time.Time) ([]*entities.Note, error)
+	Get(ctx context.Context, noteID int64) (*entities.Note, error)
+	List(ctx context.Context, opts *ListOptions) ([]*entities.Note, error)
+	ListTags(ctx context.Context) ([]*entities.TagCount, error)
+	ListCreatedTimes(ctx context.Context, startTime time.Time, endTime time.Time) ([]time.Time, error)
+	ForEach(ctx context.Context, startTime time.Time, endTime time.Time, filter *TagFilter,
+		fn func(note *entities.Note) error) error
+	Update(ctx context.Context, note *entities.Note, opts *UpdateOptions) (*entities.Note, error)
+	ListRevisions(ctx context.Context, noteID int64) ([]*entities.Revision, error)
 	Delete(ctx context.Context, noteID int64) error
+	ListTrash(ctx context.Context) ([]*entities.Note, error)
+	Restore(ctx context.Context, noteID int64) error
+	Purge(ctx context.Context, olderThan time.Time) (int64, error)
+	Search(ctx context.Context, query string, opts *SearchOptions) ([]*entities.SearchResult, error)
+	Import(ctx context.Context, batch []*entities.Note, dryRun bool) (*ImportResult, error)
+	// Close closes the DB the repository was given, once nothing else needs it
+	Close() error
+}
+
+// SearchOptions narrows down a search, where a zero StartTime or EndTime leaves that side open
+type SearchOptions struct {
+	StartTime      time.Time
+	EndTime        time.Time
+	Limit          int
+	HighlightStart string
+	HighlightEnd   string
+}
+
+// ListOptions picks out a page of notes, oldest first unless Reverse is set, carrying on after the note AfterID. A zero
+// StartTime or EndTime leaves that side open, and a Limit of 0 lists every note there is.
+type ListOptions struct {
+	StartTime time.Time
+	EndTime   time.Time
+	Filter    *TagFilter
+	Reverse   bool
+	AfterID   int64
+	Offset    int
+	Limit     int
+}
+
+// UpdateOptions has the tags to take off a note, even ones from #hashtags
+type UpdateOptions struct {
+	RemoveTags []string
+}
+
+// TagFilter matches notes with any of the tags, or with all of them when MatchAll is set
+type TagFilter struct {
+	Tags     []string
+	MatchAll bool
+}
+
+// ImportResult splits the notes given to an import into the ones added and the ones already there
+type ImportResult struct {
+	Imported   []*entities.Note
+	Duplicates []*entities.Note
 }
@@ -0,0 +1,134 @@
+package notes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"note-logger/internal/clock"
+)
+
+type JSONLConfig struct {
+	Filename string
+	Clock    clock.Clock
+}
+
+// jsonlStorage keeps every note in a single file, one JSON object a line
+type jsonlStorage struct {
+	filename string
+}
+
+// NewJSONLRepository creates a repository that keeps the notes in a JSON Lines file, locked with <file>.lock
+func NewJSONLRepository(cfg *JSONLConfig) (Repository, error) {
+	if cfg.Filename == "" {
+		return nil, errors.New("missing Filename parameter")
+	}
+
+	loaded, err := readJSONL(cfg.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return newMemoryRepo(loaded, &jsonlStorage{filename: cfg.Filename}, cfg.Clock), nil
+}
+
+func readJSONL(filename string) ([]*storedNote, error) {
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint
+	defer file.Close()
+
+	loaded := make([]*storedNote, 0)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		note := &storedNote{}
+
+		err = json.Unmarshal(scanner.Bytes(), note)
+		if err != nil {
+			return nil, fmt.Errorf("%v line %v: %w", filename, line, err)
+		}
+
+		loaded = append(loaded, note)
+	}
+
+	return loaded, scanner.Err()
+}
+
+func (storage *jsonlStorage) lock() (func(), error) {
+	return lockFile(storage.filename + ".lock")
+}
+
+func (storage *jsonlStorage) load() ([]*storedNote, error) {
+	return readJSONL(storage.filename)
+}
+
+func (storage *jsonlStorage) save(storedNotes []*storedNote) error {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	for _, note := range storedNotes {
+		err := encoder.Encode(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFileAtomic(storage.filename, buf.Bytes())
+}
+
+// writeFileAtomic writes to a temp file next to the file and renames it over the top, never leaving it half written
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), filename)
+	}
+
+	if err != nil {
+		//nolint
+		os.Remove(file.Name())
+
+		return err
+	}
+
+	return nil
+}
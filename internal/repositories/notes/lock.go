@@ -0,0 +1,34 @@
+package notes
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// lockFile waits for an exclusive lock on the file, which goes when the function it gives back is called
+func lockFile(filename string) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(filename), 0o700)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lock(file)
+	if err != nil {
+		//nolint
+		file.Close()
+
+		return nil, err
+	}
+
+	return func() {
+		//nolint
+		unlock(file)
+		//nolint
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,15 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package notes
+
+import "os"
+
+// there's no way to lock a file here, so it's up to the user to only have one process writing notes at a time
+
+func lock(file *os.File) error {
+	return nil
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
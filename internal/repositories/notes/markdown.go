@@ -0,0 +1,207 @@
+package notes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"note-logger/internal/clock"
+	"note-logger/internal/entities"
+
+	"gopkg.in/yaml.v3"
+)
+
+type MarkdownDirConfig struct {
+	Dir   string
+	Clock clock.Clock
+}
+
+// frontMatter is everything about a note besides its content, kept at the top of its Markdown file
+type frontMatter struct {
+	ID        int64             `yaml:"id"`
+	CreatedAt time.Time         `yaml:"created_at"`
+	UpdatedAt *time.Time        `yaml:"updated_at,omitempty"`
+	DeletedAt *time.Time        `yaml:"deleted_at,omitempty"`
+	Tags      []string          `yaml:"tags,omitempty,flow"`
+	Revisions []*storedRevision `yaml:"revisions,omitempty"`
+}
+
+const frontMatterDelimiter string = "---\n"
+
+// markdownDirStorage keeps each note in its own <id>.md file, only writing the files that changed
+type markdownDirStorage struct {
+	dir     string
+	written map[int64][]byte
+}
+
+// NewMarkdownDirRepository creates a repository that keeps each note in a Markdown file with YAML front matter
+func NewMarkdownDirRepository(cfg *MarkdownDirConfig) (Repository, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("missing Dir parameter")
+	}
+
+	storage := &markdownDirStorage{dir: cfg.Dir}
+
+	loaded, err := storage.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return newMemoryRepo(loaded, storage, cfg.Clock), nil
+}
+
+func (storage *markdownDirStorage) filename(noteID int64) string {
+	return filepath.Join(storage.dir, fmt.Sprintf("%d.md", noteID))
+}
+
+func (storage *markdownDirStorage) lock() (func(), error) {
+	return lockFile(filepath.Join(storage.dir, ".lock"))
+}
+
+func (storage *markdownDirStorage) load() ([]*storedNote, error) {
+	storage.written = make(map[int64][]byte)
+
+	entries, err := os.ReadDir(storage.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]*storedNote, 0)
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".md") {
+			continue
+		}
+
+		noteID, err := strconv.ParseInt(strings.TrimSuffix(name, ".md"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		contents, err := os.ReadFile(filepath.Join(storage.dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		note, err := parseMarkdownNote(contents)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", filepath.Join(storage.dir, name), err)
+		}
+
+		if note.ID != noteID {
+			return nil, fmt.Errorf("%v: has the id %v", filepath.Join(storage.dir, name), note.ID)
+		}
+
+		storage.written[noteID] = contents
+		loaded = append(loaded, note)
+	}
+
+	return loaded, nil
+}
+
+func parseMarkdownNote(contents []byte) (*storedNote, error) {
+	text := strings.ReplaceAll(string(contents), "\r\n", "\n")
+
+	if !strings.HasPrefix(text, frontMatterDelimiter) {
+		return nil, errors.New("missing front matter")
+	}
+
+	text = text[len(frontMatterDelimiter):]
+
+	end := strings.Index(text, "\n"+frontMatterDelimiter)
+	if end == -1 {
+		return nil, errors.New("front matter is never closed")
+	}
+
+	matter := &frontMatter{}
+
+	err := yaml.Unmarshal([]byte(text[:end+1]), matter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storedNote{
+		Note: entities.Note{
+			ID:        matter.ID,
+			Content:   strings.TrimSuffix(text[end+1+len(frontMatterDelimiter):], "\n"),
+			CreatedAt: matter.CreatedAt,
+			UpdatedAt: matter.UpdatedAt,
+			DeletedAt: matter.DeletedAt,
+			Tags:      matter.Tags,
+		},
+		Revisions: matter.Revisions,
+	}, nil
+}
+
+func formatMarkdownNote(note *storedNote) ([]byte, error) {
+	matter, err := yaml.Marshal(&frontMatter{
+		ID:        note.ID,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+		DeletedAt: note.DeletedAt,
+		Tags:      note.Tags,
+		Revisions: note.Revisions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(frontMatterDelimiter)
+	buf.Write(matter)
+	buf.WriteString(frontMatterDelimiter)
+	buf.WriteString(note.Content)
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+func (storage *markdownDirStorage) save(storedNotes []*storedNote) error {
+	current := make(map[int64]bool, len(storedNotes))
+
+	for _, note := range storedNotes {
+		current[note.ID] = true
+
+		contents, err := formatMarkdownNote(note)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(storage.written[note.ID], contents) {
+			continue
+		}
+
+		err = writeFileAtomic(storage.filename(note.ID), contents)
+		if err != nil {
+			return err
+		}
+
+		storage.written[note.ID] = contents
+	}
+
+	for noteID := range storage.written {
+		if current[noteID] {
+			continue
+		}
+
+		err := os.Remove(storage.filename(noteID))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		delete(storage.written, noteID)
+	}
+
+	return nil
+}
@@ -0,0 +1,810 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"note-logger/internal/clock"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/tags"
+)
+
+// storedNote is a note along with its earlier revisions, the way the backends without a DB keep them
+type storedNote struct {
+	entities.Note
+	Revisions []*storedRevision `json:"revisions,omitempty"`
+}
+
+type storedRevision struct {
+	Content   string    `json:"content" yaml:"content"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+}
+
+// memoryStorage keeps the notes of a memoryRepo somewhere more permanent, which other processes can be changing too
+type memoryStorage interface {
+	lock() (func(), error)
+	load() ([]*storedNote, error)
+	save(storedNotes []*storedNote) error
+}
+
+// memoryRepo keeps the notes in memory, loading them from its storage, if it has one, for every read and change
+type memoryRepo struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	notes   map[int64]*storedNote
+	storage memoryStorage
+}
+
+type MemoryConfig struct {
+	Clock clock.Clock
+}
+
+// NewMemoryRepository creates a repository that only keeps notes for as long as it's around
+func NewMemoryRepository(cfg *MemoryConfig) Repository {
+	return newMemoryRepo(nil, nil, cfg.Clock)
+}
+
+func newMemoryRepo(loaded []*storedNote, storage memoryStorage, c clock.Clock) *memoryRepo {
+	repo := &memoryRepo{
+		clock:   realClockIfUnset(c),
+		storage: storage,
+	}
+
+	repo.setNotes(loaded)
+
+	return repo
+}
+
+func (repo *memoryRepo) setNotes(loaded []*storedNote) {
+	repo.notes = make(map[int64]*storedNote, len(loaded))
+
+	for _, note := range loaded {
+		repo.notes[note.ID] = note
+	}
+}
+
+// reload takes the storage's lock and loads the notes from it again, giving back what lets go of the lock
+func (repo *memoryRepo) reload() (func(), error) {
+	if repo.storage == nil {
+		return func() {}, nil
+	}
+
+	unlock, err := repo.storage.lock()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := repo.storage.load()
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+
+	repo.setNotes(loaded)
+
+	return unlock, nil
+}
+
+// read calls fn with the notes as they are in the storage now, which other processes could have changed
+func (repo *memoryRepo) read(fn func() error) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	unlock, err := repo.reload()
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	return fn()
+}
+
+// change makes a change to the notes as they are in the storage now, saving them, and undoing it if that fails
+func (repo *memoryRepo) change(fn func() error) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	unlock, err := repo.reload()
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	previous := make(map[int64]*storedNote, len(repo.notes))
+	for id, note := range repo.notes {
+		previous[id] = copyStoredNote(note)
+	}
+
+	err = fn()
+
+	if err == nil && repo.storage != nil {
+		err = repo.storage.save(repo.sorted())
+	}
+
+	if err != nil {
+		repo.notes = previous
+	}
+
+	return err
+}
+
+// sorted gives all of the notes in ID order
+func (repo *memoryRepo) sorted() []*storedNote {
+	sortedNotes := make([]*storedNote, 0, len(repo.notes))
+
+	for _, note := range repo.notes {
+		sortedNotes = append(sortedNotes, note)
+	}
+
+	sort.Slice(sortedNotes, func(i, j int) bool {
+		return sortedNotes[i].ID < sortedNotes[j].ID
+	})
+
+	return sortedNotes
+}
+
+// nextID is one more than the highest ID so far, the same as SQLite picks
+func (repo *memoryRepo) nextID() int64 {
+	var highest int64
+
+	for id := range repo.notes {
+		if id > highest {
+			highest = id
+		}
+	}
+
+	return highest + 1
+}
+
+// current gives the note with the ID, as long as it isn't in the trash
+func (repo *memoryRepo) current(noteID int64) (*storedNote, error) {
+	note, ok := repo.notes[noteID]
+	if !ok || note.DeletedAt != nil {
+		return nil, &NotFoundError{ID: noteID}
+	}
+
+	return note, nil
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	copied := *t
+
+	return &copied
+}
+
+func copyNote(note *entities.Note) *entities.Note {
+	copied := *note
+	copied.UpdatedAt = copyTime(note.UpdatedAt)
+	copied.DeletedAt = copyTime(note.DeletedAt)
+
+	if note.Tags != nil {
+		copied.Tags = append([]string{}, note.Tags...)
+	}
+
+	return &copied
+}
+
+func copyStoredNote(note *storedNote) *storedNote {
+	copied := &storedNote{Note: *copyNote(&note.Note)}
+
+	for _, revision := range note.Revisions {
+		copiedRevision := *revision
+		copied.Revisions = append(copied.Revisions, &copiedRevision)
+	}
+
+	return copied
+}
+
+// listed is a copy of the note the way it gets listed, without when it was deleted
+func listed(note *storedNote) *entities.Note {
+	copied := copyNote(&note.Note)
+	copied.DeletedAt = nil
+
+	return copied
+}
+
+func (repo *memoryRepo) Create(ctx context.Context, note *entities.Note) (*entities.Note, error) {
+	noteTags, err := tags.Normalize(note.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	note.CreatedAt = repo.clock.Now()
+
+	var noteID int64
+
+	err = repo.change(func() error {
+		noteID = repo.nextID()
+
+		repo.notes[noteID] = &storedNote{
+			Note: entities.Note{
+				ID:        noteID,
+				Content:   note.Content,
+				CreatedAt: note.CreatedAt,
+				Tags:      noteTags,
+			},
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	note.ID = noteID
+
+	if len(noteTags) > 0 {
+		note.Tags = noteTags
+	}
+
+	return note, nil
+}
+
+func (repo *memoryRepo) Get(ctx context.Context, noteID int64) (*entities.Note, error) {
+	var note *entities.Note
+
+	err := repo.read(func() error {
+		current, err := repo.current(noteID)
+		if err != nil {
+			return err
+		}
+
+		note = listed(current)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
+
+// List pages through the notes the same way the DB backends do, with notes created at the same time kept in ID order
+func (repo *memoryRepo) List(ctx context.Context, opts *ListOptions) ([]*entities.Note, error) {
+	endTime := opts.EndTime
+	if endTime.IsZero() {
+		endTime = MaxTime
+	}
+
+	matching := make([]*entities.Note, 0)
+
+	err := repo.ForEach(ctx, opts.StartTime, endTime, opts.Filter, func(note *entities.Note) error {
+		matching = append(matching, note)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Reverse {
+		for i, j := 0, len(matching)-1; i < j; i, j = i+1, j-1 {
+			matching[i], matching[j] = matching[j], matching[i]
+		}
+	}
+
+	if opts.AfterID != 0 {
+		var cursor *storedNote
+
+		err = repo.read(func() error {
+			var ok bool
+
+			cursor, ok = repo.notes[opts.AfterID]
+			if !ok {
+				return &NotFoundError{ID: opts.AfterID}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		after := make([]*entities.Note, 0, len(matching))
+
+		for _, note := range matching {
+			if isAfter(note, cursor.CreatedAt, cursor.ID) != opts.Reverse && note.ID != cursor.ID {
+				after = append(after, note)
+			}
+		}
+
+		matching = after
+	}
+
+	if opts.Offset >= len(matching) {
+		return make([]*entities.Note, 0), nil
+	}
+
+	matching = matching[opts.Offset:]
+
+	if opts.Limit > 0 && opts.Limit < len(matching) {
+		matching = matching[:opts.Limit]
+	}
+
+	return matching, nil
+}
+
+// isAfter reports whether a note comes after the created time and ID of another, oldest first
+func isAfter(note *entities.Note, createdAt time.Time, noteID int64) bool {
+	if note.CreatedAt.Equal(createdAt) {
+		return note.ID > noteID
+	}
+
+	return note.CreatedAt.After(createdAt)
+}
+
+// hasTags checks a note against a tag filter, where the filter's tags have already been normalized
+func hasTags(note *storedNote, filterTags []string, matchAll bool) bool {
+	if len(filterTags) == 0 {
+		return true
+	}
+
+	noteTags := make(map[string]bool)
+	for _, tag := range note.Tags {
+		noteTags[tag] = true
+	}
+
+	found := 0
+
+	for _, tag := range filterTags {
+		if noteTags[tag] {
+			found++
+		}
+	}
+
+	if matchAll {
+		return found == len(filterTags)
+	}
+
+	return found > 0
+}
+
+// ForEach calls fn with copies of the notes, so fn is free to use the repository too
+func (repo *memoryRepo) ForEach(ctx context.Context, startTime time.Time, endTime time.Time, filter *TagFilter,
+	fn func(note *entities.Note) error) error {
+	var filterTags []string
+	var matchAll bool
+
+	if filter != nil && len(filter.Tags) > 0 {
+		var err error
+
+		filterTags, err = tags.Normalize(filter.Tags)
+		if err != nil {
+			return err
+		}
+
+		matchAll = filter.MatchAll
+	}
+
+	matching := make([]*entities.Note, 0)
+
+	err := repo.read(func() error {
+		for _, note := range repo.sorted() {
+			if note.DeletedAt != nil || note.CreatedAt.Before(startTime) || note.CreatedAt.After(endTime) {
+				continue
+			}
+
+			if hasTags(note, filterTags, matchAll) {
+				matching = append(matching, listed(note))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].CreatedAt.Before(matching[j].CreatedAt)
+	})
+
+	for _, note := range matching {
+		err = fn(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *memoryRepo) ListTags(ctx context.Context) ([]*entities.TagCount, error) {
+	counts := make(map[string]int64)
+
+	err := repo.read(func() error {
+		for _, note := range repo.notes {
+			if note.DeletedAt != nil {
+				continue
+			}
+
+			for _, tag := range note.Tags {
+				counts[tag]++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tagCounts := make([]*entities.TagCount, 0, len(counts))
+
+	for name, count := range counts {
+		tagCounts = append(tagCounts, &entities.TagCount{
+			Name:  name,
+			Count: count,
+		})
+	}
+
+	sort.Slice(tagCounts, func(i, j int) bool {
+		if tagCounts[i].Count != tagCounts[j].Count {
+			return tagCounts[i].Count > tagCounts[j].Count
+		}
+
+		return tagCounts[i].Name < tagCounts[j].Name
+	})
+
+	return tagCounts, nil
+}
+
+func (repo *memoryRepo) ListCreatedTimes(ctx context.Context, startTime time.Time,
+	endTime time.Time) ([]time.Time, error) {
+	createdTimes := make([]time.Time, 0)
+
+	err := repo.read(func() error {
+		for _, note := range repo.notes {
+			if note.DeletedAt != nil || note.CreatedAt.Before(startTime) || note.CreatedAt.After(endTime) {
+				continue
+			}
+
+			createdTimes = append(createdTimes, note.CreatedAt)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(createdTimes, func(i, j int) bool {
+		return createdTimes[i].Before(createdTimes[j])
+	})
+
+	return createdTimes, nil
+}
+
+// Update works the same as it does for SQLite
+func (repo *memoryRepo) Update(ctx context.Context, note *entities.Note, opts *UpdateOptions) (*entities.Note, error) {
+	var newTags []string
+	var createdAt time.Time
+	var updatedAt *time.Time
+
+	err := repo.change(func() error {
+		existing, err := repo.current(note.ID)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(note.Content) == "" {
+			note.Content = existing.Content
+		}
+
+		newTags, err = updatedTags(existing.Content, existing.Tags, note, opts)
+		if err != nil {
+			return err
+		}
+
+		if note.Content != existing.Content {
+			now := repo.clock.Now()
+
+			writtenAt := existing.CreatedAt
+			if existing.UpdatedAt != nil {
+				writtenAt = *existing.UpdatedAt
+			}
+
+			existing.Revisions = append(existing.Revisions, &storedRevision{
+				Content:   existing.Content,
+				CreatedAt: writtenAt,
+			})
+
+			existing.Content = note.Content
+			existing.UpdatedAt = &now
+		}
+
+		existing.Tags = newTags
+
+		createdAt = existing.CreatedAt
+
+		if existing.UpdatedAt != nil {
+			writtenAt := *existing.UpdatedAt
+			updatedAt = &writtenAt
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	note.CreatedAt = createdAt
+	note.UpdatedAt = updatedAt
+	note.Tags = nil
+
+	if len(newTags) > 0 {
+		note.Tags = newTags
+	}
+
+	return note, nil
+}
+
+func (repo *memoryRepo) ListRevisions(ctx context.Context, noteID int64) ([]*entities.Revision, error) {
+	var note *storedNote
+
+	err := repo.read(func() error {
+		current, err := repo.current(noteID)
+		if err != nil {
+			return err
+		}
+
+		note = copyStoredNote(current)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*entities.Revision, 0, len(note.Revisions)+1)
+
+	for _, revision := range note.Revisions {
+		revisions = append(revisions, &entities.Revision{
+			Number:    len(revisions) + 1,
+			NoteID:    noteID,
+			Content:   revision.Content,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+
+	writtenAt := note.CreatedAt
+	if note.UpdatedAt != nil {
+		writtenAt = *note.UpdatedAt
+	}
+
+	return append(revisions, &entities.Revision{
+		Number:    len(revisions) + 1,
+		NoteID:    noteID,
+		Content:   note.Content,
+		CreatedAt: writtenAt,
+		Current:   true,
+	}), nil
+}
+
+func (repo *memoryRepo) Delete(ctx context.Context, noteID int64) error {
+	return repo.change(func() error {
+		note, err := repo.current(noteID)
+		if err != nil {
+			return err
+		}
+
+		deletedAt := repo.clock.Now()
+		note.DeletedAt = &deletedAt
+
+		return nil
+	})
+}
+
+func (repo *memoryRepo) ListTrash(ctx context.Context) ([]*entities.Note, error) {
+	retNotes := make([]*entities.Note, 0)
+
+	err := repo.read(func() error {
+		for _, note := range repo.sorted() {
+			if note.DeletedAt != nil {
+				retNotes = append(retNotes, copyNote(&note.Note))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(retNotes, func(i, j int) bool {
+		return retNotes[i].DeletedAt.After(*retNotes[j].DeletedAt)
+	})
+
+	return retNotes, nil
+}
+
+func (repo *memoryRepo) Restore(ctx context.Context, noteID int64) error {
+	return repo.change(func() error {
+		note, ok := repo.notes[noteID]
+		if !ok {
+			return &NotFoundError{ID: noteID}
+		}
+
+		if note.DeletedAt == nil {
+			return ErrNoteNotInTrash
+		}
+
+		note.DeletedAt = nil
+
+		return nil
+	})
+}
+
+func (repo *memoryRepo) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	var purged int64
+
+	err := repo.change(func() error {
+		for id, note := range repo.notes {
+			if note.DeletedAt != nil && !note.DeletedAt.After(olderThan) {
+				delete(repo.notes, id)
+				purged++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// Search matches the words in the notes without an index, ranking them by how many times the terms appear
+func (repo *memoryRepo) Search(ctx context.Context, query string, opts *SearchOptions) ([]*entities.SearchResult, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+
+	endTime := opts.EndTime
+	if endTime.IsZero() {
+		endTime = MaxTime
+	}
+
+	matcher, err := newSearchMatcher(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*entities.SearchResult, 0)
+
+	err = repo.read(func() error {
+		for _, note := range repo.sorted() {
+			if note.DeletedAt != nil || note.CreatedAt.Before(opts.StartTime) || note.CreatedAt.After(endTime) {
+				continue
+			}
+
+			words, matches, ok := matcher.match(note.Content)
+			if !ok {
+				continue
+			}
+
+			results = append(results, &entities.SearchResult{
+				Note:    listed(note),
+				Snippet: snippet(note.Content, words, matches, opts.HighlightStart, opts.HighlightEnd),
+				Rank:    -float64(len(matches)),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank < results[j].Rank
+	})
+
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	return results, nil
+}
+
+// Import works the same as it does for SQLite
+func (repo *memoryRepo) Import(ctx context.Context, batch []*entities.Note, dryRun bool) (*ImportResult, error) {
+	for i, note := range batch {
+		if strings.TrimSpace(note.Content) == "" {
+			return nil, noteerrors.InvalidInputf("note %v has no content", i+1)
+		}
+
+		if note.CreatedAt.IsZero() {
+			return nil, noteerrors.InvalidInputf("note %v has no creation time", i+1)
+		}
+
+		noteTags, err := tags.Normalize(note.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("note %v: %w", i+1, err)
+		}
+
+		note.Tags = nil
+		if len(noteTags) > 0 {
+			note.Tags = noteTags
+		}
+	}
+
+	result := &ImportResult{
+		Imported:   make([]*entities.Note, 0),
+		Duplicates: make([]*entities.Note, 0),
+	}
+
+	apply := func() error {
+		existing := make(map[string]bool)
+		for _, note := range repo.notes {
+			existing[importKey(note.CreatedAt, note.Content, note.Tags)] = true
+		}
+
+		for _, note := range batch {
+			key := importKey(note.CreatedAt, note.Content, note.Tags)
+
+			if existing[key] {
+				result.Duplicates = append(result.Duplicates, note)
+				continue
+			}
+
+			existing[key] = true
+			result.Imported = append(result.Imported, note)
+
+			if dryRun {
+				continue
+			}
+
+			note.ID = repo.nextID()
+
+			repo.notes[note.ID] = &storedNote{
+				Note: entities.Note{
+					ID:        note.ID,
+					Content:   note.Content,
+					CreatedAt: note.CreatedAt,
+					UpdatedAt: copyTime(note.UpdatedAt),
+					Tags:      note.Tags,
+				},
+			}
+		}
+
+		return nil
+	}
+
+	if dryRun {
+		err := repo.read(apply)
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	err := repo.change(apply)
+	if err != nil {
+		for _, note := range result.Imported {
+			note.ID = 0
+		}
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Close has nothing to do, as the notes get saved after every change
+func (repo *memoryRepo) Close() error {
+	return nil
+}
@@ -6,36 +6,52 @@ package mock_notes
 
 import (
 	context "context"
-	gomock "github.com/golang/mock/gomock"
 	entities "note-logger/internal/entities"
+	notes "note-logger/internal/repositories/notes"
 	reflect "reflect"
 	time "time"
+
+	gomock "github.com/golang/mock/gomock"
 )
 
-// MockRepository is a mock of Repository interface
+// MockRepository is a mock of Repository interface.
 type MockRepository struct {
 	ctrl     *gomock.Controller
 	recorder *MockRepositoryMockRecorder
 }
 
-// MockRepositoryMockRecorder is the mock recorder for MockRepository
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
 type MockRepositoryMockRecorder struct {
 	mock *MockRepository
 }
 
-// NewMockRepository creates a new mock instance
+// NewMockRepository creates a new mock instance.
 func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
 	mock := &MockRepository{ctrl: ctrl}
 	mock.recorder = &MockRepositoryMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Create mocks base method
+// Close mocks base method.
+func (m *MockRepository) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockRepositoryMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRepository)(nil).Close))
+}
+
+// Create mocks base method.
 func (m *MockRepository) Create(ctx context.Context, note *entities.Note) (*entities.Note, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", ctx, note)
@@ -44,37 +60,200 @@ func (m *MockRepository) Create(ctx context.Context, note *entities.Note) (*enti
 	return ret0, ret1
 }
 
-// Create indicates an expected call of Create
+// Create indicates an expected call of Create.
 func (mr *MockRepositoryMockRecorder) Create(ctx, note interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), ctx, note)
 }
 
-// ListBetween mocks base method
-func (m *MockRepository) ListBetween(ctx context.Context, startTime, endTime time.Time) ([]*entities.Note, error) {
+// Delete mocks base method.
+func (m *MockRepository) Delete(ctx context.Context, noteID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, noteID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepositoryMockRecorder) Delete(ctx, noteID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, noteID)
+}
+
+// ForEach mocks base method.
+func (m *MockRepository) ForEach(ctx context.Context, startTime, endTime time.Time, filter *notes.TagFilter, fn func(*entities.Note) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListBetween", ctx, startTime, endTime)
+	ret := m.ctrl.Call(m, "ForEach", ctx, startTime, endTime, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEach indicates an expected call of ForEach.
+func (mr *MockRepositoryMockRecorder) ForEach(ctx, startTime, endTime, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEach", reflect.TypeOf((*MockRepository)(nil).ForEach), ctx, startTime, endTime, filter, fn)
+}
+
+// Get mocks base method.
+func (m *MockRepository) Get(ctx context.Context, noteID int64) (*entities.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, noteID)
+	ret0, _ := ret[0].(*entities.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockRepositoryMockRecorder) Get(ctx, noteID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepository)(nil).Get), ctx, noteID)
+}
+
+// Import mocks base method.
+func (m *MockRepository) Import(ctx context.Context, batch []*entities.Note, dryRun bool) (*notes.ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, batch, dryRun)
+	ret0, _ := ret[0].(*notes.ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockRepositoryMockRecorder) Import(ctx, batch, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockRepository)(nil).Import), ctx, batch, dryRun)
+}
+
+// List mocks base method.
+func (m *MockRepository) List(ctx context.Context, opts *notes.ListOptions) ([]*entities.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, opts)
 	ret0, _ := ret[0].([]*entities.Note)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListBetween indicates an expected call of ListBetween
-func (mr *MockRepositoryMockRecorder) ListBetween(ctx, startTime, endTime interface{}) *gomock.Call {
+// List indicates an expected call of List.
+func (mr *MockRepositoryMockRecorder) List(ctx, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBetween", reflect.TypeOf((*MockRepository)(nil).ListBetween), ctx, startTime, endTime)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockRepository)(nil).List), ctx, opts)
 }
 
-// Delete mocks base method
-func (m *MockRepository) Delete(ctx context.Context, noteID int64) error {
+// ListCreatedTimes mocks base method.
+func (m *MockRepository) ListCreatedTimes(ctx context.Context, startTime, endTime time.Time) ([]time.Time, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, noteID)
+	ret := m.ctrl.Call(m, "ListCreatedTimes", ctx, startTime, endTime)
+	ret0, _ := ret[0].([]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCreatedTimes indicates an expected call of ListCreatedTimes.
+func (mr *MockRepositoryMockRecorder) ListCreatedTimes(ctx, startTime, endTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCreatedTimes", reflect.TypeOf((*MockRepository)(nil).ListCreatedTimes), ctx, startTime, endTime)
+}
+
+// ListRevisions mocks base method.
+func (m *MockRepository) ListRevisions(ctx context.Context, noteID int64) ([]*entities.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", ctx, noteID)
+	ret0, _ := ret[0].([]*entities.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockRepositoryMockRecorder) ListRevisions(ctx, noteID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockRepository)(nil).ListRevisions), ctx, noteID)
+}
+
+// ListTags mocks base method.
+func (m *MockRepository) ListTags(ctx context.Context) ([]*entities.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTags", ctx)
+	ret0, _ := ret[0].([]*entities.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTags indicates an expected call of ListTags.
+func (mr *MockRepositoryMockRecorder) ListTags(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTags", reflect.TypeOf((*MockRepository)(nil).ListTags), ctx)
+}
+
+// ListTrash mocks base method.
+func (m *MockRepository) ListTrash(ctx context.Context) ([]*entities.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrash", ctx)
+	ret0, _ := ret[0].([]*entities.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrash indicates an expected call of ListTrash.
+func (mr *MockRepositoryMockRecorder) ListTrash(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrash", reflect.TypeOf((*MockRepository)(nil).ListTrash), ctx)
+}
+
+// Purge mocks base method.
+func (m *MockRepository) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, olderThan)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockRepositoryMockRecorder) Purge(ctx, olderThan interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockRepository)(nil).Purge), ctx, olderThan)
+}
+
+// Restore mocks base method.
+func (m *MockRepository) Restore(ctx context.Context, noteID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, noteID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Delete indicates an expected call of Delete
-func (mr *MockRepositoryMockRecorder) Delete(ctx, noteID interface{}) *gomock.Call {
+// Restore indicates an expected call of Restore.
+func (mr *MockRepositoryMockRecorder) Restore(ctx, noteID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), ctx, noteID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRepository)(nil).Restore), ctx, noteID)
+}
+
+// Search mocks base method.
+func (m *MockRepository) Search(ctx context.Context, query string, opts *notes.SearchOptions) ([]*entities.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, query, opts)
+	ret0, _ := ret[0].([]*entities.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockRepositoryMockRecorder) Search(ctx, query, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRepository)(nil).Search), ctx, query, opts)
+}
+
+// Update mocks base method.
+func (m *MockRepository) Update(ctx context.Context, note *entities.Note, opts *notes.UpdateOptions) (*entities.Note, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, note, opts)
+	ret0, _ := ret[0].(*entities.Note)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRepositoryMockRecorder) Update(ctx, note, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRepository)(nil).Update), ctx, note, opts)
 }
@@ -0,0 +1,750 @@
+// Package notestest is the conformance suite that every notes.Repository backend has to pass.
+package notestest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/repositories/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Backend describes the backend under test
+type Backend struct {
+	// Open gives back the same notes for the same dir, if the backend is Persistent
+	Open       func(t *testing.T, dir string) notes.Repository
+	Persistent bool
+	Search     bool
+}
+
+// Run runs the whole suite against the backend, with a fresh repository for each test
+func Run(t *testing.T, backend *Backend) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, backend *Backend)
+	}{
+		{"Create", testCreate},
+		{"Get", testGet},
+		{"ListWindow", testListWindow},
+		{"ListFiltered", testListFiltered},
+		{"ForEach", testForEach},
+		{"List", testList},
+		{"ListTags", testListTags},
+		{"ListCreatedTimes", testListCreatedTimes},
+		{"Update", testUpdate},
+		{"Trash", testTrash},
+		{"Purge", testPurge},
+		{"Search", testSearch},
+		{"Import", testImport},
+		{"Offsets", testOffsets},
+		{"Persistence", testPersistence},
+		{"SharedWriters", testSharedWriters},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, backend)
+		})
+	}
+}
+
+var day = time.Date(2022, time.April, 12, 0, 0, 0, 0, time.Local)
+
+// seed imports notes at known times, since Create always uses the current time
+func seed(t *testing.T, repo notes.Repository, seeded ...*entities.Note) []*entities.Note {
+	result, err := repo.Import(context.Background(), seeded, false)
+	require.NoError(t, err)
+	require.Len(t, result.Imported, len(seeded))
+
+	return result.Imported
+}
+
+func ids(notesList []*entities.Note) []int64 {
+	noteIDs := make([]int64, 0, len(notesList))
+	for _, note := range notesList {
+		noteIDs = append(noteIDs, note.ID)
+	}
+
+	return noteIDs
+}
+
+func testCreate(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	before := time.Now()
+
+	first, err := repo.Create(ctx, &entities.Note{Content: "First", Tags: []string{"Work", "#idea", "work"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), first.ID)
+	assert.Equal(t, []string{"idea", "work"}, first.Tags)
+	assert.False(t, first.CreatedAt.Before(before))
+
+	second, err := repo.Create(ctx, &entities.Note{Content: "Second"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), second.ID)
+	assert.Empty(t, second.Tags)
+
+	_, err = repo.Create(ctx, &entities.Note{Content: "Bad", Tags: []string{"not a tag"}})
+	assert.Error(t, err)
+}
+
+func testGet(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	created, err := repo.Create(ctx, &entities.Note{Content: "Line one\n  line two", Tags: []string{"work"}})
+	require.NoError(t, err)
+
+	note, err := repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, note.ID)
+	assert.Equal(t, "Line one\n  line two", note.Content)
+	assert.Equal(t, []string{"work"}, note.Tags)
+	assert.True(t, created.CreatedAt.Equal(note.CreatedAt))
+	assert.Nil(t, note.UpdatedAt)
+	assert.Nil(t, note.DeletedAt)
+
+	_, err = repo.Get(ctx, 42)
+	assert.True(t, errors.Is(err, notes.ErrNoteNotFound))
+	assert.True(t, errors.Is(err, noteerrors.ErrNotFound))
+
+	var notFound *notes.NotFoundError
+	require.True(t, errors.As(err, &notFound))
+	assert.Equal(t, int64(42), notFound.ID)
+	assert.EqualError(t, err, "note 42 does not exist")
+}
+
+func testListWindow(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo,
+		&entities.Note{Content: "Later", CreatedAt: day.Add(15 * time.Hour)},
+		&entities.Note{Content: "Earlier", CreatedAt: day.Add(9 * time.Hour)},
+		&entities.Note{Content: "Next day", CreatedAt: day.Add(30 * time.Hour)},
+	)
+
+	listed, err := repo.List(ctx, &notes.ListOptions{StartTime: day, EndTime: day.Add(24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1}, ids(listed))
+	assert.Equal(t, "Earlier", listed[0].Content)
+
+	listed, err = repo.List(ctx, &notes.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1, 3}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{StartTime: day.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	assert.NotNil(t, listed)
+	assert.Empty(t, listed)
+}
+
+func testListFiltered(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo,
+		&entities.Note{Content: "Both", CreatedAt: day.Add(time.Hour), Tags: []string{"work", "idea"}},
+		&entities.Note{Content: "Work", CreatedAt: day.Add(2 * time.Hour), Tags: []string{"work"}},
+		&entities.Note{Content: "Neither", CreatedAt: day.Add(3 * time.Hour)},
+	)
+
+	listed, err := repo.List(ctx, &notes.ListOptions{Filter: &notes.TagFilter{Tags: []string{"#Work", "idea"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids(listed))
+	assert.Equal(t, []string{"idea", "work"}, listed[0].Tags)
+
+	listed, err = repo.List(ctx, &notes.ListOptions{
+		Filter: &notes.TagFilter{Tags: []string{"work", "idea"}, MatchAll: true},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{Filter: &notes.TagFilter{}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, ids(listed))
+}
+
+func testForEach(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo,
+		&entities.Note{Content: "One", CreatedAt: day.Add(time.Hour)},
+		&entities.Note{Content: "Two", CreatedAt: day.Add(2 * time.Hour)},
+		&entities.Note{Content: "Three", CreatedAt: day.Add(3 * time.Hour)},
+	)
+
+	seen := make([]string, 0)
+
+	err := repo.ForEach(ctx, time.Time{}, notes.MaxTime, nil, func(note *entities.Note) error {
+		seen = append(seen, note.Content)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"One", "Two", "Three"}, seen)
+
+	errStop := errors.New("stop")
+	seen = seen[:0]
+
+	err = repo.ForEach(ctx, time.Time{}, notes.MaxTime, nil, func(note *entities.Note) error {
+		seen = append(seen, note.Content)
+		if len(seen) == 2 {
+			return errStop
+		}
+
+		return nil
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, []string{"One", "Two"}, seen)
+}
+
+func testList(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	// the second and third notes were written at the same time, so it's their IDs that keep the pages in order
+	seed(t, repo,
+		&entities.Note{Content: "One", CreatedAt: day.Add(time.Hour), Tags: []string{"work"}},
+		&entities.Note{Content: "Two", CreatedAt: day.Add(2 * time.Hour)},
+		&entities.Note{Content: "Three", CreatedAt: day.Add(2 * time.Hour), Tags: []string{"work"}},
+		&entities.Note{Content: "Four", CreatedAt: day.Add(3 * time.Hour)},
+		&entities.Note{Content: "Five", CreatedAt: day.Add(30 * time.Hour), Tags: []string{"work"}},
+	)
+
+	listed, err := repo.List(ctx, &notes.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{Limit: 2, AfterID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{Limit: 2, Offset: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{Reverse: true, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{5, 4}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{Reverse: true, AfterID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{EndTime: day.Add(24 * time.Hour), Reverse: true})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4, 3, 2, 1}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{
+		StartTime: day.Add(90 * time.Minute),
+		Filter:    &notes.TagFilter{Tags: []string{"work"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 5}, ids(listed))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{AfterID: 5})
+	require.NoError(t, err)
+	assert.NotNil(t, listed)
+	assert.Empty(t, listed)
+
+	// a cursor note that's been deleted since still marks the place, and one that never existed is an error
+	require.NoError(t, repo.Delete(ctx, 2))
+
+	listed, err = repo.List(ctx, &notes.ListOptions{AfterID: 2, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3}, ids(listed))
+
+	_, err = repo.List(ctx, &notes.ListOptions{AfterID: 42})
+	assert.True(t, errors.Is(err, noteerrors.ErrNotFound))
+}
+
+func testListTags(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo,
+		&entities.Note{Content: "One", CreatedAt: day.Add(time.Hour), Tags: []string{"work", "idea"}},
+		&entities.Note{Content: "Two", CreatedAt: day.Add(2 * time.Hour), Tags: []string{"work", "home"}},
+		&entities.Note{Content: "Three", CreatedAt: day.Add(3 * time.Hour), Tags: []string{"trashed"}},
+	)
+
+	require.NoError(t, repo.Delete(ctx, 3))
+
+	tagCounts, err := repo.ListTags(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*entities.TagCount{
+		{Name: "work", Count: 2},
+		{Name: "home", Count: 1},
+		{Name: "idea", Count: 1},
+	}, tagCounts)
+}
+
+func testListCreatedTimes(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	east := time.FixedZone("", 5*60*60)
+
+	seed(t, repo,
+		&entities.Note{Content: "Later", CreatedAt: day.Add(3 * time.Hour).In(east)},
+		&entities.Note{Content: "Earlier", CreatedAt: day.Add(time.Hour)},
+		&entities.Note{Content: "Trashed", CreatedAt: day.Add(2 * time.Hour)},
+		&entities.Note{Content: "Outside", CreatedAt: day.Add(48 * time.Hour)},
+	)
+
+	require.NoError(t, repo.Delete(ctx, 3))
+
+	createdTimes, err := repo.ListCreatedTimes(ctx, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, createdTimes, 2)
+	assert.True(t, day.Add(time.Hour).Equal(createdTimes[0]), createdTimes[0])
+	assert.True(t, day.Add(3*time.Hour).Equal(createdTimes[1]), createdTimes[1])
+
+	_, offset := createdTimes[1].Zone()
+	assert.Equal(t, 5*60*60, offset)
+}
+
+func testUpdate(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	created, err := repo.Create(ctx, &entities.Note{Content: "Draft #old", Tags: []string{"old", "kept"}})
+	require.NoError(t, err)
+
+	updated, err := repo.Update(ctx, &entities.Note{ID: created.ID, Content: "Final #new"}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, updated.UpdatedAt)
+	assert.Equal(t, []string{"kept", "new"}, updated.Tags)
+	assert.True(t, updated.CreatedAt.Equal(created.CreatedAt), updated.CreatedAt)
+
+	note, err := repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Final #new", note.Content)
+	assert.Equal(t, []string{"kept", "new"}, note.Tags)
+	require.NotNil(t, note.UpdatedAt)
+	assert.True(t, updated.UpdatedAt.Equal(*note.UpdatedAt))
+
+	_, err = repo.Update(ctx, &entities.Note{ID: created.ID, Content: "Really final #new"}, nil)
+	require.NoError(t, err)
+
+	revisions, err := repo.ListRevisions(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+
+	contents := make([]string, 0, len(revisions))
+	for i, revision := range revisions {
+		assert.Equal(t, i+1, revision.Number)
+		assert.Equal(t, created.ID, revision.NoteID)
+		assert.Equal(t, i == 2, revision.Current)
+		contents = append(contents, revision.Content)
+	}
+
+	assert.Equal(t, []string{"Draft #old", "Final #new", "Really final #new"}, contents)
+	assert.True(t, revisions[0].CreatedAt.Equal(created.CreatedAt))
+	assert.True(t, revisions[1].CreatedAt.Equal(*updated.UpdatedAt))
+
+	// changing only the tags, whether the content is left out or the same, doesn't make a revision
+	for _, content := range []string{"", "Really final #new"} {
+		tagged, err := repo.Update(ctx, &entities.Note{ID: created.ID, Content: content, Tags: []string{"extra"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Really final #new", tagged.Content)
+		assert.Equal(t, []string{"extra", "kept", "new"}, tagged.Tags)
+		require.NotNil(t, tagged.UpdatedAt)
+		assert.True(t, tagged.UpdatedAt.Equal(revisions[2].CreatedAt))
+	}
+
+	// tags can be taken off as well, even ones from #hashtags
+	untagged, err := repo.Update(ctx, &entities.Note{ID: created.ID}, &notes.UpdateOptions{
+		RemoveTags: []string{"#Kept", "new"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Really final #new", untagged.Content)
+	assert.Equal(t, []string{"extra"}, untagged.Tags)
+
+	note, err = repo.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"extra"}, note.Tags)
+
+	revisions, err = repo.ListRevisions(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 3)
+
+	// the note comes back written at the offset it was created at, not in UTC or the local zone
+	imported := seed(t, repo, &entities.Note{
+		Content:   "Imported",
+		CreatedAt: time.Date(2022, time.April, 12, 9, 30, 0, 0, time.FixedZone("", 5*60*60)),
+	})
+
+	updated, err = repo.Update(ctx, &entities.Note{ID: imported[0].ID, Content: "Imported and edited"}, nil)
+	require.NoError(t, err)
+	assert.True(t, updated.CreatedAt.Equal(imported[0].CreatedAt))
+
+	_, offset := updated.CreatedAt.Zone()
+	assert.Equal(t, 5*60*60, offset)
+
+	_, err = repo.Update(ctx, &entities.Note{ID: 42, Content: "Missing"}, nil)
+	assert.True(t, errors.Is(err, notes.ErrNoteNotFound))
+
+	_, err = repo.ListRevisions(ctx, 42)
+	assert.True(t, errors.Is(err, notes.ErrNoteNotFound))
+}
+
+func testTrash(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo,
+		&entities.Note{Content: "One", CreatedAt: day.Add(time.Hour)},
+		&entities.Note{Content: "Two", CreatedAt: day.Add(2 * time.Hour)},
+		&entities.Note{Content: "Three", CreatedAt: day.Add(3 * time.Hour)},
+	)
+
+	require.NoError(t, repo.Delete(ctx, 1))
+	require.NoError(t, repo.Delete(ctx, 3))
+
+	assert.True(t, errors.Is(repo.Delete(ctx, 1), notes.ErrNoteNotFound))
+	assert.True(t, errors.Is(repo.Delete(ctx, 42), notes.ErrNoteNotFound))
+	assert.EqualError(t, repo.Delete(ctx, 1), "note 1 does not exist")
+
+	_, err := repo.Get(ctx, 1)
+	assert.True(t, errors.Is(err, notes.ErrNoteNotFound))
+
+	listed, err := repo.List(ctx, &notes.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids(listed))
+
+	trash, err := repo.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 1}, ids(trash))
+	require.NotNil(t, trash[0].DeletedAt)
+
+	require.NoError(t, repo.Restore(ctx, 1))
+	assert.True(t, errors.Is(repo.Restore(ctx, 1), notes.ErrNoteNotInTrash))
+	assert.True(t, errors.Is(repo.Restore(ctx, 1), noteerrors.ErrConflict))
+	assert.True(t, errors.Is(repo.Restore(ctx, 42), notes.ErrNoteNotFound))
+	assert.True(t, errors.Is(repo.Restore(ctx, 42), noteerrors.ErrNotFound))
+
+	note, err := repo.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "One", note.Content)
+
+	// IDs aren't reused while a note is still in the trash
+	created, err := repo.Create(ctx, &entities.Note{Content: "Four"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), created.ID)
+}
+
+func testPurge(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo,
+		&entities.Note{Content: "One", CreatedAt: day.Add(time.Hour)},
+		&entities.Note{Content: "Two", CreatedAt: day.Add(2 * time.Hour)},
+	)
+
+	require.NoError(t, repo.Delete(ctx, 1))
+
+	purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = repo.Purge(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	trash, err := repo.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	assert.True(t, errors.Is(repo.Restore(ctx, 1), notes.ErrNoteNotFound))
+
+	_, err = repo.Get(ctx, 2)
+	assert.NoError(t, err)
+}
+
+func testSearch(t *testing.T, backend *Backend) {
+	if !backend.Search {
+		t.Skip("the backend doesn't support searching")
+	}
+
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo,
+		&entities.Note{Content: "Deployed the new build to staging", CreatedAt: day.Add(time.Hour)},
+		&entities.Note{Content: "Build broke again, build cache to blame", CreatedAt: day.Add(2 * time.Hour)},
+		&entities.Note{Content: "Lunch with the team", CreatedAt: day.Add(3 * time.Hour)},
+		&entities.Note{Content: "Trashed build note", CreatedAt: day.Add(4 * time.Hour)},
+	)
+
+	require.NoError(t, repo.Delete(ctx, 4))
+
+	resultIDs := func(results []*entities.SearchResult) []int64 {
+		noteIDs := make([]int64, 0, len(results))
+		for _, result := range results {
+			noteIDs = append(noteIDs, result.Note.ID)
+		}
+
+		return noteIDs
+	}
+
+	results, err := repo.Search(ctx, "build", &notes.SearchOptions{HighlightStart: "[", HighlightEnd: "]"})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1}, resultIDs(results))
+	assert.Contains(t, results[1].Snippet, "[build]")
+	assert.Equal(t, "Deployed the new build to staging", results[1].Note.Content)
+
+	results, err = repo.Search(ctx, "build NOT staging", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, resultIDs(results))
+
+	results, err = repo.Search(ctx, "staging OR lunch", nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 3}, resultIDs(results))
+
+	results, err = repo.Search(ctx, "deploy*", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, resultIDs(results))
+
+	results, err = repo.Search(ctx, `"build cache"`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, resultIDs(results))
+
+	results, err = repo.Search(ctx, "build", &notes.SearchOptions{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	results, err = repo.Search(ctx, "build", &notes.SearchOptions{StartTime: day.Add(90 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, resultIDs(results))
+
+	results, err = repo.Search(ctx, "nothing", nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	results, err = repo.Search(ctx, "staging OR", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, resultIDs(results))
+
+	results, err = repo.Search(ctx, "build AND NOT staging", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, resultIDs(results))
+
+	results, err = repo.Search(ctx, "staging OR OR lunch NOT", nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 3}, resultIDs(results))
+
+	_, err = repo.Search(ctx, "NOT staging", nil)
+	assert.True(t, errors.Is(err, noteerrors.ErrInvalidInput))
+}
+
+func testImport(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	seed(t, repo, &entities.Note{Content: "Existing", CreatedAt: day.Add(time.Hour), Tags: []string{"work"}})
+
+	batch := func() []*entities.Note {
+		return []*entities.Note{
+			{Content: "Existing", CreatedAt: day.Add(time.Hour), Tags: []string{"#Work"}},
+			{Content: "New", CreatedAt: day.Add(2 * time.Hour)},
+			{Content: "New", CreatedAt: day.Add(2 * time.Hour)},
+			{Content: "Existing", CreatedAt: day.Add(time.Hour), Tags: []string{"home"}},
+		}
+	}
+
+	result, err := repo.Import(ctx, batch(), true)
+	require.NoError(t, err)
+	assert.Len(t, result.Imported, 2)
+	assert.Len(t, result.Duplicates, 2)
+
+	listed, err := repo.List(ctx, &notes.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+
+	result, err = repo.Import(ctx, batch(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, ids(result.Imported))
+	assert.Len(t, result.Duplicates, 2)
+
+	note, err := repo.Get(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"home"}, note.Tags)
+	assert.True(t, note.CreatedAt.Equal(day.Add(time.Hour)))
+
+	result, err = repo.Import(ctx, batch(), false)
+	require.NoError(t, err)
+	assert.Empty(t, result.Imported)
+
+	_, err = repo.Import(ctx, []*entities.Note{{Content: "Fine", CreatedAt: day}, {Content: " ", CreatedAt: day}}, false)
+	assert.EqualError(t, err, "note 2 has no content")
+
+	_, err = repo.Import(ctx, []*entities.Note{{Content: "No time"}}, false)
+	assert.EqualError(t, err, "note 1 has no creation time")
+
+	_, err = repo.Get(ctx, 4)
+	assert.True(t, errors.Is(err, notes.ErrNoteNotFound))
+}
+
+// testOffsets checks notes from different time zones sort by when they were created, and keep their offsets
+func testOffsets(t *testing.T, backend *Backend) {
+	ctx := context.Background()
+	repo := backend.Open(t, t.TempDir())
+
+	utc := time.Date(2022, time.April, 12, 6, 0, 0, 0, time.UTC)
+	east := time.Date(2022, time.April, 12, 9, 0, 0, 0, time.FixedZone("", 5*60*60))
+	west := time.Date(2022, time.April, 12, 1, 0, 0, 0, time.FixedZone("", -4*60*60))
+
+	seed(t, repo,
+		&entities.Note{Content: "In UTC", CreatedAt: utc},
+		&entities.Note{Content: "East", CreatedAt: east},
+		&entities.Note{Content: "West", CreatedAt: west},
+	)
+
+	listed, err := repo.List(ctx, &notes.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3, 1}, ids(listed))
+
+	for i, expected := range []time.Time{east, west, utc} {
+		assert.True(t, expected.Equal(listed[i].CreatedAt), listed[i].CreatedAt)
+
+		_, expectedOffset := expected.Zone()
+		_, offset := listed[i].CreatedAt.Zone()
+		assert.Equal(t, expectedOffset, offset, listed[i].Content)
+	}
+
+	listed, err = repo.List(ctx, &notes.ListOptions{
+		StartTime: utc.Add(-90 * time.Minute),
+		EndTime:   utc.Add(-30 * time.Minute).In(time.Local),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3}, ids(listed))
+}
+
+func testPersistence(t *testing.T, backend *Backend) {
+	if !backend.Persistent {
+		t.Skip("the backend doesn't keep its notes")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := backend.Open(t, dir)
+
+	content := "Multi-line note\n---\n  with: \"odd\" things\n\nin it"
+
+	created, err := repo.Create(ctx, &entities.Note{Content: "Draft", Tags: []string{"work"}})
+	require.NoError(t, err)
+
+	_, err = repo.Update(ctx, &entities.Note{ID: created.ID, Content: content}, nil)
+	require.NoError(t, err)
+
+	trashed, err := repo.Create(ctx, &entities.Note{Content: "Trashed"})
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete(ctx, trashed.ID))
+
+	reopened := backend.Open(t, dir)
+
+	note, err := reopened.Get(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, content, note.Content)
+	assert.Equal(t, []string{"work"}, note.Tags)
+	assert.True(t, created.CreatedAt.Equal(note.CreatedAt))
+	assert.NotNil(t, note.UpdatedAt)
+
+	revisions, err := reopened.ListRevisions(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Draft", revisions[0].Content)
+
+	trash, err := reopened.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{trashed.ID}, ids(trash))
+
+	created, err = reopened.Create(ctx, &entities.Note{Content: strings.Repeat("Long ", 1000)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), created.ID)
+}
+
+// testSharedWriters has two repositories open on the same notes, as two processes would
+func testSharedWriters(t *testing.T, backend *Backend) {
+	if !backend.Persistent {
+		t.Skip("the backend doesn't keep its notes")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := backend.Open(t, dir)
+	second := backend.Open(t, dir)
+
+	created, err := first.Create(ctx, &entities.Note{Content: "From the first"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+
+	created, err = second.Create(ctx, &entities.Note{Content: "From the second"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), created.ID)
+
+	note, err := first.Get(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "From the second", note.Content)
+
+	require.NoError(t, first.Delete(ctx, 2))
+
+	trash, err := second.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids(trash))
+
+	const writes = 20
+
+	var wg sync.WaitGroup
+
+	for _, repo := range []notes.Repository{first, second} {
+		wg.Add(1)
+
+		go func(repo notes.Repository) {
+			defer wg.Done()
+
+			for i := 0; i < writes; i++ {
+				_, err := repo.Create(ctx, &entities.Note{Content: "At the same time"})
+				assert.NoError(t, err)
+			}
+		}(repo)
+	}
+
+	wg.Wait()
+
+	for _, repo := range []notes.Repository{first, second} {
+		listed, err := repo.List(ctx, &notes.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, listed, 1+2*writes)
+	}
+
+	listed, err := backend.Open(t, dir).List(ctx, &notes.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, listed, 1+2*writes)
+
+	seen := make(map[int64]bool)
+	for _, note := range listed {
+		assert.False(t, seen[note.ID], note.ID)
+		seen[note.ID] = true
+	}
+
+	trash, err = backend.Open(t, dir).ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids(trash))
+}
@@ -0,0 +1,901 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"note-logger/internal/clock"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/tags"
+
+	"github.com/lib/pq"
+)
+
+const pgInsertNoteQuery string = `
+INSERT INTO notes (content, created_at, created_offset) VALUES($1,$2,$3) RETURNING id;
+`
+
+const pgInsertTagQuery string = `
+INSERT INTO tags (name) VALUES($1) ON CONFLICT (name) DO NOTHING;
+`
+
+const pgInsertNoteTagQuery string = `
+INSERT INTO note_tags (note_id, tag_id) SELECT $1, id FROM tags WHERE name = $2;
+`
+
+// the note's tags come back as a comma separated list, the same as from SQLite
+const pgNoteTagsColumn string = `
+(SELECT string_agg(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id)
+`
+
+const pgListBetweenQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,` + pgNoteTagsColumn + `
+FROM notes WHERE deleted_at IS NULL AND created_at >= $1 AND created_at <= $2 ORDER BY created_at ASC, id ASC
+`
+
+const pgListAnyTagsQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,` + pgNoteTagsColumn + `
+FROM notes WHERE deleted_at IS NULL AND created_at >= $1 AND created_at <= $2 AND id IN (
+SELECT note_tags.note_id FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE tags.name = ANY($3)
+) ORDER BY created_at ASC, id ASC
+`
+
+const pgListAllTagsQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,` + pgNoteTagsColumn + `
+FROM notes WHERE deleted_at IS NULL AND created_at >= $1 AND created_at <= $2 AND id IN (
+SELECT note_tags.note_id FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE tags.name = ANY($3)
+GROUP BY note_tags.note_id HAVING COUNT(*) = $4
+) ORDER BY created_at ASC, id ASC
+`
+
+// pgListPageQuery is pgListBetweenQuery with room for the tag filter and the cursor, then the order and the limit
+const pgListPageQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,` + pgNoteTagsColumn + `
+FROM notes WHERE deleted_at IS NULL AND created_at >= $1 AND created_at <= $2%v%v
+ORDER BY created_at %v, id %v LIMIT %v OFFSET %v
+`
+
+const pgAnyTagsCondition string = `
+AND id IN (SELECT note_tags.note_id FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE tags.name = ANY(%v))
+`
+
+const pgAllTagsCondition string = `
+AND id IN (SELECT note_tags.note_id FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE tags.name = ANY(%v)
+GROUP BY note_tags.note_id HAVING COUNT(*) = %v)
+`
+
+const pgCursorCondition string = `
+AND (created_at, id) %v (SELECT created_at, id FROM notes WHERE id = %v)
+`
+
+// notes in the trash count, as a cursor note could have been deleted after the page before was listed
+const pgNoteExistsQuery string = `
+SELECT COUNT(*) FROM notes WHERE id = $1
+`
+
+const pgListTagsQuery string = `
+SELECT tags.name, COUNT(*) FROM tags JOIN note_tags ON note_tags.tag_id = tags.id
+JOIN notes ON notes.id = note_tags.note_id WHERE notes.deleted_at IS NULL
+GROUP BY tags.id, tags.name ORDER BY COUNT(*) DESC, tags.name ASC
+`
+
+const pgListCreatedTimesQuery string = `
+SELECT created_at, created_offset FROM notes
+WHERE deleted_at IS NULL AND created_at >= $1 AND created_at <= $2 ORDER BY created_at ASC
+`
+
+const pgGetNoteQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,` + pgNoteTagsColumn + `
+FROM notes WHERE id = $1 AND deleted_at IS NULL
+`
+
+// the row stays locked until the update commits, or two machines updating the note at once could lose a revision
+const pgGetNoteForUpdateQuery string = `
+SELECT content, created_at, created_offset, updated_at,` + pgNoteTagsColumn + `
+FROM notes WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+`
+
+const pgInsertRevisionQuery string = `
+INSERT INTO note_revisions (note_id, content, created_at) VALUES($1,$2,$3);
+`
+
+const pgUpdateNoteQuery string = `
+UPDATE notes SET content = $1, updated_at = $2 WHERE id = $3;
+`
+
+const pgDeleteNoteTagsQuery string = `
+DELETE FROM note_tags WHERE note_id = $1;
+`
+
+const pgListRevisionsQuery string = `
+SELECT content, created_at FROM note_revisions WHERE note_id = $1 ORDER BY id ASC
+`
+
+const pgGetCurrentRevisionQuery string = `
+SELECT content, created_at, updated_at FROM notes WHERE id = $1 AND deleted_at IS NULL
+`
+
+const pgDeleteNoteQuery string = `
+UPDATE notes SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL
+`
+
+const pgListTrashQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,` + pgNoteTagsColumn + `,
+deleted_at
+FROM notes WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC
+`
+
+const pgRestoreNoteQuery string = `
+UPDATE notes SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL
+`
+
+const pgPurgeNotesQuery string = `
+DELETE FROM notes WHERE deleted_at IS NOT NULL AND deleted_at <= $1
+`
+
+const pgImportNoteQuery string = `
+INSERT INTO notes (content, created_at, created_offset, updated_at) VALUES($1,$2,$3,$4) RETURNING id;
+`
+
+// notes in the trash count too, or importing a file again would bring back deleted notes
+const pgImportCandidatesQuery string = `
+SELECT content,` + pgNoteTagsColumn + `
+FROM notes WHERE created_at >= $1 AND created_at < $2
+`
+
+// ts_rank is higher for better matches, so it's negated to sort the same way as SQLite's bm25
+const pgSearchQuery string = `
+SELECT notes.id, notes.content, notes.created_at, notes.created_offset, notes.updated_at,` + pgNoteTagsColumn + `,
+ts_headline('simple', notes.content, query, $1), -ts_rank(notes.content_tsv, query)
+FROM notes, to_tsquery('simple', $2) query
+WHERE notes.content_tsv @@ query AND notes.deleted_at IS NULL AND notes.created_at >= $3 AND notes.created_at <= $4
+ORDER BY ts_rank(notes.content_tsv, query) DESC, notes.id ASC LIMIT $5
+`
+
+type postgresRepo struct {
+	dbConn *sql.DB
+	clock  clock.Clock
+}
+
+// NewPostgresRepository creates a repository on a Postgres DB opened with postgres.New
+func NewPostgresRepository(cfg *Config) (Repository, error) {
+	if cfg.DB == nil {
+		return nil, errors.New("missing DB parameter")
+	}
+
+	newRepo := &postgresRepo{
+		dbConn: cfg.DB,
+		clock:  realClockIfUnset(cfg.Clock),
+	}
+
+	return newRepo, nil
+}
+
+// now is rounded to microseconds the way Postgres rounds timestamptz values, to match the times read back later
+func (repo *postgresRepo) now() time.Time {
+	return repo.clock.Now().Round(time.Microsecond)
+}
+
+func (repo *postgresRepo) Create(ctx context.Context, note *entities.Note) (*entities.Note, error) {
+	noteTags, err := tags.Normalize(note.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	note.CreatedAt = repo.now()
+
+	tx, err := repo.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	var lastID int64
+
+	_, offset := note.CreatedAt.Zone()
+
+	err = tx.QueryRowContext(ctx, pgInsertNoteQuery, note.Content, note.CreatedAt, offset).Scan(&lastID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pgInsertTags(ctx, tx, lastID, noteTags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	note.ID = lastID
+
+	if len(noteTags) > 0 {
+		note.Tags = noteTags
+	}
+
+	return note, nil
+}
+
+func pgInsertTags(ctx context.Context, tx *sql.Tx, noteID int64, noteTags []string) error {
+	for _, tag := range noteTags {
+		_, err := tx.ExecContext(ctx, pgInsertTagQuery, tag)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, pgInsertNoteTagQuery, noteID, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (repo *postgresRepo) Get(ctx context.Context, noteID int64) (*entities.Note, error) {
+	rows, err := repo.dbConn.QueryContext(ctx, pgGetNoteQuery, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		err = rows.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, &NotFoundError{ID: noteID}
+	}
+
+	return scanNote(rows)
+}
+
+// ForEach works the same as it does for SQLite, reading the notes from the DB as it goes
+func (repo *postgresRepo) ForEach(ctx context.Context, startTime time.Time, endTime time.Time, filter *TagFilter,
+	fn func(note *entities.Note) error) error {
+	query := pgListBetweenQuery
+	args := []interface{}{startTime, endTime}
+
+	if filter != nil && len(filter.Tags) > 0 {
+		filterTags, err := tags.Normalize(filter.Tags)
+		if err != nil {
+			return err
+		}
+
+		query = pgListAnyTagsQuery
+		args = append(args, pq.Array(filterTags))
+
+		if filter.MatchAll {
+			query = pgListAllTagsQuery
+			args = append(args, len(filterTags))
+		}
+	}
+
+	rows, err := repo.dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return err
+		}
+
+		err = fn(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// List works the same as it does for SQLite, where a NULL limit is no limit at all
+func (repo *postgresRepo) List(ctx context.Context, opts *ListOptions) ([]*entities.Note, error) {
+	endTime := opts.EndTime
+	if endTime.IsZero() {
+		endTime = MaxTime
+	}
+
+	args := []interface{}{opts.StartTime, endTime}
+
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%v", len(args))
+	}
+
+	tagsCondition := ""
+
+	if opts.Filter != nil && len(opts.Filter.Tags) > 0 {
+		filterTags, err := tags.Normalize(opts.Filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.Filter.MatchAll {
+			tagsCondition = fmt.Sprintf(pgAllTagsCondition, param(pq.Array(filterTags)), param(len(filterTags)))
+		} else {
+			tagsCondition = fmt.Sprintf(pgAnyTagsCondition, param(pq.Array(filterTags)))
+		}
+	}
+
+	cursorCondition := ""
+
+	if opts.AfterID != 0 {
+		err := repo.checkExists(ctx, opts.AfterID)
+		if err != nil {
+			return nil, err
+		}
+
+		cursorCondition = fmt.Sprintf(pgCursorCondition, cursorComparison(opts.Reverse), param(opts.AfterID))
+	}
+
+	var limit interface{}
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+
+	direction := sortDirection(opts.Reverse)
+	query := fmt.Sprintf(pgListPageQuery, tagsCondition, cursorCondition, direction, direction, param(limit),
+		param(opts.Offset))
+
+	rows, err := repo.dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanNotes(rows)
+}
+
+func (repo *postgresRepo) ListTags(ctx context.Context) ([]*entities.TagCount, error) {
+	tagCounts := make([]*entities.TagCount, 0)
+
+	rows, err := repo.dbConn.QueryContext(ctx, pgListTagsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var count int64
+
+		err = rows.Scan(&name, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		tagCounts = append(tagCounts, &entities.TagCount{
+			Name:  name,
+			Count: count,
+		})
+	}
+
+	return tagCounts, rows.Err()
+}
+
+func (repo *postgresRepo) ListCreatedTimes(ctx context.Context, startTime time.Time,
+	endTime time.Time) ([]time.Time, error) {
+	rows, err := repo.dbConn.QueryContext(ctx, pgListCreatedTimesQuery, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanCreatedTimes(rows)
+}
+
+// Update works the same as it does for SQLite, with the note's row locked while the revision gets written
+func (repo *postgresRepo) Update(ctx context.Context, note *entities.Note,
+	opts *UpdateOptions) (*entities.Note, error) {
+	tx, err := repo.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, pgGetNoteForUpdateQuery, note.ID)
+
+	var oldContent string
+	var oldCreatedAt time.Time
+	var oldOffset int
+	var oldUpdatedAt sql.NullTime
+	var oldTags sql.NullString
+
+	err = row.Scan(&oldContent, &oldCreatedAt, &oldOffset, &oldUpdatedAt, &oldTags)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &NotFoundError{ID: note.ID}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(note.Content) == "" {
+		note.Content = oldContent
+	}
+
+	newTags, err := updatedTags(oldContent, splitTags(oldTags), note, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt := nullTime(oldUpdatedAt)
+
+	if note.Content != oldContent {
+		now := repo.now()
+		updatedAt = &now
+
+		_, err = tx.ExecContext(ctx, pgInsertRevisionQuery, note.ID, oldContent, writtenAt(oldCreatedAt, oldUpdatedAt))
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, pgUpdateNoteQuery, note.Content, now, note.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, pgDeleteNoteTagsQuery, note.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pgInsertTags(ctx, tx, note.ID, newTags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	note.CreatedAt = createdIn(oldCreatedAt, oldOffset)
+	note.UpdatedAt = updatedAt
+	note.Tags = nil
+
+	if len(newTags) > 0 {
+		note.Tags = newTags
+	}
+
+	return note, nil
+}
+
+func (repo *postgresRepo) ListRevisions(ctx context.Context, noteID int64) ([]*entities.Revision, error) {
+	row := repo.dbConn.QueryRowContext(ctx, pgGetCurrentRevisionQuery, noteID)
+
+	var content string
+	var createdAt time.Time
+	var updatedAt sql.NullTime
+
+	err := row.Scan(&content, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &NotFoundError{ID: noteID}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	current := &entities.Revision{
+		NoteID:    noteID,
+		Content:   content,
+		CreatedAt: writtenAt(createdAt, updatedAt),
+		Current:   true,
+	}
+
+	rows, err := repo.dbConn.QueryContext(ctx, pgListRevisionsQuery, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := make([]*entities.Revision, 0)
+
+	for rows.Next() {
+		err = rows.Scan(&content, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, &entities.Revision{
+			Number:    len(revisions) + 1,
+			NoteID:    noteID,
+			Content:   content,
+			CreatedAt: createdAt,
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	current.Number = len(revisions) + 1
+
+	return append(revisions, current), nil
+}
+
+// Delete moves a note to the trash, checking it exists in the same statement
+func (repo *postgresRepo) Delete(ctx context.Context, noteID int64) error {
+	res, err := repo.dbConn.ExecContext(ctx, pgDeleteNoteQuery, repo.now(), noteID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &NotFoundError{ID: noteID}
+	}
+
+	return nil
+}
+
+func (repo *postgresRepo) ListTrash(ctx context.Context) ([]*entities.Note, error) {
+	retNotes := make([]*entities.Note, 0)
+
+	rows, err := repo.dbConn.QueryContext(ctx, pgListTrashQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var content string
+		var createdAt time.Time
+		var createdOffset int
+		var updatedAt sql.NullTime
+		var noteTags sql.NullString
+		var deletedAt sql.NullTime
+
+		err = rows.Scan(&id, &content, &createdAt, &createdOffset, &updatedAt, &noteTags, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		retNotes = append(retNotes, &entities.Note{
+			ID:        id,
+			Content:   content,
+			CreatedAt: createdIn(createdAt, createdOffset),
+			UpdatedAt: nullTime(updatedAt),
+			DeletedAt: nullTime(deletedAt),
+			Tags:      splitTags(noteTags),
+		})
+	}
+
+	return retNotes, rows.Err()
+}
+
+// checkExists gives a NotFoundError unless there's a note with the ID, in the trash or not
+func (repo *postgresRepo) checkExists(ctx context.Context, noteID int64) error {
+	var count int64
+
+	err := repo.dbConn.QueryRowContext(ctx, pgNoteExistsQuery, noteID).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return &NotFoundError{ID: noteID}
+	}
+
+	return nil
+}
+
+func (repo *postgresRepo) Restore(ctx context.Context, noteID int64) error {
+	res, err := repo.dbConn.ExecContext(ctx, pgRestoreNoteQuery, noteID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		err = repo.checkExists(ctx, noteID)
+		if err != nil {
+			return err
+		}
+
+		return ErrNoteNotInTrash
+	}
+
+	return nil
+}
+
+func (repo *postgresRepo) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	res, err := repo.dbConn.ExecContext(ctx, pgPurgeNotesQuery, olderThan)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Import works the same as it does for SQLite
+func (repo *postgresRepo) Import(ctx context.Context, batch []*entities.Note, dryRun bool) (*ImportResult, error) {
+	for i, note := range batch {
+		if strings.TrimSpace(note.Content) == "" {
+			return nil, noteerrors.InvalidInputf("note %v has no content", i+1)
+		}
+
+		if note.CreatedAt.IsZero() {
+			return nil, noteerrors.InvalidInputf("note %v has no creation time", i+1)
+		}
+
+		noteTags, err := tags.Normalize(note.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("note %v: %w", i+1, err)
+		}
+
+		note.Tags = nil
+		if len(noteTags) > 0 {
+			note.Tags = noteTags
+		}
+	}
+
+	tx, err := repo.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	result := &ImportResult{
+		Imported:   make([]*entities.Note, 0),
+		Duplicates: make([]*entities.Note, 0),
+	}
+
+	seen := make(map[string]bool)
+
+	for _, note := range batch {
+		key := importKey(note.CreatedAt, note.Content, note.Tags)
+
+		duplicate := seen[key]
+		if !duplicate {
+			duplicate, err = pgImportExists(ctx, tx, note, key)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if duplicate {
+			result.Duplicates = append(result.Duplicates, note)
+
+			continue
+		}
+
+		seen[key] = true
+		result.Imported = append(result.Imported, note)
+
+		if dryRun {
+			continue
+		}
+
+		var updatedAt interface{}
+		if note.UpdatedAt != nil {
+			updatedAt = *note.UpdatedAt
+		}
+
+		_, offset := note.CreatedAt.Zone()
+
+		err = tx.QueryRowContext(ctx, pgImportNoteQuery, note.Content, note.CreatedAt, offset, updatedAt).
+			Scan(&note.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = pgInsertTags(ctx, tx, note.ID, note.Tags)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func pgImportExists(ctx context.Context, tx *sql.Tx, note *entities.Note, key string) (bool, error) {
+	second := note.CreatedAt.Truncate(time.Second)
+
+	rows, err := tx.QueryContext(ctx, pgImportCandidatesQuery, second, second.Add(time.Second))
+	if err != nil {
+		return false, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var content string
+		var noteTags sql.NullString
+
+		err = rows.Scan(&content, &noteTags)
+		if err != nil {
+			return false, err
+		}
+
+		if importKey(note.CreatedAt, content, splitTags(noteTags)) == key {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// Search uses the tsvector column, with the search turned into a tsquery
+func (repo *postgresRepo) Search(ctx context.Context, query string, opts *SearchOptions) ([]*entities.SearchResult, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+
+	results := make([]*entities.SearchResult, 0)
+
+	tsQuery, err := buildTSQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if tsQuery == "" {
+		return results, nil
+	}
+
+	endTime := opts.EndTime
+	if endTime.IsZero() {
+		endTime = MaxTime
+	}
+
+	// no limit at all to Postgres is a NULL one
+	var limit interface{}
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+
+	rows, err := repo.dbConn.QueryContext(ctx, pgSearchQuery, headlineOptions(opts), tsQuery, opts.StartTime, endTime,
+		limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var content string
+		var createdAt time.Time
+		var createdOffset int
+		var updatedAt sql.NullTime
+		var noteTags sql.NullString
+		var snippet string
+		var rank float64
+
+		err = rows.Scan(&id, &content, &createdAt, &createdOffset, &updatedAt, &noteTags, &snippet, &rank)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, &entities.SearchResult{
+			Note: &entities.Note{
+				ID:        id,
+				Content:   content,
+				CreatedAt: createdIn(createdAt, createdOffset),
+				UpdatedAt: nullTime(updatedAt),
+				Tags:      splitTags(noteTags),
+			},
+			Snippet: snippet,
+			Rank:    rank,
+		})
+	}
+
+	return results, rows.Err()
+}
+
+// buildTSQuery turns a search typed in by a user into a tsquery, with every word quoted and a phrase's words in order
+func buildTSQuery(query string) (string, error) {
+	tokens, err := parseSearch(query)
+	if err != nil {
+		return "", err
+	}
+
+	var built strings.Builder
+
+	operator := ""
+
+	for _, token := range tokens {
+		if token.operator != "" {
+			operator = token.operator
+			continue
+		}
+
+		words := splitWords(token.term)
+		if len(words) == 0 {
+			continue
+		}
+
+		lexemes := make([]string, 0, len(words))
+		for _, w := range words {
+			lexemes = append(lexemes, "'"+strings.ReplaceAll(w.text, "'", "''")+"'")
+		}
+
+		if token.prefix {
+			lexemes[len(lexemes)-1] += ":*"
+		}
+
+		operand := strings.Join(lexemes, " <-> ")
+		if len(lexemes) > 1 {
+			operand = "(" + operand + ")"
+		}
+
+		switch {
+		case built.Len() == 0 && operator == "NOT":
+			built.WriteString("!")
+		case built.Len() == 0:
+		case operator == "OR":
+			built.WriteString(" | ")
+		case operator == "NOT":
+			built.WriteString(" & !")
+		default:
+			built.WriteString(" & ")
+		}
+
+		built.WriteString(operand)
+
+		operator = ""
+	}
+
+	return built.String(), nil
+}
+
+// headlineOptions has ts_headline mark the matches the way SQLite's snippet does
+func headlineOptions(opts *SearchOptions) string {
+	quote := func(value string) string {
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	}
+
+	return fmt.Sprintf("StartSel=%v, StopSel=%v, MaxWords=%v, MinWords=%v, ShortWord=0",
+		quote(opts.HighlightStart), quote(opts.HighlightEnd), snippetWords, snippetWords/2)
+}
+
+// Close closes the DB, ending its connections to the Postgres server
+func (repo *postgresRepo) Close() error {
+	return repo.dbConn.Close()
+}
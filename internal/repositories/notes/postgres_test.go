@@ -0,0 +1,280 @@
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	mock_clock "note-logger/internal/clock/mock"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type pgTestSuite struct {
+	suite.Suite
+	ctrl        *gomock.Controller
+	ctx         context.Context
+	mockDB      sqlmock.Sqlmock
+	mockClock   *mock_clock.MockClock
+	repoFixture *postgresRepo
+}
+
+func (s *pgTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.ctx = context.Background()
+
+	db, mockDB, err := sqlmock.New()
+	if err != nil {
+		s.T().Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	s.mockDB = mockDB
+	s.mockClock = mock_clock.NewMockClock(s.ctrl)
+
+	s.repoFixture = &postgresRepo{
+		dbConn: db,
+		clock:  s.mockClock,
+	}
+}
+
+func (s *pgTestSuite) AfterTest(_, _ string) {
+	s.ctrl.Finish()
+
+	err := s.mockDB.ExpectationsWereMet()
+	assert.NoError(s.T(), err)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Create_WithTags() {
+	createdAt := time.Unix(1649707678, 0).UTC()
+
+	expectedNote := &entities.Note{
+		ID:        5,
+		Content:   "Fixed the build #work",
+		CreatedAt: createdAt,
+		Tags:      []string{"ci", "work"},
+	}
+
+	s.mockClock.EXPECT().Now().Return(createdAt)
+
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgInsertNoteQuery)).
+		WithArgs(expectedNote.Content, createdAt, 0).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+	for _, tag := range expectedNote.Tags {
+		s.mockDB.ExpectExec(regexp.QuoteMeta(pgInsertTagQuery)).WithArgs(tag).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		s.mockDB.ExpectExec(regexp.QuoteMeta(pgInsertNoteTagQuery)).
+			WithArgs(int64(5), tag).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	s.mockDB.ExpectCommit()
+
+	res, err := s.repoFixture.Create(s.ctx, &entities.Note{
+		Content: "Fixed the build #work",
+		Tags:    []string{"work", "CI"},
+	})
+
+	assert.Equal(s.T(), expectedNote, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Get_NotFound() {
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"})
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgGetNoteQuery)).WithArgs(int64(5)).WillReturnRows(rows)
+
+	res, err := s.repoFixture.Get(s.ctx, 5)
+
+	assert.Nil(s.T(), res)
+	assert.ErrorIs(s.T(), err, ErrNoteNotFound)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_ForEach_AllOf() {
+	createdAt := time.Unix(1649707678, 0).UTC()
+	startTime := time.Unix(1649700000, 0).UTC()
+
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow(3, "Tagged note", createdAt, 0, nil, "work,idea")
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgListAllTagsQuery)).
+		WithArgs(startTime, MaxTime, pq.Array([]string{"idea", "work"}), 2).WillReturnRows(rows)
+
+	res := make([]*entities.Note, 0)
+
+	err := s.repoFixture.ForEach(s.ctx, startTime, MaxTime, &TagFilter{Tags: []string{"#Work", "idea"}, MatchAll: true},
+		func(note *entities.Note) error {
+			res = append(res, note)
+
+			return nil
+		})
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []*entities.Note{
+		{ID: 3, Content: "Tagged note", CreatedAt: createdAt, Tags: []string{"idea", "work"}},
+	}, res)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_List_Page() {
+	createdAt := time.Unix(1649707678, 0).UTC()
+
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow(4, "Older note", createdAt, 0, nil, "work")
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgNoteExistsQuery)).WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(pgListPageQuery, fmt.Sprintf(pgAnyTagsCondition, "$3"),
+		fmt.Sprintf(pgCursorCondition, "<", "$4"), "DESC", "DESC", "$5", "$6"))).
+		WithArgs(time.Time{}, MaxTime, pq.Array([]string{"work"}), int64(5), 10, 0).WillReturnRows(rows)
+
+	res, err := s.repoFixture.List(s.ctx, &ListOptions{
+		Filter:  &TagFilter{Tags: []string{"work"}},
+		Reverse: true,
+		AfterID: 5,
+		Limit:   10,
+	})
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []*entities.Note{
+		{ID: 4, Content: "Older note", CreatedAt: createdAt, Tags: []string{"work"}},
+	}, res)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Update_NotFound() {
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgGetNoteForUpdateQuery)).WithArgs(int64(5)).WillReturnError(sql.ErrNoRows)
+
+	s.mockDB.ExpectRollback()
+
+	res, err := s.repoFixture.Update(s.ctx, &entities.Note{ID: 5, Content: "Changed"}, nil)
+
+	assert.Nil(s.T(), res)
+	assert.ErrorIs(s.T(), err, ErrNoteNotFound)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Delete() {
+	deletedAt := time.Unix(1649707678, 0).UTC()
+
+	s.mockClock.EXPECT().Now().Return(deletedAt).Times(2)
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(pgDeleteNoteQuery)).
+		WithArgs(deletedAt, int64(5)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(pgDeleteNoteQuery)).
+		WithArgs(deletedAt, int64(6)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(s.T(), s.repoFixture.Delete(s.ctx, 5))
+
+	err := s.repoFixture.Delete(s.ctx, 6)
+	assert.ErrorIs(s.T(), err, ErrNoteNotFound)
+	assert.Equal(s.T(), &NotFoundError{ID: 6}, err)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Restore_NotInTrash() {
+	s.mockDB.ExpectExec(regexp.QuoteMeta(pgRestoreNoteQuery)).
+		WithArgs(int64(5)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgNoteExistsQuery)).WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	assert.ErrorIs(s.T(), s.repoFixture.Restore(s.ctx, 5), ErrNoteNotInTrash)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Restore_NotFound() {
+	s.mockDB.ExpectExec(regexp.QuoteMeta(pgRestoreNoteQuery)).
+		WithArgs(int64(5)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgNoteExistsQuery)).WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	assert.ErrorIs(s.T(), s.repoFixture.Restore(s.ctx, 5), ErrNoteNotFound)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Search_Success() {
+	expectedResults := []*entities.SearchResult{
+		{
+			Note: &entities.Note{
+				ID:        2,
+				Content:   "Deployed the new search feature",
+				CreatedAt: time.Unix(1649717678, 0).UTC(),
+			},
+			Snippet: "Deployed the new [search] feature",
+			Rank:    -0.06,
+		},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags", "snippet", "rank"}).
+		AddRow(expectedResults[0].Note.ID, expectedResults[0].Note.Content, expectedResults[0].Note.CreatedAt, 0, nil, nil,
+			expectedResults[0].Snippet, expectedResults[0].Rank)
+
+	startTime := time.Unix(1649707678, 0).UTC()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(pgSearchQuery)).
+		WithArgs(`StartSel="[", StopSel="]", MaxWords=16, MinWords=8, ShortWord=0`, `'search':*`, startTime,
+			MaxTime, nil).
+		WillReturnRows(rows)
+
+	res, err := s.repoFixture.Search(s.ctx, "search*", &SearchOptions{
+		StartTime:      startTime,
+		HighlightStart: "[",
+		HighlightEnd:   "]",
+	})
+
+	assert.Equal(s.T(), expectedResults, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *pgTestSuite) TestPostgresRepo_Search_NothingToSearchFor() {
+	res, err := s.repoFixture.Search(s.ctx, `"" ***`, nil)
+
+	assert.Equal(s.T(), []*entities.SearchResult{}, res)
+	assert.NoError(s.T(), err)
+}
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "deploy", expected: `'deploy'`},
+		{query: "  Deploy   server ", expected: `'deploy' & 'server'`},
+		{query: "deploy*", expected: `'deploy':*`},
+		{query: `"new search feature"`, expected: `('new' <-> 'search' <-> 'feature')`},
+		{query: `"new search"* feature`, expected: `('new' <-> 'search':*) & 'feature'`},
+		{query: "deploy OR release NOT staging", expected: `'deploy' | 'release' & !'staging'`},
+		{query: "deploy AND release", expected: `'deploy' & 'release'`},
+		{query: "deploy OR", expected: `'deploy'`},
+		{query: "OR deploy AND NOT staging", expected: `'deploy' & !'staging'`},
+		{query: "can't stop-gap", expected: `('can' <-> 't') & ('stop' <-> 'gap')`},
+		{query: `say "hi`, expected: `'say' & 'hi'`},
+		{query: `"" !&|`, expected: ``},
+	}
+
+	for _, test := range tests {
+		tsQuery, err := buildTSQuery(test.query)
+		assert.NoError(t, err, test.query)
+		assert.Equal(t, test.expected, tsQuery, test.query)
+	}
+
+	_, err := buildTSQuery("NOT staging")
+	assert.ErrorIs(t, err, noteerrors.ErrInvalidInput)
+}
+
+func TestHeadlineOptions(t *testing.T) {
+	assert.Equal(t, `StartSel="", StopSel="", MaxWords=16, MinWords=8, ShortWord=0`, headlineOptions(&SearchOptions{}))
+	assert.Equal(t, `StartSel="<b class=""hit"">", StopSel="</b>", MaxWords=16, MinWords=8, ShortWord=0`,
+		headlineOptions(&SearchOptions{HighlightStart: `<b class="hit">`, HighlightEnd: "</b>"}))
+}
+
+func TestPostgresSuites(t *testing.T) {
+	suite.Run(t, new(pgTestSuite))
+}
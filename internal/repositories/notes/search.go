@@ -0,0 +1,340 @@
+package notes
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"note-logger/internal/noteerrors"
+)
+
+// searchToken is a single part of a search typed in by a user, either a term to look for or an operator
+type searchToken struct {
+	term     string
+	prefix   bool
+	operator string
+}
+
+// splitSearch breaks a search into its terms, with "quoted phrases" kept together, and its AND, OR and NOT operators
+func splitSearch(query string) []searchToken {
+	tokens := make([]searchToken, 0)
+
+	rest := strings.TrimSpace(query)
+
+	for rest != "" {
+		var term string
+		var prefix bool
+
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end == -1 {
+				term, rest = rest[1:], ""
+			} else {
+				term, rest = rest[1:end+1], rest[end+2:]
+			}
+
+			if strings.HasPrefix(rest, "*") {
+				prefix, rest = true, rest[1:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end == -1 {
+				end = len(rest)
+			}
+
+			term, rest = rest[:end], rest[end:]
+
+			if term == "AND" || term == "OR" || term == "NOT" {
+				tokens = append(tokens, searchToken{operator: term})
+				rest = strings.TrimSpace(rest)
+
+				continue
+			}
+
+			prefix = strings.HasSuffix(term, "*")
+			term = strings.TrimRight(term, "*")
+		}
+
+		rest = strings.TrimSpace(rest)
+
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+
+		tokens = append(tokens, searchToken{term: term, prefix: prefix})
+	}
+
+	return tokens
+}
+
+// parseSearch splits a search and tidies up its operators for every backend to read the same way. Of operators in a
+// row the last one counts, and ones with nothing to join are dropped, but a search can't start with NOT.
+func parseSearch(query string) ([]searchToken, error) {
+	tokens := make([]searchToken, 0)
+
+	for _, token := range splitSearch(query) {
+		last := len(tokens) - 1
+
+		switch {
+		case token.operator == "":
+		case last == -1 && token.operator == "NOT":
+			return nil, noteerrors.InvalidInput(errors.New("a search can't start with NOT, put what to search for first"))
+		case last == -1:
+			continue
+		case tokens[last].operator != "":
+			tokens[last] = token
+			continue
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	for len(tokens) > 0 && tokens[len(tokens)-1].operator != "" {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	return tokens, nil
+}
+
+// buildMatchQuery turns a search into an FTS5 query, quoting every term to keep punctuation from being FTS5 syntax
+func buildMatchQuery(query string) (string, error) {
+	tokens, err := parseSearch(query)
+	if err != nil {
+		return "", err
+	}
+
+	terms := make([]string, 0)
+
+	for _, token := range tokens {
+		if token.operator != "" {
+			terms = append(terms, token.operator)
+			continue
+		}
+
+		quoted := `"` + strings.ReplaceAll(token.term, `"`, `""`) + `"`
+		if token.prefix {
+			quoted += "*"
+		}
+
+		terms = append(terms, quoted)
+	}
+
+	return strings.Join(terms, " "), nil
+}
+
+// word is a word in a note's content, lower cased, along with where it is in the content
+type word struct {
+	text  string
+	start int
+	end   int
+}
+
+// splitWords breaks content into words the same way the FTS5 tokenizer does
+func splitWords(content string) []word {
+	words := make([]word, 0)
+
+	start := -1
+
+	for i, r := range content {
+		isWordChar := unicode.IsLetter(r) || unicode.IsNumber(r)
+
+		if isWordChar && start == -1 {
+			start = i
+		}
+
+		if !isWordChar && start != -1 {
+			words = append(words, word{text: strings.ToLower(content[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+
+	if start != -1 {
+		words = append(words, word{text: strings.ToLower(content[start:]), start: start, end: len(content)})
+	}
+
+	return words
+}
+
+// searchTerm is a phrase of one or more words to look for
+type searchTerm struct {
+	words  []string
+	prefix bool
+	not    bool
+}
+
+// searchMatcher matches notes against a search, for the backends without a full-text index
+type searchMatcher struct {
+	alternatives [][]searchTerm
+}
+
+func newSearchMatcher(query string) (*searchMatcher, error) {
+	tokens, err := parseSearch(query)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := &searchMatcher{}
+
+	current := make([]searchTerm, 0)
+	not := false
+
+	for _, token := range tokens {
+		switch token.operator {
+		case "OR":
+			matcher.alternatives = append(matcher.alternatives, current)
+			current = make([]searchTerm, 0)
+
+			continue
+		case "NOT":
+			not = true
+			continue
+		case "AND":
+			continue
+		}
+
+		termWords := make([]string, 0)
+		for _, w := range splitWords(token.term) {
+			termWords = append(termWords, w.text)
+		}
+
+		if len(termWords) > 0 {
+			current = append(current, searchTerm{words: termWords, prefix: token.prefix, not: not})
+		}
+
+		not = false
+	}
+
+	matcher.alternatives = append(matcher.alternatives, current)
+
+	return matcher, nil
+}
+
+// occurrences finds where the term appears in the words, as ranges of word indexes
+func (term searchTerm) occurrences(words []word) [][2]int {
+	found := make([][2]int, 0)
+
+	for i := 0; i+len(term.words) <= len(words); i++ {
+		matched := true
+
+		for j, termWord := range term.words {
+			contentWord := words[i+j].text
+
+			last := j == len(term.words)-1
+			if contentWord != termWord && !(last && term.prefix && strings.HasPrefix(contentWord, termWord)) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			found = append(found, [2]int{i, i + len(term.words) - 1})
+		}
+	}
+
+	return found
+}
+
+// match gives the ranges of words in the content that matched, and whether the content matches at all
+func (matcher *searchMatcher) match(content string) ([]word, [][2]int, bool) {
+	words := splitWords(content)
+
+	for _, terms := range matcher.alternatives {
+		if len(terms) == 0 {
+			continue
+		}
+
+		matches := make([][2]int, 0)
+		positive := 0
+		matched := true
+
+		for _, term := range terms {
+			found := term.occurrences(words)
+
+			if term.not {
+				if len(found) > 0 {
+					matched = false
+				}
+
+				continue
+			}
+
+			if len(found) == 0 {
+				matched = false
+			}
+
+			positive++
+			matches = append(matches, found...)
+		}
+
+		if matched && positive > 0 {
+			sort.Slice(matches, func(i, j int) bool {
+				return matches[i][0] < matches[j][0]
+			})
+
+			return words, matches, true
+		}
+	}
+
+	return words, nil, false
+}
+
+// snippetWords is how many words of the content a snippet shows, the same as the FTS5 snippets
+const snippetWords int = 16
+
+// snippet highlights the matches in the content, cut down to the words around the first match like FTS5 snippets
+func snippet(content string, words []word, matches [][2]int, highlightStart string, highlightEnd string) string {
+	first, last := 0, len(words)-1
+
+	if len(words) > snippetWords {
+		if len(matches) > 0 {
+			first = matches[0][0] - 2
+		}
+
+		if first > len(words)-snippetWords {
+			first = len(words) - snippetWords
+		}
+
+		if first < 0 {
+			first = 0
+		}
+
+		last = first + snippetWords - 1
+	}
+
+	start, end := 0, len(content)
+
+	var builder strings.Builder
+
+	if first > 0 {
+		start = words[first].start
+		builder.WriteString("...")
+	}
+
+	if last < len(words)-1 {
+		end = words[last].end
+	}
+
+	position := start
+
+	for _, m := range matches {
+		if m[0] < first || m[1] > last || words[m[0]].start < position {
+			continue
+		}
+
+		builder.WriteString(content[position:words[m[0]].start])
+		builder.WriteString(highlightStart)
+		builder.WriteString(content[words[m[0]].start:words[m[1]].end])
+		builder.WriteString(highlightEnd)
+
+		position = words[m[1]].end
+	}
+
+	builder.WriteString(content[position:end])
+
+	if end < len(content) {
+		builder.WriteString("...")
+	}
+
+	return builder.String()
+}
@@ -2,32 +2,175 @@ package notes
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
 	"note-logger/internal/clock"
 	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/tags"
 
-	_ "github.com/mattn/go-sqlite3"
+	sqlite3 "github.com/mattn/go-sqlite3"
 )
 
 const insertNoteQuery string = `
-INSERT INTO notes (content, created_at) VALUES(?,?);
+INSERT INTO notes (content, created_at, created_offset) VALUES(?,?,?);
 `
 
+const insertTagQuery string = `
+INSERT OR IGNORE INTO tags (name) VALUES(?);
+`
+
+const insertNoteTagQuery string = `
+INSERT INTO note_tags (note_id, tag_id) SELECT ?, id FROM tags WHERE name = ?;
+`
+
+// the note's tags come back as a comma separated list, which is safe since tags can't contain commas
 const listBetweenQuery string = `
-SELECT id, content, created_at FROM notes WHERE created_at >= ? AND created_at <= ? ORDER BY created_at ASC
+SELECT id, content, created_at, created_offset, updated_at,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id)
+FROM notes WHERE deleted_at IS NULL AND created_at >= ? AND created_at <= ? ORDER BY created_at ASC
+`
+
+const listTaggedQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id)
+FROM notes WHERE deleted_at IS NULL AND created_at >= ? AND created_at <= ? AND id IN (%v) ORDER BY created_at ASC
+`
+
+// listPageQuery is listBetweenQuery with room for the tag filter and the cursor, then the order and the limit
+const listPageQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id)
+FROM notes WHERE deleted_at IS NULL AND created_at >= ? AND created_at <= ?%v%v
+ORDER BY created_at %v, id %v LIMIT ? OFFSET ?
 `
 
+// notes in the trash count, as a cursor note could have been deleted after the page before was listed
 const noteExistsQuery string = `
-SELECT id FROM notes WHERE id = ?
+SELECT COUNT(*) FROM notes WHERE id = ?
+`
+
+const anyTagsQuery string = `
+SELECT note_tags.note_id FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE tags.name IN (%v)
+`
+
+const allTagsQuery string = `
+SELECT note_tags.note_id FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE tags.name IN (%v)
+GROUP BY note_tags.note_id HAVING COUNT(*) = %v
+`
+
+const listTagsQuery string = `
+SELECT tags.name, COUNT(*) FROM tags JOIN note_tags ON note_tags.tag_id = tags.id
+JOIN notes ON notes.id = note_tags.note_id WHERE notes.deleted_at IS NULL
+GROUP BY tags.id ORDER BY COUNT(*) DESC, tags.name ASC
+`
+
+// only the times are read, which the created_at index covers
+const listCreatedTimesQuery string = `
+SELECT created_at, created_offset FROM notes
+WHERE deleted_at IS NULL AND created_at >= ? AND created_at <= ? ORDER BY created_at ASC
+`
+
+const getNoteQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id)
+FROM notes WHERE id = ? AND deleted_at IS NULL
+`
+
+const getNoteForUpdateQuery string = `
+SELECT content, created_at, created_offset, updated_at,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id)
+FROM notes WHERE id = ? AND deleted_at IS NULL
+`
+
+const insertRevisionQuery string = `
+INSERT INTO note_revisions (note_id, content, created_at) VALUES(?,?,?);
+`
+
+const updateNoteQuery string = `
+UPDATE notes SET content = ?, updated_at = ? WHERE id = ?;
+`
+
+const deleteNoteTagsQuery string = `
+DELETE FROM note_tags WHERE note_id = ?;
+`
+
+const listRevisionsQuery string = `
+SELECT content, created_at FROM note_revisions WHERE note_id = ? ORDER BY id ASC
+`
+
+const getCurrentRevisionQuery string = `
+SELECT content, created_at, updated_at FROM notes WHERE id = ? AND deleted_at IS NULL
 `
 
 const deleteNoteQuery string = `
-DELETE FROM notes WHERE id = ?
+UPDATE notes SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL
+`
+
+const listTrashQuery string = `
+SELECT id, content, created_at, created_offset, updated_at,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id),
+deleted_at
+FROM notes WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC
 `
 
+const restoreNoteQuery string = `
+UPDATE notes SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+const purgeNotesQuery string = `
+DELETE FROM notes WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+`
+
+const importNoteQuery string = `
+INSERT INTO notes (content, created_at, created_offset, updated_at) VALUES(?,?,?,?);
+`
+
+// notes in the trash count too, or importing a file again would bring back deleted notes
+const importCandidatesQuery string = `
+SELECT content,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id)
+FROM notes WHERE created_at >= ? AND created_at < ?
+`
+
+const searchQuery string = `
+SELECT notes.id, notes.content, notes.created_at, notes.created_offset, notes.updated_at,
+(SELECT group_concat(tags.name, ',') FROM note_tags JOIN tags ON tags.id = note_tags.tag_id WHERE note_tags.note_id = notes.id),
+snippet(notes_fts, 0, ?, ?, '...', 16), bm25(notes_fts)
+FROM notes_fts JOIN notes ON notes.id = notes_fts.rowid
+WHERE notes_fts MATCH ? AND notes.deleted_at IS NULL AND notes.created_at >= ? AND notes.created_at <= ?
+ORDER BY bm25(notes_fts) LIMIT ?
+`
+
+// ErrNoteNotFound is for a note that doesn't exist or is in the trash, wrapped in a NotFoundError saying which
+var ErrNoteNotFound = noteerrors.NotFound(errors.New("note does not exist"))
+
+// NotFoundError is the ErrNoteNotFound for a particular note
+type NotFoundError struct {
+	ID int64
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("note %v does not exist", e.ID)
+}
+
+func (e *NotFoundError) Unwrap() error {
+	return ErrNoteNotFound
+}
+
+// ErrNoteNotInTrash is returned when restoring a note that isn't in the trash, and is a noteerrors.ErrConflict
+var ErrNoteNotInTrash = noteerrors.Conflict(errors.New("note is not in the trash"))
+
+// MaxTime is later than any note, for leaving the end of a time window open
+var MaxTime = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
+
 //go:generate mockgen -destination=mock_sql/mock.go -package=mock_sql -source=sqlite.go
 
 type sqliteRepo struct {
@@ -35,8 +178,19 @@ type sqliteRepo struct {
 	clock  clock.Clock
 }
 
+// Config takes the real clock when Clock isn't set
 type Config struct {
-	DB *sql.DB
+	DB    *sql.DB
+	Clock clock.Clock
+}
+
+// realClockIfUnset gives the clock from a repository's config, falling back to the real one
+func realClockIfUnset(c clock.Clock) clock.Clock {
+	if c == nil {
+		return clock.NewClock()
+	}
+
+	return c
 }
 
 func NewRepository(cfg *Config) (Repository, error) {
@@ -46,34 +200,232 @@ func NewRepository(cfg *Config) (Repository, error) {
 
 	newRepo := &sqliteRepo{
 		dbConn: cfg.DB,
-		clock:  clock.NewClock(),
+		clock:  realClockIfUnset(cfg.Clock),
 	}
 
 	return newRepo, nil
 }
 
 func (repo *sqliteRepo) Create(ctx context.Context, note *entities.Note) (*entities.Note, error) {
+	noteTags, err := tags.Normalize(note.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	note.CreatedAt = repo.clock.Now()
 
-	res, err := repo.dbConn.ExecContext(ctx, insertNoteQuery, note.Content, note.CreatedAt)
+	var lastID int64
+
+	err = retryBusy(ctx, func() error {
+		lastID, err = repo.insertNote(ctx, note, noteTags)
+
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	note.ID = lastID
+
+	if len(noteTags) > 0 {
+		note.Tags = noteTags
+	}
+
+	return note, nil
+}
+
+func (repo *sqliteRepo) insertNote(ctx context.Context, note *entities.Note, noteTags []string) (int64, error) {
+	tx, err := repo.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	_, offset := note.CreatedAt.Zone()
+
+	res, err := tx.ExecContext(ctx, insertNoteQuery, note.Content, note.CreatedAt.UTC(), offset)
+	if err != nil {
+		return 0, err
+	}
+
 	lastID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, tag := range noteTags {
+		_, err = tx.ExecContext(ctx, insertTagQuery, tag)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, insertNoteTagQuery, lastID, tag)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return lastID, tx.Commit()
+}
+
+// ForEach calls fn with each note in the time window matching the filter, oldest first, reading them as it goes
+func (repo *sqliteRepo) ForEach(ctx context.Context, startTime time.Time, endTime time.Time, filter *TagFilter,
+	fn func(note *entities.Note) error) error {
+	query := listBetweenQuery
+	args := []interface{}{startTime.UTC(), endTime.UTC()}
+
+	tagsQuery, tagArgs, err := tagFilterQuery(filter)
+	if err != nil {
+		return err
+	}
+
+	if tagsQuery != "" {
+		query = fmt.Sprintf(listTaggedQuery, tagsQuery)
+		args = append(args, tagArgs...)
+	}
+
+	rows, err := repo.dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return err
+		}
+
+		err = fn(note)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// tagFilterQuery gives the query for the IDs of the notes matching the filter, or nothing without any tags
+func tagFilterQuery(filter *TagFilter) (string, []interface{}, error) {
+	if filter == nil || len(filter.Tags) == 0 {
+		return "", nil, nil
+	}
+
+	filterTags, err := tags.Normalize(filter.Tags)
+	if err != nil {
+		return "", nil, err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(filterTags)), ",")
+
+	tagsQuery := fmt.Sprintf(anyTagsQuery, placeholders)
+	if filter.MatchAll {
+		tagsQuery = fmt.Sprintf(allTagsQuery, placeholders, len(filterTags))
+	}
+
+	args := make([]interface{}, 0, len(filterTags))
+	for _, tag := range filterTags {
+		args = append(args, tag)
+	}
+
+	return tagsQuery, args, nil
+}
+
+// List gives a page of the notes, carrying on from the created time and ID of the cursor note
+func (repo *sqliteRepo) List(ctx context.Context, opts *ListOptions) ([]*entities.Note, error) {
+	endTime := opts.EndTime
+	if endTime.IsZero() {
+		endTime = MaxTime
+	}
+
+	args := []interface{}{opts.StartTime.UTC(), endTime.UTC()}
+
+	tagsCondition := ""
+
+	tagsQuery, tagArgs, err := tagFilterQuery(opts.Filter)
 	if err != nil {
 		return nil, err
 	}
 
-	note.ID = lastID
+	if tagsQuery != "" {
+		tagsCondition = " AND id IN (" + tagsQuery + ")"
+		args = append(args, tagArgs...)
+	}
 
-	return note, nil
+	cursorCondition := ""
+
+	if opts.AfterID != 0 {
+		err = repo.checkExists(ctx, opts.AfterID)
+		if err != nil {
+			return nil, err
+		}
+
+		cursorCondition = fmt.Sprintf(" AND (created_at, id) %v (SELECT created_at, id FROM notes WHERE id = ?)",
+			cursorComparison(opts.Reverse))
+		args = append(args, opts.AfterID)
+	}
+
+	// a negative limit is no limit at all to SQLite
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	args = append(args, limit, opts.Offset)
+
+	direction := sortDirection(opts.Reverse)
+	query := fmt.Sprintf(listPageQuery, tagsCondition, cursorCondition, direction, direction)
+
+	rows, err := repo.dbConn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanNotes(rows)
 }
 
-func (repo *sqliteRepo) ListBetween(ctx context.Context, startTime time.Time, endTime time.Time) ([]*entities.Note, error) {
-	retNotes := make([]*entities.Note, 0)
+// checkExists gives a NotFoundError unless there's a note with the ID, in the trash or not
+func (repo *sqliteRepo) checkExists(ctx context.Context, noteID int64) error {
+	var count int64
+
+	err := repo.dbConn.QueryRowContext(ctx, noteExistsQuery, noteID).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return &NotFoundError{ID: noteID}
+	}
+
+	return nil
+}
+
+func sortDirection(reverse bool) string {
+	if reverse {
+		return "DESC"
+	}
 
-	rows, err := repo.dbConn.QueryContext(ctx, listBetweenQuery, startTime, endTime)
+	return "ASC"
+}
+
+// cursorComparison is how the notes after the cursor compare to it, in the order they're listed in
+func cursorComparison(reverse bool) string {
+	if reverse {
+		return "<"
+	}
+
+	return ">"
+}
+
+func (repo *sqliteRepo) ListTags(ctx context.Context) ([]*entities.TagCount, error) {
+	tagCounts := make([]*entities.TagCount, 0)
+
+	rows, err := repo.dbConn.QueryContext(ctx, listTagsQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -81,39 +433,709 @@ func (repo *sqliteRepo) ListBetween(ctx context.Context, startTime time.Time, en
 	defer rows.Close()
 
 	for rows.Next() {
-		var id int64
-		var content string
-		var createdAt time.Time
+		var name string
+		var count int64
 
-		err = rows.Scan(&id, &content, &createdAt)
+		err = rows.Scan(&name, &count)
 		if err != nil {
 			return nil, err
 		}
 
-		retNotes = append(retNotes, &entities.Note{
-			ID:        id,
-			Content:   content,
-			CreatedAt: createdAt,
+		tagCounts = append(tagCounts, &entities.TagCount{
+			Name:  name,
+			Count: count,
 		})
 	}
 
-	return retNotes, nil
+	return tagCounts, rows.Err()
 }
 
-func (repo *sqliteRepo) Delete(ctx context.Context, noteID int64) error {
-	row := repo.dbConn.QueryRowContext(ctx, noteExistsQuery, noteID)
+// ListCreatedTimes gives when the notes in the time window were created, oldest first, in their own offsets
+func (repo *sqliteRepo) ListCreatedTimes(ctx context.Context, startTime time.Time,
+	endTime time.Time) ([]time.Time, error) {
+	rows, err := repo.dbConn.QueryContext(ctx, listCreatedTimesQuery, startTime.UTC(), endTime.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanCreatedTimes(rows)
+}
+
+func scanCreatedTimes(rows *sql.Rows) ([]time.Time, error) {
+	createdTimes := make([]time.Time, 0)
+
+	for rows.Next() {
+		var createdAt time.Time
+		var createdOffset int
+
+		err := rows.Scan(&createdAt, &createdOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		createdTimes = append(createdTimes, createdIn(createdAt, createdOffset))
+	}
 
+	return createdTimes, rows.Err()
+}
+
+func (repo *sqliteRepo) Get(ctx context.Context, noteID int64) (*entities.Note, error) {
+	rows, err := repo.dbConn.QueryContext(ctx, getNoteQuery, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		err = rows.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, &NotFoundError{ID: noteID}
+	}
+
+	return scanNote(rows)
+}
+
+func scanNotes(rows *sql.Rows) ([]*entities.Note, error) {
+	retNotes := make([]*entities.Note, 0)
+
+	for rows.Next() {
+		note, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		retNotes = append(retNotes, note)
+	}
+
+	return retNotes, rows.Err()
+}
+
+func scanNote(rows *sql.Rows) (*entities.Note, error) {
 	var id int64
+	var content string
+	var createdAt time.Time
+	var createdOffset int
+	var updatedAt sql.NullTime
+	var noteTags sql.NullString
+
+	err := rows.Scan(&id, &content, &createdAt, &createdOffset, &updatedAt, &noteTags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.Note{
+		ID:        id,
+		Content:   content,
+		CreatedAt: createdIn(createdAt, createdOffset),
+		UpdatedAt: nullTime(updatedAt),
+		Tags:      splitTags(noteTags),
+	}, nil
+}
+
+// createdIn puts a note's created time, stored in UTC, back in the offset it was created at
+func createdIn(createdAt time.Time, offset int) time.Time {
+	if offset == 0 {
+		return createdAt.UTC()
+	}
+
+	return createdAt.In(time.FixedZone("", offset))
+}
+
+// writtenAt is when the note's current content was written
+func writtenAt(createdAt time.Time, updatedAt sql.NullTime) time.Time {
+	if updatedAt.Valid {
+		return updatedAt.Time
+	}
+
+	return createdAt
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+// splitTags turns the comma separated tags that come back from a query into a sorted slice
+func splitTags(noteTags sql.NullString) []string {
+	if !noteTags.Valid || noteTags.String == "" {
+		return nil
+	}
+
+	split := strings.Split(noteTags.String, ",")
+	sort.Strings(split)
 
-	err := row.Scan(&id)
+	return split
+}
+
+// updatedTags works out a note's tags after an update, keeping the ones that didn't come from old #hashtags
+func updatedTags(oldContent string, oldTags []string, note *entities.Note, opts *UpdateOptions) ([]string, error) {
+	keptTags := tags.Missing(oldContent, oldTags)
+
+	newTags, err := tags.Normalize(append(append(tags.Extract(note.Content), note.Tags...), keptTags...))
+	if err != nil || opts == nil || len(opts.RemoveTags) == 0 {
+		return newTags, err
+	}
+
+	removeTags, err := tags.Normalize(opts.RemoveTags)
 	if err != nil {
-		return errors.New("note does not exist")
+		return nil, err
+	}
+
+	removed := make(map[string]bool, len(removeTags))
+	for _, tag := range removeTags {
+		removed[tag] = true
 	}
 
-	_, err = repo.dbConn.ExecContext(ctx, deleteNoteQuery, noteID)
+	remaining := make([]string, 0, len(newTags))
+
+	for _, tag := range newTags {
+		if !removed[tag] {
+			remaining = append(remaining, tag)
+		}
+	}
+
+	return remaining, nil
+}
+
+// Delete moves a note to the trash, checking it exists in the same statement
+func (repo *sqliteRepo) Delete(ctx context.Context, noteID int64) error {
+	res, err := repo.exec(ctx, deleteNoteQuery, repo.clock.Now().UTC(), noteID)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return &NotFoundError{ID: noteID}
+	}
+
+	return nil
+}
+
+func (repo *sqliteRepo) ListTrash(ctx context.Context) ([]*entities.Note, error) {
+	retNotes := make([]*entities.Note, 0)
+
+	rows, err := repo.dbConn.QueryContext(ctx, listTrashQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var content string
+		var createdAt time.Time
+		var createdOffset int
+		var updatedAt sql.NullTime
+		var noteTags sql.NullString
+		var deletedAt sql.NullTime
+
+		err = rows.Scan(&id, &content, &createdAt, &createdOffset, &updatedAt, &noteTags, &deletedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		retNotes = append(retNotes, &entities.Note{
+			ID:        id,
+			Content:   content,
+			CreatedAt: createdIn(createdAt, createdOffset),
+			UpdatedAt: nullTime(updatedAt),
+			DeletedAt: nullTime(deletedAt),
+			Tags:      splitTags(noteTags),
+		})
+	}
+
+	return retNotes, rows.Err()
+}
+
+func (repo *sqliteRepo) Restore(ctx context.Context, noteID int64) error {
+	res, err := repo.exec(ctx, restoreNoteQuery, noteID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		err = repo.checkExists(ctx, noteID)
+		if err != nil {
+			return err
+		}
+
+		return ErrNoteNotInTrash
+	}
+
+	return nil
+}
+
+// Purge deletes the notes trashed at or before olderThan for good, giving how many there were
+func (repo *sqliteRepo) Purge(ctx context.Context, olderThan time.Time) (int64, error) {
+	res, err := repo.exec(ctx, purgeNotesQuery, olderThan.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Update changes a note, keeping the old content as a revision. New #hashtags replace the old ones, other tags stay,
+// note.Tags get added and opts.RemoveTags taken off.
+func (repo *sqliteRepo) Update(ctx context.Context, note *entities.Note, opts *UpdateOptions) (*entities.Note, error) {
+	var updated *entities.Note
+
+	err := retryBusy(ctx, func() error {
+		var err error
+
+		updated, err = repo.update(ctx, note, opts)
+
+		return err
+	})
+
+	return updated, err
+}
+
+func (repo *sqliteRepo) update(ctx context.Context, note *entities.Note, opts *UpdateOptions) (*entities.Note, error) {
+	tx, err := repo.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, getNoteForUpdateQuery, note.ID)
+
+	var oldContent string
+	var oldCreatedAt time.Time
+	var oldOffset int
+	var oldUpdatedAt sql.NullTime
+	var oldTags sql.NullString
+
+	err = row.Scan(&oldContent, &oldCreatedAt, &oldOffset, &oldUpdatedAt, &oldTags)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &NotFoundError{ID: note.ID}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(note.Content) == "" {
+		note.Content = oldContent
+	}
+
+	newTags, err := updatedTags(oldContent, splitTags(oldTags), note, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt := nullTime(oldUpdatedAt)
+
+	if note.Content != oldContent {
+		now := repo.clock.Now()
+		updatedAt = &now
+
+		_, err = tx.ExecContext(ctx, insertRevisionQuery, note.ID, oldContent,
+			writtenAt(oldCreatedAt, oldUpdatedAt).UTC())
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, updateNoteQuery, note.Content, now.UTC(), note.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, deleteNoteTagsQuery, note.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range newTags {
+		_, err = tx.ExecContext(ctx, insertTagQuery, tag)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, insertNoteTagQuery, note.ID, tag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	note.CreatedAt = createdIn(oldCreatedAt, oldOffset)
+	note.UpdatedAt = updatedAt
+	note.Tags = nil
+
+	if len(newTags) > 0 {
+		note.Tags = newTags
+	}
+
+	return note, nil
+}
+
+// ListRevisions gives every version of a note's content, oldest first, ending with the current one
+func (repo *sqliteRepo) ListRevisions(ctx context.Context, noteID int64) ([]*entities.Revision, error) {
+	row := repo.dbConn.QueryRowContext(ctx, getCurrentRevisionQuery, noteID)
+
+	var content string
+	var createdAt time.Time
+	var updatedAt sql.NullTime
+
+	err := row.Scan(&content, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &NotFoundError{ID: noteID}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	current := &entities.Revision{
+		NoteID:    noteID,
+		Content:   content,
+		CreatedAt: writtenAt(createdAt, updatedAt),
+		Current:   true,
+	}
+
+	rows, err := repo.dbConn.QueryContext(ctx, listRevisionsQuery, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	revisions := make([]*entities.Revision, 0)
+
+	for rows.Next() {
+		err = rows.Scan(&content, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, &entities.Revision{
+			Number:    len(revisions) + 1,
+			NoteID:    noteID,
+			Content:   content,
+			CreatedAt: createdAt,
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	current.Number = len(revisions) + 1
+
+	return append(revisions, current), nil
+}
+
+// Import adds notes with their own times, skipping ones already there with the same content and tags in the same
+// second, as some export formats only keep seconds
+func (repo *sqliteRepo) Import(ctx context.Context, batch []*entities.Note, dryRun bool) (*ImportResult, error) {
+	for i, note := range batch {
+		if strings.TrimSpace(note.Content) == "" {
+			return nil, noteerrors.InvalidInputf("note %v has no content", i+1)
+		}
+
+		if note.CreatedAt.IsZero() {
+			return nil, noteerrors.InvalidInputf("note %v has no creation time", i+1)
+		}
+
+		noteTags, err := tags.Normalize(note.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("note %v: %w", i+1, err)
+		}
+
+		note.Tags = nil
+		if len(noteTags) > 0 {
+			note.Tags = noteTags
+		}
+	}
+
+	var result *ImportResult
+
+	err := retryBusy(ctx, func() error {
+		var err error
+
+		result, err = repo.importBatch(ctx, batch, dryRun)
+
+		return err
+	})
+
+	return result, err
+}
+
+func (repo *sqliteRepo) importBatch(ctx context.Context, batch []*entities.Note, dryRun bool) (*ImportResult, error) {
+	tx, err := repo.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint
+	defer tx.Rollback()
+
+	result := &ImportResult{
+		Imported:   make([]*entities.Note, 0),
+		Duplicates: make([]*entities.Note, 0),
+	}
+
+	// catches the same note appearing more than once in what's being imported
+	seen := make(map[string]bool)
+
+	for _, note := range batch {
+		key := importKey(note.CreatedAt, note.Content, note.Tags)
+
+		duplicate := seen[key]
+		if !duplicate {
+			duplicate, err = repo.importExists(ctx, tx, note, key)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if duplicate {
+			result.Duplicates = append(result.Duplicates, note)
+
+			continue
+		}
+
+		seen[key] = true
+		result.Imported = append(result.Imported, note)
+
+		if dryRun {
+			continue
+		}
+
+		var updatedAt interface{}
+		if note.UpdatedAt != nil {
+			updatedAt = note.UpdatedAt.UTC()
+		}
+
+		_, offset := note.CreatedAt.Zone()
+
+		res, err := tx.ExecContext(ctx, importNoteQuery, note.Content, note.CreatedAt.UTC(), offset, updatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		note.ID, err = res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range note.Tags {
+			_, err = tx.ExecContext(ctx, insertTagQuery, tag)
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = tx.ExecContext(ctx, insertNoteTagQuery, note.ID, tag)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// importExists checks whether a note with the same key was already created within the same second as the note
+func (repo *sqliteRepo) importExists(ctx context.Context, tx *sql.Tx, note *entities.Note, key string) (bool, error) {
+	second := note.CreatedAt.Truncate(time.Second)
+
+	rows, err := tx.QueryContext(ctx, importCandidatesQuery, second.UTC(), second.Add(time.Second).UTC())
+	if err != nil {
+		return false, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var content string
+		var noteTags sql.NullString
+
+		err = rows.Scan(&content, &noteTags)
+		if err != nil {
+			return false, err
+		}
+
+		if importKey(note.CreatedAt, content, splitTags(noteTags)) == key {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// importKey is the second a note was created in and a hash of its content, with its tags appended the way a Markdown
+// export writes them
+func importKey(createdAt time.Time, content string, noteTags []string) string {
+	hash := sha256.Sum256([]byte(tags.AppendMissing(content, noteTags)))
+
+	return fmt.Sprintf("%v:%x", createdAt.Unix(), hash)
+}
+
+func (repo *sqliteRepo) Search(ctx context.Context, query string, opts *SearchOptions) ([]*entities.SearchResult, error) {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+
+	endTime := opts.EndTime
+	if endTime.IsZero() {
+		endTime = MaxTime
+	}
+
+	// a negative limit means no limit at all to SQLite
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	matchQuery, err := buildMatchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*entities.SearchResult, 0)
+
+	rows, err := repo.dbConn.QueryContext(ctx, searchQuery, opts.HighlightStart, opts.HighlightEnd, matchQuery,
+		opts.StartTime.UTC(), endTime.UTC(), limit)
+	if err != nil {
+		return nil, searchError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var content string
+		var createdAt time.Time
+		var createdOffset int
+		var updatedAt sql.NullTime
+		var noteTags sql.NullString
+		var snippet string
+		var rank float64
+
+		err = rows.Scan(&id, &content, &createdAt, &createdOffset, &updatedAt, &noteTags, &snippet, &rank)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, &entities.SearchResult{
+			Note: &entities.Note{
+				ID:        id,
+				Content:   content,
+				CreatedAt: createdIn(createdAt, createdOffset),
+				UpdatedAt: nullTime(updatedAt),
+				Tags:      splitTags(noteTags),
+			},
+			Snippet: snippet,
+			Rank:    rank,
+		})
+	}
+
+	return results, searchError(rows.Err())
+}
+
+// searchError makes FTS5 failing to read a search an invalid input error, as it's the search that's wrong
+func searchError(err error) error {
+	var sqliteErr sqlite3.Error
+
+	if errors.As(err, &sqliteErr) && strings.HasPrefix(sqliteErr.Error(), "fts5: syntax error") {
+		return noteerrors.InvalidInput(err)
+	}
+
+	return err
+}
+
+// busyRetries is how many more times a write is tried when the DB is still locked after the busy timeout
+const busyRetries int = 5
+
+// busyRetryDelay is how long to wait before the first retry, doubling for each one after that
+var busyRetryDelay = 50 * time.Millisecond
+
+// retryBusy runs fn, trying again with backoff for as long as it fails because the DB is locked
+func retryBusy(ctx context.Context, fn func() error) error {
+	delay := busyRetryDelay
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !isBusy(err) {
+			return err
+		}
+
+		if attempt == busyRetries {
+			return noteerrors.Storage(err)
+		}
+
+		// the jitter stops writers that collided once from colliding again on every retry
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+	}
+}
+
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+func (repo *sqliteRepo) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+
+	err := retryBusy(ctx, func() error {
+		var err error
+
+		res, err = repo.dbConn.ExecContext(ctx, query, args...)
+
+		return err
+	})
+
+	return res, err
+}
+
+// Close closes the DB, which checkpoints the WAL into the DB file when it's the last connection to it
+func (repo *sqliteRepo) Close() error {
+	return repo.dbConn.Close()
 }
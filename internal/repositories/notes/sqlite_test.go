@@ -2,15 +2,20 @@ package notes
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"regexp"
 	"testing"
 	"time"
 
 	mock_clock "note-logger/internal/clock/mock"
 	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
+	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -40,6 +45,9 @@ func (s *testSuite) SetupTest() {
 		dbConn: db,
 		clock:  s.mockClock,
 	}
+
+	// keeps the tests that retry from waiting around
+	busyRetryDelay = time.Millisecond
 }
 
 func (s *testSuite) AfterTest(_, _ string) {
@@ -50,7 +58,7 @@ func (s *testSuite) AfterTest(_, _ string) {
 }
 
 func (s *testSuite) TestNotesRepo_Create_Success() {
-	createdAt := time.Unix(1649707678, 0).UTC()
+	createdAt := time.Unix(1649707678, 0).In(time.FixedZone("", 2*60*60))
 
 	newNote := &entities.Note{
 		Content: "This is a new note!",
@@ -64,8 +72,49 @@ func (s *testSuite) TestNotesRepo_Create_Success() {
 
 	s.mockClock.EXPECT().Now().Return(createdAt)
 
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(insertNoteQuery)).
+		WithArgs(expectedNote.Content, createdAt.UTC(), 2*60*60).WillReturnResult(sqlmock.NewResult(5, 1))
+
+	s.mockDB.ExpectCommit()
+
+	res, err := s.repoFixture.Create(s.ctx, newNote)
+
+	assert.Equal(s.T(), expectedNote, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_Create_WithTags() {
+	createdAt := time.Unix(1649707678, 0).UTC()
+
+	newNote := &entities.Note{
+		Content: "Fixed the build #work",
+		Tags:    []string{"work", "CI", "#work"},
+	}
+
+	expectedNote := &entities.Note{
+		ID:        5,
+		Content:   "Fixed the build #work",
+		CreatedAt: createdAt,
+		Tags:      []string{"ci", "work"},
+	}
+
+	s.mockClock.EXPECT().Now().Return(createdAt)
+
+	s.mockDB.ExpectBegin()
+
 	s.mockDB.ExpectExec(regexp.QuoteMeta(insertNoteQuery)).
-		WithArgs(expectedNote.Content, createdAt).WillReturnResult(sqlmock.NewResult(5, 1))
+		WithArgs(expectedNote.Content, createdAt, 0).WillReturnResult(sqlmock.NewResult(5, 1))
+
+	for _, tag := range expectedNote.Tags {
+		s.mockDB.ExpectExec(regexp.QuoteMeta(insertTagQuery)).WithArgs(tag).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		s.mockDB.ExpectExec(regexp.QuoteMeta(insertNoteTagQuery)).
+			WithArgs(int64(5), tag).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	s.mockDB.ExpectCommit()
 
 	res, err := s.repoFixture.Create(s.ctx, newNote)
 
@@ -73,7 +122,76 @@ func (s *testSuite) TestNotesRepo_Create_Success() {
 	assert.NoError(s.T(), err)
 }
 
-func (s *testSuite) TestNotesRepo_ListBetween_Success() {
+func (s *testSuite) TestNotesRepo_Create_RetriesWhenBusy() {
+	createdAt := time.Unix(1649707678, 0).UTC()
+
+	s.mockClock.EXPECT().Now().Return(createdAt)
+
+	s.mockDB.ExpectBegin().WillReturnError(sqlite3.Error{Code: sqlite3.ErrBusy})
+
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(insertNoteQuery)).
+		WithArgs("This is a new note!", createdAt, 0).WillReturnError(sqlite3.Error{Code: sqlite3.ErrLocked})
+
+	s.mockDB.ExpectRollback()
+
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(insertNoteQuery)).
+		WithArgs("This is a new note!", createdAt, 0).WillReturnResult(sqlmock.NewResult(5, 1))
+
+	s.mockDB.ExpectCommit()
+
+	res, err := s.repoFixture.Create(s.ctx, &entities.Note{Content: "This is a new note!"})
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(5), res.ID)
+}
+
+func (s *testSuite) TestNotesRepo_Create_InvalidTag() {
+	res, err := s.repoFixture.Create(s.ctx, &entities.Note{
+		Content: "Some note",
+		Tags:    []string{"not valid"},
+	})
+
+	assert.Nil(s.T(), res)
+	assert.Error(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_Get_Success() {
+	createdAt := time.Unix(1649707678, 0).UTC()
+
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow(5, "Fixed the build #work", createdAt, 0, nil, "work,ci")
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(getNoteQuery)).WithArgs(int64(5)).WillReturnRows(rows)
+
+	res, err := s.repoFixture.Get(s.ctx, 5)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), &entities.Note{
+		ID:        5,
+		Content:   "Fixed the build #work",
+		CreatedAt: createdAt,
+		Tags:      []string{"ci", "work"},
+	}, res)
+}
+
+func (s *testSuite) TestNotesRepo_Get_NotFound() {
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"})
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(getNoteQuery)).WithArgs(int64(5)).WillReturnRows(rows)
+
+	res, err := s.repoFixture.Get(s.ctx, 5)
+
+	assert.Nil(s.T(), res)
+	assert.ErrorIs(s.T(), err, ErrNoteNotFound)
+}
+
+func (s *testSuite) TestNotesRepo_List_Success() {
+	updatedAt := time.Unix(1649719678, 0).UTC()
+
 	expectedNotes := []*entities.Note{
 		{
 			ID:        1,
@@ -84,6 +202,8 @@ func (s *testSuite) TestNotesRepo_ListBetween_Success() {
 			ID:        2,
 			Content:   "Some second note!",
 			CreatedAt: time.Unix(1649717678, 0).UTC(),
+			UpdatedAt: &updatedAt,
+			Tags:      []string{"ci", "work"},
 		},
 		{
 			ID:        3,
@@ -92,34 +212,492 @@ func (s *testSuite) TestNotesRepo_ListBetween_Success() {
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "content", "created_at"}).
-		AddRow(expectedNotes[0].ID, expectedNotes[0].Content, expectedNotes[0].CreatedAt).
-		AddRow(expectedNotes[1].ID, expectedNotes[1].Content, expectedNotes[1].CreatedAt).
-		AddRow(expectedNotes[2].ID, expectedNotes[2].Content, expectedNotes[2].CreatedAt)
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow(expectedNotes[0].ID, expectedNotes[0].Content, expectedNotes[0].CreatedAt, 0, nil, nil).
+		AddRow(expectedNotes[1].ID, expectedNotes[1].Content, expectedNotes[1].CreatedAt, 0, *expectedNotes[1].UpdatedAt, "work,ci").
+		AddRow(expectedNotes[2].ID, expectedNotes[2].Content, expectedNotes[2].CreatedAt, 0, nil, nil)
 
 	startTime := time.Unix(1649707678, 0).UTC()
 	endTime := time.Unix(1649807678, 0).UTC()
 
-	s.mockDB.ExpectQuery(regexp.QuoteMeta(listBetweenQuery)).WithArgs(startTime, endTime).WillReturnRows(rows)
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(listPageQuery, "", "", "ASC", "ASC"))).
+		WithArgs(startTime, endTime, -1, 0).WillReturnRows(rows)
 
-	res, err := s.repoFixture.ListBetween(s.ctx, startTime, endTime)
+	res, err := s.repoFixture.List(s.ctx, &ListOptions{StartTime: startTime, EndTime: endTime})
 
 	assert.Equal(s.T(), expectedNotes, res)
 	assert.NoError(s.T(), err)
 }
 
+func (s *testSuite) TestNotesRepo_ForEach_AnyOf() {
+	expectedNotes := []*entities.Note{
+		{
+			ID:        2,
+			Content:   "Some tagged note!",
+			CreatedAt: time.Unix(1649717678, 0).UTC(),
+			Tags:      []string{"work"},
+		},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow(expectedNotes[0].ID, expectedNotes[0].Content, expectedNotes[0].CreatedAt, 0, nil, "work")
+
+	startTime := time.Unix(1649707678, 0).UTC()
+	endTime := time.Unix(1649807678, 0).UTC()
+
+	expectedQuery := fmt.Sprintf(listTaggedQuery, fmt.Sprintf(anyTagsQuery, "?,?"))
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+		WithArgs(startTime, endTime, "home", "work").WillReturnRows(rows)
+
+	res := make([]*entities.Note, 0)
+
+	err := s.repoFixture.ForEach(s.ctx, startTime, endTime, &TagFilter{Tags: []string{"work", "#Home"}},
+		func(note *entities.Note) error {
+			res = append(res, note)
+
+			return nil
+		})
+
+	assert.Equal(s.T(), expectedNotes, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_ForEach_AllOf() {
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"})
+
+	startTime := time.Unix(1649707678, 0).UTC()
+	endTime := time.Unix(1649807678, 0).UTC()
+
+	expectedQuery := fmt.Sprintf(listTaggedQuery, fmt.Sprintf(allTagsQuery, "?,?", 2))
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
+		WithArgs(startTime, endTime, "home", "work").WillReturnRows(rows)
+
+	calls := 0
+
+	err := s.repoFixture.ForEach(s.ctx, startTime, endTime, &TagFilter{Tags: []string{"work", "home"}, MatchAll: true},
+		func(note *entities.Note) error {
+			calls++
+
+			return nil
+		})
+
+	assert.Equal(s.T(), 0, calls)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_ForEach_Success() {
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow(1, "Some first note!", time.Unix(1649707678, 0).UTC(), 0, nil, nil).
+		AddRow(2, "Some second note!", time.Unix(1649717678, 0).UTC(), 0, nil, "work")
+
+	startTime := time.Unix(1649707678, 0).UTC()
+	endTime := time.Unix(1649807678, 0).UTC()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(listBetweenQuery)).WithArgs(startTime, endTime).WillReturnRows(rows)
+
+	seen := make([]int64, 0)
+
+	err := s.repoFixture.ForEach(s.ctx, startTime, endTime, nil, func(note *entities.Note) error {
+		seen = append(seen, note.ID)
+
+		return nil
+	})
+
+	assert.Equal(s.T(), []int64{1, 2}, seen)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_ForEach_StopsOnError() {
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow(1, "Some first note!", time.Unix(1649707678, 0).UTC(), 0, nil, nil).
+		AddRow(2, "Some second note!", time.Unix(1649717678, 0).UTC(), 0, nil, nil)
+
+	startTime := time.Unix(1649707678, 0).UTC()
+	endTime := time.Unix(1649807678, 0).UTC()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(listBetweenQuery)).WithArgs(startTime, endTime).WillReturnRows(rows)
+
+	calls := 0
+
+	err := s.repoFixture.ForEach(s.ctx, startTime, endTime, nil, func(note *entities.Note) error {
+		calls++
+
+		return errors.New("disk full")
+	})
+
+	assert.Equal(s.T(), 1, calls)
+	assert.Equal(s.T(), errors.New("disk full"), err)
+}
+
+func (s *testSuite) TestNotesRepo_ListTags_Success() {
+	expectedTags := []*entities.TagCount{
+		{Name: "work", Count: 12},
+		{Name: "home", Count: 3},
+	}
+
+	rows := sqlmock.NewRows([]string{"name", "count"}).
+		AddRow(expectedTags[0].Name, expectedTags[0].Count).
+		AddRow(expectedTags[1].Name, expectedTags[1].Count)
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(listTagsQuery)).WillReturnRows(rows)
+
+	res, err := s.repoFixture.ListTags(s.ctx)
+
+	assert.Equal(s.T(), expectedTags, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_ListCreatedTimes_Success() {
+	startTime := time.Date(2022, time.April, 12, 0, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(24 * time.Hour)
+
+	rows := sqlmock.NewRows([]string{"created_at", "created_offset"}).
+		AddRow(startTime.Add(time.Hour), 0).
+		AddRow(startTime.Add(2*time.Hour), 2*60*60)
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(listCreatedTimesQuery)).WithArgs(startTime, endTime).WillReturnRows(rows)
+
+	res, err := s.repoFixture.ListCreatedTimes(s.ctx, startTime.In(time.FixedZone("", 60*60)), endTime)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []time.Time{
+		startTime.Add(time.Hour),
+		startTime.Add(2 * time.Hour).In(time.FixedZone("", 2*60*60)),
+	}, res)
+}
+
+func (s *testSuite) TestNotesRepo_Update_Success() {
+	createdAt := time.Unix(1649707678, 0).UTC()
+	updatedAt := time.Unix(1649717678, 0).UTC()
+
+	rows := sqlmock.NewRows([]string{"content", "created_at", "created_offset", "updated_at", "tags"}).
+		AddRow("Fixed teh build #work", createdAt, 2*60*60, nil, "ci,work")
+
+	s.mockClock.EXPECT().Now().Return(updatedAt)
+
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(getNoteForUpdateQuery)).WithArgs(int64(5)).WillReturnRows(rows)
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(insertRevisionQuery)).
+		WithArgs(int64(5), "Fixed teh build #work", createdAt).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(updateNoteQuery)).
+		WithArgs("Fixed the build #home", updatedAt, int64(5)).WillReturnResult(sqlmock.NewResult(5, 1))
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(deleteNoteTagsQuery)).WithArgs(int64(5)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// the work hashtag is gone from the content, but ci was added explicitly so it stays
+	for _, tag := range []string{"ci", "home", "urgent"} {
+		s.mockDB.ExpectExec(regexp.QuoteMeta(insertTagQuery)).WithArgs(tag).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		s.mockDB.ExpectExec(regexp.QuoteMeta(insertNoteTagQuery)).
+			WithArgs(int64(5), tag).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+
+	s.mockDB.ExpectCommit()
+
+	res, err := s.repoFixture.Update(s.ctx, &entities.Note{
+		ID:      5,
+		Content: "Fixed the build #home",
+		Tags:    []string{"urgent"},
+	}, nil)
+
+	assert.Equal(s.T(), &entities.Note{
+		ID:        5,
+		Content:   "Fixed the build #home",
+		CreatedAt: createdAt.In(time.FixedZone("", 2*60*60)),
+		UpdatedAt: &updatedAt,
+		Tags:      []string{"ci", "home", "urgent"},
+	}, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_Update_NotFound() {
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(getNoteForUpdateQuery)).WithArgs(int64(5)).WillReturnError(sql.ErrNoRows)
+
+	s.mockDB.ExpectRollback()
+
+	res, err := s.repoFixture.Update(s.ctx, &entities.Note{ID: 5, Content: "Some note"}, nil)
+
+	assert.Nil(s.T(), res)
+	assert.Equal(s.T(), &NotFoundError{ID: 5}, err)
+	assert.EqualError(s.T(), err, "note 5 does not exist")
+}
+
+func (s *testSuite) TestNotesRepo_ListRevisions_Success() {
+	expectedRevisions := []*entities.Revision{
+		{Number: 1, NoteID: 5, Content: "Fixed teh build", CreatedAt: time.Unix(1649707678, 0).UTC()},
+		{Number: 2, NoteID: 5, Content: "Fixed the build", CreatedAt: time.Unix(1649717678, 0).UTC()},
+		{Number: 3, NoteID: 5, Content: "Fixed the flaky build", CreatedAt: time.Unix(1649727678, 0).UTC(), Current: true},
+	}
+
+	currentRows := sqlmock.NewRows([]string{"content", "created_at", "updated_at"}).
+		AddRow(expectedRevisions[2].Content, expectedRevisions[0].CreatedAt, expectedRevisions[2].CreatedAt)
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(getCurrentRevisionQuery)).WithArgs(int64(5)).WillReturnRows(currentRows)
+
+	rows := sqlmock.NewRows([]string{"content", "created_at"}).
+		AddRow(expectedRevisions[0].Content, expectedRevisions[0].CreatedAt).
+		AddRow(expectedRevisions[1].Content, expectedRevisions[1].CreatedAt)
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(listRevisionsQuery)).WithArgs(int64(5)).WillReturnRows(rows)
+
+	res, err := s.repoFixture.ListRevisions(s.ctx, 5)
+
+	assert.Equal(s.T(), expectedRevisions, res)
+	assert.NoError(s.T(), err)
+}
+
 func (s *testSuite) TestNotesRepo_Delete_Success() {
-	rows := sqlmock.NewRows([]string{"id"}).AddRow(100)
+	deletedAt := time.Unix(1649707678, 0).UTC()
 
-	s.mockDB.ExpectQuery(regexp.QuoteMeta(noteExistsQuery)).WithArgs(int64(100)).WillReturnRows(rows)
+	s.mockClock.EXPECT().Now().Return(deletedAt)
 
-	s.mockDB.ExpectExec(regexp.QuoteMeta(deleteNoteQuery)).WithArgs(int64(100)).WillReturnResult(sqlmock.NewResult(100, 1))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(deleteNoteQuery)).
+		WithArgs(deletedAt, int64(100)).WillReturnResult(sqlmock.NewResult(100, 1))
 
 	err := s.repoFixture.Delete(s.ctx, int64(100))
 
 	assert.NoError(s.T(), err)
 }
 
+func (s *testSuite) TestNotesRepo_Delete_NotFound() {
+	deletedAt := time.Unix(1649707678, 0).UTC()
+
+	s.mockClock.EXPECT().Now().Return(deletedAt)
+
+	// a note that's missing or already in the trash doesn't get updated
+	s.mockDB.ExpectExec(regexp.QuoteMeta(deleteNoteQuery)).
+		WithArgs(deletedAt, int64(100)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := s.repoFixture.Delete(s.ctx, int64(100))
+
+	assert.Equal(s.T(), &NotFoundError{ID: 100}, err)
+	assert.ErrorIs(s.T(), err, ErrNoteNotFound)
+}
+
+func (s *testSuite) TestNotesRepo_ListTrash_Success() {
+	deletedAt := time.Unix(1649727678, 0).UTC()
+
+	expectedNotes := []*entities.Note{
+		{
+			ID:        1,
+			Content:   "Some deleted note!",
+			CreatedAt: time.Unix(1649707678, 0).UTC(),
+			DeletedAt: &deletedAt,
+			Tags:      []string{"work"},
+		},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags", "deleted_at"}).
+		AddRow(expectedNotes[0].ID, expectedNotes[0].Content, expectedNotes[0].CreatedAt, 0, nil, "work", deletedAt)
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(listTrashQuery)).WillReturnRows(rows)
+
+	res, err := s.repoFixture.ListTrash(s.ctx)
+
+	assert.Equal(s.T(), expectedNotes, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_Restore_Success() {
+	s.mockDB.ExpectExec(regexp.QuoteMeta(restoreNoteQuery)).WithArgs(int64(100)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := s.repoFixture.Restore(s.ctx, int64(100))
+
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_Restore_NotInTrash() {
+	s.mockDB.ExpectExec(regexp.QuoteMeta(restoreNoteQuery)).WithArgs(int64(100)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(noteExistsQuery)).WithArgs(int64(100)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	err := s.repoFixture.Restore(s.ctx, int64(100))
+
+	assert.EqualError(s.T(), err, "note is not in the trash")
+}
+
+func (s *testSuite) TestNotesRepo_Restore_NotFound() {
+	s.mockDB.ExpectExec(regexp.QuoteMeta(restoreNoteQuery)).WithArgs(int64(100)).WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(noteExistsQuery)).WithArgs(int64(100)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	err := s.repoFixture.Restore(s.ctx, int64(100))
+
+	assert.ErrorIs(s.T(), err, ErrNoteNotFound)
+}
+
+func (s *testSuite) TestNotesRepo_Purge_Success() {
+	olderThan := time.Unix(1649707678, 0).UTC()
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(purgeNotesQuery)).WithArgs(olderThan).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := s.repoFixture.Purge(s.ctx, olderThan)
+
+	assert.Equal(s.T(), int64(3), purged)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_Purge_GivesUpWhenStillBusy() {
+	olderThan := time.Unix(1649707678, 0).UTC()
+
+	for i := 0; i <= busyRetries; i++ {
+		s.mockDB.ExpectExec(regexp.QuoteMeta(purgeNotesQuery)).WithArgs(olderThan).
+			WillReturnError(sqlite3.Error{Code: sqlite3.ErrBusy})
+	}
+
+	purged, err := s.repoFixture.Purge(s.ctx, olderThan)
+
+	assert.Equal(s.T(), int64(0), purged)
+	assert.True(s.T(), errors.Is(err, noteerrors.ErrStorage))
+	assert.True(s.T(), isBusy(err))
+}
+
+func (s *testSuite) TestNotesRepo_Search_Success() {
+	expectedResults := []*entities.SearchResult{
+		{
+			Note: &entities.Note{
+				ID:        2,
+				Content:   "Deployed the new search feature",
+				CreatedAt: time.Unix(1649717678, 0).UTC(),
+			},
+			Snippet: "Deployed the new [search] feature",
+			Rank:    -1.5,
+		},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "content", "created_at", "created_offset", "updated_at", "tags", "snippet", "rank"}).
+		AddRow(expectedResults[0].Note.ID, expectedResults[0].Note.Content, expectedResults[0].Note.CreatedAt, 0, nil, nil,
+			expectedResults[0].Snippet, expectedResults[0].Rank)
+
+	startTime := time.Unix(1649707678, 0).UTC()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(searchQuery)).
+		WithArgs("[", "]", `"search"`, startTime, MaxTime, 10).WillReturnRows(rows)
+
+	res, err := s.repoFixture.Search(s.ctx, "search", &SearchOptions{
+		StartTime:      startTime,
+		Limit:          10,
+		HighlightStart: "[",
+		HighlightEnd:   "]",
+	})
+
+	assert.Equal(s.T(), expectedResults, res)
+	assert.NoError(s.T(), err)
+}
+
+func (s *testSuite) TestNotesRepo_Import_Success() {
+	zone := time.FixedZone("", 2*60*60)
+	createdAt := time.Unix(1649707678, 0).UTC()
+	updatedAt := time.Unix(1649707999, 0).In(zone)
+
+	batch := []*entities.Note{
+		{Content: "Fixed the build", CreatedAt: createdAt.In(zone), UpdatedAt: &updatedAt, Tags: []string{"Work"}},
+		{Content: "Already there #work", CreatedAt: createdAt.Add(time.Minute)},
+		{Content: "Fixed the build", CreatedAt: createdAt.Add(500 * time.Millisecond), Tags: []string{"work"}},
+	}
+
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(importCandidatesQuery)).WithArgs(createdAt, createdAt.Add(time.Second)).
+		WillReturnRows(sqlmock.NewRows([]string{"content", "tags"}).AddRow("Fixed the build", nil))
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(importNoteQuery)).
+		WithArgs("Fixed the build", createdAt, 2*60*60, updatedAt.UTC()).WillReturnResult(sqlmock.NewResult(7, 1))
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(insertTagQuery)).WithArgs("work").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	s.mockDB.ExpectExec(regexp.QuoteMeta(insertNoteTagQuery)).
+		WithArgs(int64(7), "work").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(importCandidatesQuery)).
+		WithArgs(createdAt.Add(time.Minute), createdAt.Add(time.Minute+time.Second)).
+		WillReturnRows(sqlmock.NewRows([]string{"content", "tags"}).AddRow("Already there", "work"))
+
+	s.mockDB.ExpectCommit()
+
+	res, err := s.repoFixture.Import(s.ctx, batch, false)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []*entities.Note{batch[0]}, res.Imported)
+	assert.Equal(s.T(), []*entities.Note{batch[1], batch[2]}, res.Duplicates)
+	assert.Equal(s.T(), int64(7), batch[0].ID)
+	assert.Equal(s.T(), []string{"work"}, batch[0].Tags)
+}
+
+func (s *testSuite) TestNotesRepo_Import_DryRun() {
+	createdAt := time.Unix(1649707678, 0).In(time.Local)
+
+	batch := []*entities.Note{
+		{Content: "Fixed the build", CreatedAt: createdAt},
+	}
+
+	s.mockDB.ExpectBegin()
+
+	s.mockDB.ExpectQuery(regexp.QuoteMeta(importCandidatesQuery)).
+		WithArgs(createdAt.UTC(), createdAt.Add(time.Second).UTC()).
+		WillReturnRows(sqlmock.NewRows([]string{"content", "tags"}))
+
+	s.mockDB.ExpectRollback()
+
+	res, err := s.repoFixture.Import(s.ctx, batch, true)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), batch, res.Imported)
+	assert.Empty(s.T(), res.Duplicates)
+	assert.Equal(s.T(), int64(0), batch[0].ID)
+}
+
+func (s *testSuite) TestNotesRepo_Import_Invalid() {
+	res, err := s.repoFixture.Import(s.ctx, []*entities.Note{
+		{Content: "Fine", CreatedAt: time.Unix(1649707678, 0)},
+		{Content: "  ", CreatedAt: time.Unix(1649707678, 0)},
+	}, false)
+
+	assert.Nil(s.T(), res)
+	assert.EqualError(s.T(), err, "note 2 has no content")
+
+	res, err = s.repoFixture.Import(s.ctx, []*entities.Note{{Content: "No time"}}, false)
+
+	assert.Nil(s.T(), res)
+	assert.EqualError(s.T(), err, "note 1 has no creation time")
+}
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "deploy", expected: `"deploy"`},
+		{query: "  deploy   server ", expected: `"deploy" "server"`},
+		{query: "deploy*", expected: `"deploy"*`},
+		{query: `"new search feature"`, expected: `"new search feature"`},
+		{query: `"new search"* feature`, expected: `"new search"* "feature"`},
+		{query: "deploy OR release NOT staging", expected: `"deploy" OR "release" NOT "staging"`},
+		{query: "can't stop-gap", expected: `"can't" "stop-gap"`},
+		{query: `say "hi`, expected: `"say" "hi"`},
+		{query: `""`, expected: ``},
+		{query: "deploy OR", expected: `"deploy"`},
+		{query: "OR deploy AND NOT staging", expected: `"deploy" NOT "staging"`},
+		{query: "deploy OR OR release NOT", expected: `"deploy" OR "release"`},
+	}
+
+	for _, test := range tests {
+		matchQuery, err := buildMatchQuery(test.query)
+		assert.NoError(t, err, test.query)
+		assert.Equal(t, test.expected, matchQuery, test.query)
+	}
+
+	_, err := buildMatchQuery("NOT staging")
+	assert.ErrorIs(t, err, noteerrors.ErrInvalidInput)
+}
+
 func TestSuites(t *testing.T) {
 	suite.Run(t, new(testSuite))
 }
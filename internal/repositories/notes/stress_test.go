@@ -0,0 +1,183 @@
+package notes_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/entities"
+	"note-logger/internal/repositories/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stressDBEnvVar tells the test binary, when run as one of the stress test's processes, which DB to write notes to
+const stressDBEnvVar string = "NOTE_LOGGER_STRESS_DB"
+
+const stressWriters int = 8
+const stressNotesPerWriter int = 25
+
+func openStressRepo(filename string) (notes.Repository, func() error, error) {
+	db, err := sqlite.New(context.Background(), &sqlite.Config{Filename: filename})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo, err := notes.NewRepository(&notes.Config{DB: db})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repo, db.Close, nil
+}
+
+// createStressNotes writes notes one at a time, each with a tag of its own and one every writer shares
+func createStressNotes(repo notes.Repository, writer string) error {
+	for i := 0; i < stressNotesPerWriter; i++ {
+		_, err := repo.Create(context.Background(), &entities.Note{
+			Content: fmt.Sprintf("note %v from writer %v", i, writer),
+			Tags:    []string{"stress", "writer-" + writer},
+		})
+		if err != nil {
+			return fmt.Errorf("writer %v, note %v: %w", writer, i, err)
+		}
+	}
+
+	return nil
+}
+
+// checkStressNotes makes sure every note written made it in once, with its tags
+func checkStressNotes(t *testing.T, filename string, writers []string) {
+	repo, closeDB, err := openStressRepo(filename)
+	require.NoError(t, err)
+
+	//nolint
+	defer closeDB()
+
+	tagCounts, err := repo.ListTags(context.Background())
+	require.NoError(t, err)
+
+	counts := make(map[string]int)
+	for _, tagCount := range tagCounts {
+		counts[tagCount.Name] = int(tagCount.Count)
+	}
+
+	assert.Equal(t, len(writers)*stressNotesPerWriter, counts["stress"])
+
+	for _, writer := range writers {
+		assert.Equal(t, stressNotesPerWriter, counts["writer-"+writer], writer)
+	}
+
+	allNotes, err := repo.List(context.Background(), &notes.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, len(writers)*stressNotesPerWriter, len(allNotes))
+}
+
+func TestSQLite_Stress(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the stress test takes a while")
+	}
+
+	t.Run("many goroutines", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		repo, closeDB, err := openStressRepo(filename)
+		require.NoError(t, err)
+
+		//nolint
+		defer closeDB()
+
+		var wg sync.WaitGroup
+
+		errs := make(chan error, stressWriters)
+		writers := make([]string, 0, stressWriters)
+
+		for i := 0; i < stressWriters; i++ {
+			writer := strconv.Itoa(i)
+			writers = append(writers, writer)
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				errs <- createStressNotes(repo, writer)
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		checkStressNotes(t, filename, writers)
+	})
+
+	t.Run("many processes", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "notes.sqlite")
+
+		// every process starts on a DB that hasn't been created yet, so they all race to migrate it too
+		commands := make([]*exec.Cmd, 0, stressWriters)
+		writers := make([]string, 0, stressWriters)
+
+		for i := 0; i < stressWriters; i++ {
+			writer := strconv.Itoa(i)
+			writers = append(writers, writer)
+
+			command := exec.Command(os.Args[0], "-test.run=^TestSQLite_StressProcess$", "-test.count=1")
+			command.Env = append(os.Environ(), stressDBEnvVar+"="+filename, "NOTE_LOGGER_STRESS_WRITER="+writer)
+
+			commands = append(commands, command)
+		}
+
+		outputs := make([][]byte, len(commands))
+		errs := make([]error, len(commands))
+
+		var wg sync.WaitGroup
+
+		for i, command := range commands {
+			i, command := i, command
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				outputs[i], errs[i] = command.CombinedOutput()
+			}()
+		}
+
+		wg.Wait()
+
+		for i, err := range errs {
+			assert.NoError(t, err, string(outputs[i]))
+		}
+
+		checkStressNotes(t, filename, writers)
+	})
+}
+
+// TestSQLite_StressProcess is one of the processes started by the stress test, and does nothing when run on its own
+func TestSQLite_StressProcess(t *testing.T) {
+	filename := os.Getenv(stressDBEnvVar)
+	if filename == "" {
+		t.Skip("only runs as part of TestSQLite_Stress")
+	}
+
+	repo, closeDB, err := openStressRepo(filename)
+	require.NoError(t, err)
+
+	//nolint
+	defer closeDB()
+
+	require.NoError(t, createStressNotes(repo, os.Getenv("NOTE_LOGGER_STRESS_WRITER")))
+}
@@ -0,0 +1,387 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"note-logger/internal/clock"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/repositories/notes"
+	"note-logger/internal/tags"
+	"note-logger/internal/timerange"
+)
+
+// OpenAPI describes the API, and gets served without needing the token
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// maxBodySize is far more than any note needs, but keeps a bad client from sending an endless body
+const maxBodySize int64 = 1 << 20
+
+// defaultPageSize and maxPageSize are how many notes GET /notes lists without a limit, and the most it lists with one
+const defaultPageSize int = 100
+const maxPageSize int = 1000
+
+type server struct {
+	repo  notes.Repository
+	token string
+	clock clock.Clock
+}
+
+// Config takes the real clock when Clock isn't set
+type Config struct {
+	Repo  notes.Repository
+	Token string
+	Clock clock.Clock
+}
+
+// NewHandler creates the handler for the REST API, which checks for the token as a bearer token on every request
+func NewHandler(cfg *Config) (http.Handler, error) {
+	if cfg.Repo == nil {
+		return nil, errors.New("missing Repo parameter")
+	}
+
+	if cfg.Token == "" {
+		return nil, errors.New("missing Token parameter")
+	}
+
+	newServer := &server{
+		repo:  cfg.Repo,
+		token: cfg.Token,
+		clock: cfg.Clock,
+	}
+
+	if newServer.clock == nil {
+		newServer.clock = clock.NewClock()
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/openapi.yaml", newServer.handleOpenAPI)
+	mux.Handle("/notes", newServer.authenticate(http.HandlerFunc(newServer.handleNotes)))
+	mux.Handle("/notes/", newServer.authenticate(http.HandlerFunc(newServer.handleNote)))
+
+	return mux, nil
+}
+
+// errorResponse is the body of every response for a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// noteRequest is the body for creating or updating a note
+type noteRequest struct {
+	Content    string   `json:"content"`
+	Tags       []string `json:"tags"`
+	RemoveTags []string `json:"remove_tags"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	//nolint
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+// writeRepoError turns an error from the repository into a response by its kind
+func writeRepoError(w http.ResponseWriter, err error) {
+	switch noteerrors.KindOf(err) {
+	case noteerrors.ErrNotFound:
+		writeError(w, http.StatusNotFound, err)
+	case noteerrors.ErrInvalidInput:
+		writeError(w, http.StatusBadRequest, err)
+	case noteerrors.ErrConflict:
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+}
+
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, bearer := cutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !bearer || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func cutPrefix(value string, prefix string) (string, bool) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, false
+	}
+
+	return value[len(prefix):], true
+}
+
+func (s *server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+
+	//nolint
+	w.Write(OpenAPI)
+}
+
+func (s *server) handleNotes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.listNotes(w, r)
+	case http.MethodPost:
+		s.createNote(w, r)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (s *server) handleNote(w http.ResponseWriter, r *http.Request) {
+	noteID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/notes/"), 10, 64)
+	if err != nil || noteID <= 0 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getNote(w, r, noteID)
+	case http.MethodPatch:
+		s.updateNote(w, r, noteID)
+	case http.MethodDelete:
+		s.deleteNote(w, r, noteID)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+// parseTime interprets the same English-friendly times as the command line, or gives the fallback for none
+func (s *server) parseTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+
+	return timerange.ParseTime(value, s.clock.Now())
+}
+
+func (s *server) listNotes(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	startTime, err := s.parseTime(query.Get("start"), time.Time{})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid start: %w", err))
+		return
+	}
+
+	endTime, err := s.parseTime(query.Get("end"), notes.MaxTime)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid end: %w", err))
+		return
+	}
+
+	allTags, _ := strconv.ParseBool(query.Get("all_tags"))
+	reverse, _ := strconv.ParseBool(query.Get("reverse"))
+
+	limit := defaultPageSize
+
+	if query.Get("limit") != "" {
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 || limit > maxPageSize {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit, it has to be from 1 to %v", maxPageSize))
+			return
+		}
+	}
+
+	var afterID int64
+
+	if query.Get("after_id") != "" {
+		afterID, err = strconv.ParseInt(query.Get("after_id"), 10, 64)
+		if err != nil || afterID < 1 {
+			writeError(w, http.StatusBadRequest, errors.New("invalid after_id, it has to be a note ID"))
+			return
+		}
+	}
+
+	// one more note than the page holds says whether there's another page after it
+	notesRes, err := s.repo.List(r.Context(), &notes.ListOptions{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Filter: &notes.TagFilter{
+			Tags:     query["tag"],
+			MatchAll: allTags,
+		},
+		Reverse: reverse,
+		AfterID: afterID,
+		Limit:   limit + 1,
+	})
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	if len(notesRes) > limit {
+		notesRes = notesRes[:limit]
+
+		query.Set("after_id", strconv.FormatInt(notesRes[limit-1].ID, 10))
+		w.Header().Set("Link", fmt.Sprintf(`<%v?%v>; rel="next"`, r.URL.Path, query.Encode()))
+	}
+
+	writeJSON(w, http.StatusOK, notesRes)
+}
+
+// readNoteRequest decodes the body, checking the tags up front as bad ones are the client's fault
+func readNoteRequest(w http.ResponseWriter, r *http.Request) (*noteRequest, error) {
+	req := &noteRequest{}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(req)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	_, err = tags.Normalize(append(req.Tags, req.RemoveTags...))
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (s *server) createNote(w http.ResponseWriter, r *http.Request) {
+	req, err := readNoteRequest(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if strings.TrimSpace(req.Content) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("note content required"))
+		return
+	}
+
+	if len(req.RemoveTags) > 0 {
+		writeError(w, http.StatusBadRequest, errors.New("remove_tags can only be given when updating a note"))
+		return
+	}
+
+	// the same as add-note, the #hashtags in the content become tags too
+	note, err := s.repo.Create(r.Context(), &entities.Note{
+		Content: req.Content,
+		Tags:    append(tags.Extract(req.Content), req.Tags...),
+	})
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/notes/%v", note.ID))
+	writeJSON(w, http.StatusCreated, note)
+}
+
+func (s *server) getNote(w http.ResponseWriter, r *http.Request, noteID int64) {
+	note, err := s.repo.Get(r.Context(), noteID)
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, note)
+}
+
+func (s *server) updateNote(w http.ResponseWriter, r *http.Request, noteID int64) {
+	req, err := readNoteRequest(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// without any content, the repo keeps the current content and only changes the tags
+	_, err = s.repo.Update(r.Context(), &entities.Note{
+		ID:      noteID,
+		Content: req.Content,
+		Tags:    req.Tags,
+	}, &notes.UpdateOptions{RemoveTags: req.RemoveTags})
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	// fetched again for everything that GET gives
+	note, err := s.repo.Get(r.Context(), noteID)
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, note)
+}
+
+func (s *server) deleteNote(w http.ResponseWriter, r *http.Request, noteID int64) {
+	err := s.repo.Delete(r.Context(), noteID)
+	if err != nil {
+		writeRepoError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Serve runs the server on the listener until the context is done, giving requests still going the timeout to finish
+func Serve(ctx context.Context, httpServer *http.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+
+	err = <-serveErr
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
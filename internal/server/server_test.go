@@ -0,0 +1,362 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mock_clock "note-logger/internal/clock/mock"
+	"note-logger/internal/entities"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/repositories/notes"
+	mock_notes "note-logger/internal/repositories/notes/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const testToken string = "secret"
+
+type testSuite struct {
+	suite.Suite
+	ctrl      *gomock.Controller
+	mockRepo  *mock_notes.MockRepository
+	mockClock *mock_clock.MockClock
+	handler   http.Handler
+}
+
+func (s *testSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.mockRepo = mock_notes.NewMockRepository(s.ctrl)
+	s.mockClock = mock_clock.NewMockClock(s.ctrl)
+
+	handler, err := NewHandler(&Config{Repo: s.mockRepo, Token: testToken})
+	if err != nil {
+		s.T().Fatal(err)
+	}
+
+	s.handler = handler
+}
+
+func (s *testSuite) AfterTest(_, _ string) {
+	s.ctrl.Finish()
+}
+
+func (s *testSuite) request(method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	recorder := httptest.NewRecorder()
+	s.handler.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+var testNote = &entities.Note{
+	ID:        5,
+	Content:   "Fixed the build #work",
+	CreatedAt: time.Date(2022, time.April, 12, 16, 26, 19, 0, time.UTC),
+	Tags:      []string{"work"},
+}
+
+const testNoteJSON string = `{"id":5,"content":"Fixed the build #work","created_at":"2022-04-12T16:26:19Z","tags":["work"]}` +
+	"\n"
+
+func (s *testSuite) TestServer_Unauthorized() {
+	for _, header := range []string{"", "Bearer wrong", testToken} {
+		req := httptest.NewRequest(http.MethodGet, "/notes", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+
+		recorder := httptest.NewRecorder()
+		s.handler.ServeHTTP(recorder, req)
+
+		assert.Equal(s.T(), http.StatusUnauthorized, recorder.Code, header)
+		assert.Equal(s.T(), "Bearer", recorder.Header().Get("WWW-Authenticate"))
+		assert.Equal(s.T(), `{"error":"missing or invalid token"}`+"\n", recorder.Body.String())
+	}
+}
+
+func (s *testSuite) TestServer_OpenAPI() {
+	recorder := httptest.NewRecorder()
+	s.handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.yaml", nil))
+
+	assert.Equal(s.T(), http.StatusOK, recorder.Code)
+	assert.Equal(s.T(), OpenAPI, recorder.Body.Bytes())
+}
+
+func (s *testSuite) TestServer_CreateNote() {
+	s.mockRepo.EXPECT().Create(gomock.Any(), &entities.Note{
+		Content: "Fixed the build #work",
+		Tags:    []string{"work", "ci"},
+	}).Return(testNote, nil)
+
+	recorder := s.request(http.MethodPost, "/notes", `{"content":"Fixed the build #work","tags":["ci"]}`)
+
+	assert.Equal(s.T(), http.StatusCreated, recorder.Code)
+	assert.Equal(s.T(), "/notes/5", recorder.Header().Get("Location"))
+	assert.Equal(s.T(), testNoteJSON, recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_CreateNote_Invalid() {
+	for body, expected := range map[string]string{
+		`{"content":"  "}`:               "note content required",
+		`{"content":"x","tags":["a b"]}`: "invalid tag 'a b', tags can only contain letters, numbers, _, - and /",
+		`{"content":"x","colour":"red"}`: `invalid request body: json: unknown field "colour"`,
+		`{"content":`:                    "invalid request body: unexpected EOF",
+		`{"content":"x","tags":"not a list"}`: "invalid request body: json: cannot unmarshal string into Go struct field " +
+			"noteRequest.tags of type []string",
+	} {
+		recorder := s.request(http.MethodPost, "/notes", body)
+
+		assert.Equal(s.T(), http.StatusBadRequest, recorder.Code, body)
+		assert.Equal(s.T(), `{"error":`+quote(expected)+"}\n", recorder.Body.String(), body)
+	}
+}
+
+func quote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+func (s *testSuite) TestServer_ListNotes() {
+	s.mockRepo.EXPECT().List(gomock.Any(), &notes.ListOptions{
+		StartTime: time.Time{},
+		EndTime:   notes.MaxTime,
+		Filter:    &notes.TagFilter{Tags: []string{"work", "ci"}, MatchAll: true},
+		Limit:     101,
+	}).Return([]*entities.Note{testNote}, nil)
+
+	recorder := s.request(http.MethodGet, "/notes?tag=work&tag=ci&all_tags=true", "")
+
+	assert.Equal(s.T(), http.StatusOK, recorder.Code)
+	assert.Equal(s.T(), "["+strings.TrimSpace(testNoteJSON)+"]\n", recorder.Body.String())
+	assert.Empty(s.T(), recorder.Header().Get("Link"))
+}
+
+func (s *testSuite) TestServer_ListNotes_Pages() {
+	s.mockRepo.EXPECT().List(gomock.Any(), &notes.ListOptions{
+		EndTime: notes.MaxTime,
+		Filter:  &notes.TagFilter{},
+		Reverse: true,
+		AfterID: 9,
+		Limit:   3,
+	}).Return([]*entities.Note{{ID: 8}, {ID: 7}, {ID: 6}}, nil)
+
+	recorder := s.request(http.MethodGet, "/notes?limit=2&after_id=9&reverse=true", "")
+
+	assert.Equal(s.T(), http.StatusOK, recorder.Code)
+	assert.Contains(s.T(), recorder.Body.String(), `"id":7`)
+	assert.NotContains(s.T(), recorder.Body.String(), `"id":6`)
+	assert.Equal(s.T(), `</notes?after_id=7&limit=2&reverse=true>; rel="next"`, recorder.Header().Get("Link"))
+
+	for query, expected := range map[string]string{
+		"limit=0":        "invalid limit, it has to be from 1 to 1000",
+		"limit=1001":     "invalid limit, it has to be from 1 to 1000",
+		"after_id=first": "invalid after_id, it has to be a note ID",
+	} {
+		recorder = s.request(http.MethodGet, "/notes?"+query, "")
+
+		assert.Equal(s.T(), http.StatusBadRequest, recorder.Code, query)
+		assert.Equal(s.T(), `{"error":`+quote(expected)+"}\n", recorder.Body.String(), query)
+	}
+}
+
+func (s *testSuite) TestServer_ListNotes_NaturalDates() {
+	now := time.Date(2022, time.April, 12, 16, 0, 0, 0, time.UTC)
+
+	srv := &server{repo: s.mockRepo, token: testToken, clock: s.mockClock}
+
+	s.mockClock.EXPECT().Now().Return(now).Times(2)
+
+	s.mockRepo.EXPECT().List(gomock.Any(), &notes.ListOptions{
+		StartTime: now.Add(-10 * time.Minute),
+		EndTime:   now,
+		Filter:    &notes.TagFilter{},
+		Limit:     101,
+	}).Return([]*entities.Note{}, nil)
+
+	recorder := httptest.NewRecorder()
+	srv.listNotes(recorder, httptest.NewRequest(http.MethodGet, "/notes?start=10+minutes+ago&end=now", nil))
+
+	assert.Equal(s.T(), http.StatusOK, recorder.Code)
+	assert.Equal(s.T(), "[]\n", recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_GetNote() {
+	s.mockRepo.EXPECT().Get(gomock.Any(), int64(5)).Return(testNote, nil)
+
+	recorder := s.request(http.MethodGet, "/notes/5", "")
+
+	assert.Equal(s.T(), http.StatusOK, recorder.Code)
+	assert.Equal(s.T(), testNoteJSON, recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_GetNote_NotFound() {
+	s.mockRepo.EXPECT().Get(gomock.Any(), int64(6)).Return(nil, notes.ErrNoteNotFound)
+
+	recorder := s.request(http.MethodGet, "/notes/6", "")
+
+	assert.Equal(s.T(), http.StatusNotFound, recorder.Code)
+	assert.Equal(s.T(), `{"error":"note does not exist"}`+"\n", recorder.Body.String())
+
+	recorder = s.request(http.MethodGet, "/notes/abc", "")
+
+	assert.Equal(s.T(), http.StatusNotFound, recorder.Code)
+}
+
+func (s *testSuite) TestServer_UpdateNote() {
+	s.mockRepo.EXPECT().Update(gomock.Any(), &entities.Note{
+		ID:      5,
+		Content: "Fixed the build #work",
+	}, &notes.UpdateOptions{}).Return(&entities.Note{ID: 5, Content: "Fixed the build #work"}, nil)
+
+	s.mockRepo.EXPECT().Get(gomock.Any(), int64(5)).Return(testNote, nil)
+
+	recorder := s.request(http.MethodPatch, "/notes/5", `{"content":"Fixed the build #work"}`)
+
+	assert.Equal(s.T(), http.StatusOK, recorder.Code)
+	assert.Equal(s.T(), testNoteJSON, recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_UpdateNote_TagsOnly() {
+	s.mockRepo.EXPECT().Update(gomock.Any(), &entities.Note{
+		ID:   5,
+		Tags: []string{"ci"},
+	}, &notes.UpdateOptions{RemoveTags: []string{"work"}}).Return(testNote, nil)
+
+	s.mockRepo.EXPECT().Get(gomock.Any(), int64(5)).Return(testNote, nil)
+
+	recorder := s.request(http.MethodPatch, "/notes/5", `{"tags":["ci"],"remove_tags":["work"]}`)
+
+	assert.Equal(s.T(), http.StatusOK, recorder.Code)
+
+	recorder = s.request(http.MethodPatch, "/notes/5", `{"remove_tags":["not a tag"]}`)
+
+	assert.Equal(s.T(), http.StatusBadRequest, recorder.Code)
+
+	recorder = s.request(http.MethodPost, "/notes", `{"content":"Standup","remove_tags":["work"]}`)
+
+	assert.Equal(s.T(), http.StatusBadRequest, recorder.Code)
+	assert.Equal(s.T(), `{"error":"remove_tags can only be given when updating a note"}`+"\n", recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_DeleteNote() {
+	s.mockRepo.EXPECT().Delete(gomock.Any(), int64(5)).Return(nil)
+
+	recorder := s.request(http.MethodDelete, "/notes/5", "")
+
+	assert.Equal(s.T(), http.StatusNoContent, recorder.Code)
+	assert.Equal(s.T(), "", recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_StorageError() {
+	s.mockRepo.EXPECT().Delete(gomock.Any(), int64(5)).Return(errors.New("database is locked"))
+
+	recorder := s.request(http.MethodDelete, "/notes/5", "")
+
+	assert.Equal(s.T(), http.StatusInternalServerError, recorder.Code)
+	assert.Equal(s.T(), `{"error":"database is locked"}`+"\n", recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_ConflictError() {
+	s.mockRepo.EXPECT().Delete(gomock.Any(), int64(5)).Return(noteerrors.Conflict(errors.New("note is being edited")))
+
+	recorder := s.request(http.MethodDelete, "/notes/5", "")
+
+	assert.Equal(s.T(), http.StatusConflict, recorder.Code)
+	assert.Equal(s.T(), `{"error":"note is being edited"}`+"\n", recorder.Body.String())
+}
+
+func (s *testSuite) TestServer_MethodNotAllowed() {
+	recorder := s.request(http.MethodPut, "/notes/5", "")
+
+	assert.Equal(s.T(), http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(s.T(), "GET, PATCH, DELETE", recorder.Header().Get("Allow"))
+}
+
+func TestServer_UpdateNote_TagsOnlyKeepsHistory(t *testing.T) {
+	ctx := context.Background()
+	repo := notes.NewMemoryRepository(&notes.MemoryConfig{})
+
+	created, err := repo.Create(ctx, &entities.Note{Content: "Fixed the build #work"})
+	require.NoError(t, err)
+
+	handler, err := NewHandler(&Config{Repo: repo, Token: testToken})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPatch, "/notes/1", strings.NewReader(`{"tags":["ci"]}`))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"tags":["ci","work"]`)
+	assert.NotContains(t, recorder.Body.String(), `"updated_at"`)
+
+	revisions, err := repo.ListRevisions(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Fixed the build #work", revisions[0].Content)
+}
+
+func TestServe_GracefulShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	httpServer := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusNoContent)
+		}),
+		ReadHeaderTimeout: time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- Serve(ctx, httpServer, listener, 5*time.Second)
+	}()
+
+	responseCode := make(chan int, 1)
+
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responseCode <- 0
+			return
+		}
+
+		res.Body.Close()
+		responseCode <- res.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	// the request that's still going gets to finish before the server stops
+	close(release)
+
+	assert.Equal(t, http.StatusNoContent, <-responseCode)
+	assert.NoError(t, <-serveErr)
+
+	_, err = http.Get("http://" + listener.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestSuites(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
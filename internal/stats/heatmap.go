@@ -0,0 +1,82 @@
+package stats
+
+import (
+	"strings"
+)
+
+// the shades of the heatmap's days, from none for no notes, up to the most notes there are on a day
+var shades = []rune{'·', '░', '▒', '▓', '█'}
+
+// the weekdays that get labelled, the same ones GitHub labels
+var weekdayLabels = []string{"Mon", "", "Wed", "", "Fri", "", ""}
+
+const labelWidth int = 4
+
+// Heatmap draws the days as a calendar the way GitHub does, shaded by how many notes there are on each
+func Heatmap(stats *Stats) string {
+	if len(stats.Days) == 0 {
+		return ""
+	}
+
+	most := 0
+
+	for _, day := range stats.Days {
+		if day.Count > most {
+			most = day.Count
+		}
+	}
+
+	firstWeekday := (int(stats.Start.Weekday()) + 6) % 7
+	columns := (firstWeekday + len(stats.Days) + 6) / 7
+
+	months := []rune(strings.Repeat(" ", columns+labelWidth))
+	nextFree := 0
+
+	grid := make([][]rune, 7)
+	for row := range grid {
+		grid[row] = []rune(strings.Repeat(" ", columns))
+	}
+
+	for i, day := range stats.Days {
+		column := (firstWeekday + i) / 7
+		grid[(firstWeekday+i)%7][column] = shade(day.Count, most)
+
+		// each month is labelled at the week it starts in, if there's room to after the month before
+		if i == 0 || day.Start.Day() == 1 {
+			label := day.Start.Format("Jan")
+			at := column + labelWidth
+
+			if at >= nextFree && at+len(label) <= len(months) {
+				copy(months[at:], []rune(label))
+				nextFree = at + len(label) + 1
+			}
+		}
+	}
+
+	var heatmap strings.Builder
+
+	heatmap.WriteString(strings.TrimRight(string(months), " ") + "\n")
+
+	for row, days := range grid {
+		heatmap.WriteString(padLabel(weekdayLabels[row]) + strings.TrimRight(string(days), " ") + "\n")
+	}
+
+	heatmap.WriteString(padLabel("") + "Less " + string(shades) + " More\n")
+
+	return heatmap.String()
+}
+
+func shade(count int, most int) rune {
+	if count == 0 || most == 0 {
+		return shades[0]
+	}
+
+	levels := len(shades) - 1
+
+	// rounded up, or a day with a few notes wouldn't show
+	return shades[(count*levels+most-1)/most]
+}
+
+func padLabel(label string) string {
+	return label + strings.Repeat(" ", labelWidth-len(label))
+}
@@ -0,0 +1,164 @@
+// Package stats works out how often notes get written, and what about, along with a heatmap for the terminal.
+package stats
+
+import (
+	"time"
+)
+
+// the average number of days in a month, over the leap years too
+const daysPerMonth float64 = 365.25 / 12
+
+// Stats describes the notes written from Start to End
+type Stats struct {
+	Start         time.Time    `json:"start"`
+	End           time.Time    `json:"end"`
+	Total         int          `json:"total"`
+	ActiveDays    int          `json:"active_days"`
+	PerDay        float64      `json:"per_day"`
+	PerWeek       float64      `json:"per_week"`
+	PerMonth      float64      `json:"per_month"`
+	CurrentStreak Streak       `json:"current_streak"`
+	LongestStreak Streak       `json:"longest_streak"`
+	AverageLength float64      `json:"average_length"`
+	TopWords      []*WordCount `json:"top_words"`
+	Days          []*Count     `json:"days"`
+	Weeks         []*Count     `json:"weeks"`
+	Months        []*Count     `json:"months"`
+}
+
+// Count is how many notes were written in the day, week or month beginning at Start. Weeks begin on Mondays.
+type Count struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// Streak is a run of days in a row with notes on every one of them
+type Streak struct {
+	Days  int        `json:"days"`
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// Build works out the stats from the notes' times and words, in loc or their own offsets, from the first by default
+func Build(createdTimes []time.Time, start time.Time, end time.Time, loc *time.Location, words *Words,
+	top int) *Stats {
+	if loc != nil {
+		end = end.In(loc)
+	}
+
+	if start.IsZero() && len(createdTimes) > 0 {
+		start = createdTimes[0]
+	}
+
+	if start.IsZero() || start.After(end) {
+		start = end
+	}
+
+	dayLoc := end.Location()
+
+	byDay := make(map[string]int)
+
+	for _, createdAt := range createdTimes {
+		if loc != nil {
+			createdAt = createdAt.In(loc)
+		}
+
+		byDay[createdAt.Format("2006-01-02")]++
+	}
+
+	stats := &Stats{
+		Start:         dayOf(start.In(dayLoc)),
+		End:           end,
+		Total:         len(createdTimes),
+		AverageLength: words.AverageLength(),
+		TopWords:      words.Top(top),
+		Days:          make([]*Count, 0),
+		Weeks:         make([]*Count, 0),
+		Months:        make([]*Count, 0),
+	}
+
+	var streak Streak
+
+	for day := stats.Start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		count := byDay[day.Format("2006-01-02")]
+
+		stats.Days = append(stats.Days, &Count{Start: day, Count: count})
+		stats.addToWeek(day, count)
+		stats.addToMonth(day, count)
+
+		if count == 0 {
+			streak = Streak{}
+			continue
+		}
+
+		stats.ActiveDays++
+
+		streak = streak.extend(day)
+		if streak.Days > stats.LongestStreak.Days {
+			stats.LongestStreak = streak
+		}
+	}
+
+	stats.CurrentStreak = currentStreak(stats.Days)
+
+	if days := len(stats.Days); days > 0 {
+		stats.PerDay = float64(stats.Total) / float64(days)
+		stats.PerWeek = stats.PerDay * 7
+		stats.PerMonth = stats.PerDay * daysPerMonth
+	}
+
+	return stats
+}
+
+func (stats *Stats) addToWeek(day time.Time, count int) {
+	if len(stats.Weeks) == 0 || day.Weekday() == time.Monday {
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		stats.Weeks = append(stats.Weeks, &Count{Start: day.AddDate(0, 0, -daysSinceMonday)})
+	}
+
+	stats.Weeks[len(stats.Weeks)-1].Count += count
+}
+
+func (stats *Stats) addToMonth(day time.Time, count int) {
+	if len(stats.Months) == 0 || day.Day() == 1 {
+		stats.Months = append(stats.Months, &Count{Start: day.AddDate(0, 0, 1-day.Day())})
+	}
+
+	stats.Months[len(stats.Months)-1].Count += count
+}
+
+func (streak Streak) extend(day time.Time) Streak {
+	extended := Streak{Days: streak.Days + 1, Start: streak.Start, End: &day}
+	if extended.Start == nil {
+		extended.Start = &day
+	}
+
+	return extended
+}
+
+// currentStreak is the streak that runs up to the last day, or the day before while the last day has nothing yet
+func currentStreak(days []*Count) Streak {
+	last := len(days) - 1
+	if last >= 0 && days[last].Count == 0 {
+		last--
+	}
+
+	var streak Streak
+
+	for i := last; i >= 0 && days[i].Count > 0; i-- {
+		start := days[i].Start
+		streak.Start = &start
+		streak.Days++
+	}
+
+	if streak.Days > 0 {
+		end := days[last].Start
+		streak.End = &end
+	}
+
+	return streak
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
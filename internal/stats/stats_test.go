@@ -0,0 +1,131 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a Sunday afternoon, at the end of a four week window
+var (
+	end   = time.Date(2026, time.October, 18, 15, 30, 0, 0, time.UTC)
+	start = time.Date(2026, time.September, 21, 0, 0, 0, 0, time.UTC)
+)
+
+func day(month time.Month, d int, hour int) time.Time {
+	return time.Date(2026, month, d, hour, 0, 0, 0, time.UTC)
+}
+
+func date(month time.Month, d int) *time.Time {
+	t := day(month, d, 0)
+
+	return &t
+}
+
+var createdTimes = []time.Time{
+	day(time.September, 22, 9),
+	day(time.September, 23, 9),
+	day(time.September, 24, 9),
+	day(time.September, 24, 17),
+	day(time.September, 30, 9),
+	day(time.October, 1, 9),
+	day(time.October, 16, 9),
+	day(time.October, 17, 9),
+	day(time.October, 17, 10),
+}
+
+func TestBuild(t *testing.T) {
+	t.Run("counts notes by day, week and month", func(t *testing.T) {
+		stats := Build(createdTimes, start, end, nil, NewWords(), 10)
+
+		assert.Equal(t, start, stats.Start)
+		assert.Equal(t, 9, stats.Total)
+		assert.Equal(t, 7, stats.ActiveDays)
+		require.Len(t, stats.Days, 28)
+		assert.Equal(t, &Count{Start: start.AddDate(0, 0, 3), Count: 2}, stats.Days[3])
+
+		assert.Equal(t, []*Count{
+			{Start: start, Count: 4},
+			{Start: start.AddDate(0, 0, 7), Count: 2},
+			{Start: start.AddDate(0, 0, 14), Count: 0},
+			{Start: start.AddDate(0, 0, 21), Count: 3},
+		}, stats.Weeks)
+
+		assert.Equal(t, []*Count{
+			{Start: day(time.September, 1, 0), Count: 5},
+			{Start: day(time.October, 1, 0), Count: 4},
+		}, stats.Months)
+
+		assert.InDelta(t, 9.0/28, stats.PerDay, 0.0001)
+		assert.InDelta(t, 9.0/4, stats.PerWeek, 0.0001)
+		assert.InDelta(t, 9.0/28*30.4375, stats.PerMonth, 0.0001)
+	})
+
+	t.Run("finds the current and longest streaks", func(t *testing.T) {
+		stats := Build(createdTimes, start, end, nil, NewWords(), 10)
+
+		// nothing's been written on the last day yet, which doesn't break the streak
+		assert.Equal(t, Streak{Days: 2, Start: date(time.October, 16), End: date(time.October, 17)}, stats.CurrentStreak)
+		assert.Equal(t, Streak{Days: 3, Start: date(time.September, 22), End: date(time.September, 24)},
+			stats.LongestStreak)
+
+		stats = Build(createdTimes[:6], start, end, nil, NewWords(), 10)
+		assert.Equal(t, Streak{}, stats.CurrentStreak)
+	})
+
+	t.Run("works out the days in the zone given", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+
+		// 17:00 UTC is the next morning in Tokyo
+		stats := Build(createdTimes[2:4], day(time.September, 24, 0).In(tokyo), end, tokyo, NewWords(), 10)
+
+		assert.Equal(t, 1, stats.Days[0].Count)
+		assert.Equal(t, 1, stats.Days[1].Count)
+	})
+
+	t.Run("starts on the day of the first note without a start", func(t *testing.T) {
+		stats := Build(createdTimes[6:], time.Time{}, end, nil, NewWords(), 10)
+
+		assert.Equal(t, day(time.October, 16, 0), stats.Start)
+		assert.Len(t, stats.Days, 3)
+
+		stats = Build(nil, time.Time{}, end, nil, NewWords(), 10)
+		assert.Equal(t, 0, stats.Total)
+		assert.Len(t, stats.Days, 1)
+	})
+}
+
+func TestWords(t *testing.T) {
+	words := NewWords()
+	words.Add("Deployed the API #work")
+	words.Add("  Reviewed the deploy, then deployed it again at 10:30 ")
+	words.Add("API docs for the #api")
+
+	assert.InDelta(t, float64(22+52+21)/3, words.AverageLength(), 0.0001)
+	assert.Equal(t, []*WordCount{
+		{Word: "api", Count: 2},
+		{Word: "deployed", Count: 2},
+		{Word: "deploy", Count: 1},
+	}, words.Top(3))
+
+	assert.Empty(t, NewWords().Top(3))
+	assert.Equal(t, 0.0, NewWords().AverageLength())
+}
+
+func TestHeatmap(t *testing.T) {
+	stats := Build(createdTimes, start.AddDate(0, 0, 2), end, nil, NewWords(), 10)
+
+	assert.Equal(t, `    Sep
+Mon  ···
+     ···
+Wed ▒▒··
+    █▒··
+Fri ···▒
+    ···█
+    ····
+    Less ·░▒▓█ More
+`, Heatmap(stats))
+}
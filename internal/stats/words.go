@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the words too common to say anything about what the notes are about
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "that": true, "this": true, "with": true, "was": true, "are": true,
+	"but": true, "not": true, "you": true, "all": true, "can": true, "had": true, "her": true, "his": true,
+	"one": true, "our": true, "out": true, "has": true, "have": true, "from": true, "they": true, "will": true,
+	"would": true, "there": true, "their": true, "what": true, "about": true, "which": true, "when": true,
+	"were": true, "been": true, "into": true, "them": true, "then": true, "than": true, "some": true, "just": true,
+	"its": true, "it's": true, "also": true, "more": true, "need": true, "get": true, "got": true, "did": true,
+	"does": true, "don't": true, "didn't": true, "now": true, "too": true, "very": true, "how": true, "why": true,
+	"who": true, "after": true, "before": true, "over": true, "again": true, "still": true, "should": true,
+	"could": true, "these": true, "those": true, "because": true, "being": true, "like": true, "i'm": true,
+	"i've": true, "i'll": true, "we're": true, "let": true, "make": true, "made": true,
+}
+
+// the shortest word that gets counted, in characters
+const minWordLength int = 3
+
+// WordCount is how many times a word came up, across all the notes
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// Words counts the words in notes, other than tags, and how long the notes are, a note at a time
+type Words struct {
+	notes      int
+	characters int
+	counts     map[string]int
+}
+
+func NewWords() *Words {
+	return &Words{counts: make(map[string]int)}
+}
+
+// Add counts the words in a note's content
+func (words *Words) Add(content string) {
+	content = strings.TrimSpace(content)
+
+	words.notes++
+	words.characters += utf8.RuneCountInString(content)
+
+	fields := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '#' && r != '\'' && r != '_'
+	})
+
+	for _, field := range fields {
+		if strings.HasPrefix(field, "#") {
+			continue
+		}
+
+		word := strings.Trim(field, "'#_")
+
+		// numbers on their own, like times and IDs, aren't words
+		if utf8.RuneCountInString(word) < minWordLength || stopWords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+
+		words.counts[word]++
+	}
+}
+
+// AverageLength is the average number of characters in a note
+func (words *Words) AverageLength() float64 {
+	if words.notes == 0 {
+		return 0
+	}
+
+	return float64(words.characters) / float64(words.notes)
+}
+
+// Top gives the n words that came up most, with the words that came up as often as each other in alphabetical order
+func (words *Words) Top(n int) []*WordCount {
+	top := make([]*WordCount, 0, len(words.counts))
+
+	for word, count := range words.counts {
+		top = append(top, &WordCount{Word: word, Count: count})
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+
+		return top[i].Word < top[j].Word
+	})
+
+	if len(top) > n {
+		top = top[:n]
+	}
+
+	return top
+}
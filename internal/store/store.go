@@ -0,0 +1,222 @@
+package store
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"note-logger/internal/clock"
+	"note-logger/internal/config"
+	"note-logger/internal/databases/postgres"
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/noteerrors"
+	"note-logger/internal/repositories/notes"
+)
+
+// DSN says which backend keeps the notes, and where, like sqlite:///path/to/notes.sqlite or memory://
+type DSN struct {
+	Scheme string
+	// Path is unescaped, with a leading ~ expanded
+	Path  string
+	Query url.Values
+	Raw   string
+}
+
+func (dsn *DSN) String() string {
+	return dsn.Raw
+}
+
+// Opener opens a backend's repository for the DSN, with the clock that notes get their times from
+type Opener func(ctx context.Context, dsn *DSN, c clock.Clock) (notes.Repository, error)
+
+var (
+	openersMu sync.RWMutex
+	openers   = make(map[string]Opener)
+)
+
+// Register makes a backend available under the scheme, panicking if it's already taken
+func Register(scheme string, opener Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+
+	if opener == nil {
+		panic("store: Register opener is nil")
+	}
+
+	if _, taken := openers[scheme]; taken {
+		panic("store: Register called twice for scheme " + scheme)
+	}
+
+	openers[scheme] = opener
+}
+
+// Schemes gives the registered schemes in alphabetical order
+func Schemes() []string {
+	openersMu.RLock()
+	defer openersMu.RUnlock()
+
+	schemes := make([]string, 0, len(openers))
+	for scheme := range openers {
+		schemes = append(schemes, scheme)
+	}
+
+	sort.Strings(schemes)
+
+	return schemes
+}
+
+// pathEscaper escapes what Parse would otherwise take as the start of the options or as an escape
+var pathEscaper = strings.NewReplacer("%", "%25", "?", "%3F")
+
+// SQLiteDSN gives the DSN for the SQLite DB at filename, which can have any characters in it
+func SQLiteDSN(filename string) string {
+	return "sqlite://" + pathEscaper.Replace(filename)
+}
+
+// Parse splits a DSN into its scheme and path
+func Parse(raw string) (*DSN, error) {
+	separator := strings.Index(raw, "://")
+	if separator <= 0 {
+		return nil, noteerrors.InvalidInputf(
+			"invalid store '%v', it should look like scheme://path, with the scheme one of: %v",
+			raw, strings.Join(Schemes(), ", "))
+	}
+
+	path := raw[separator+len("://"):]
+
+	var query url.Values
+
+	if queryStart := strings.Index(path, "?"); queryStart >= 0 {
+		var err error
+
+		query, err = url.ParseQuery(path[queryStart+1:])
+		if err != nil {
+			return nil, noteerrors.InvalidInputf("invalid options in store '%v': %w", raw, err)
+		}
+
+		path = path[:queryStart]
+	}
+
+	path, err := url.PathUnescape(path)
+	if err != nil {
+		return nil, noteerrors.InvalidInputf("invalid path in store '%v': %w", raw, err)
+	}
+
+	path, err = config.ExpandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DSN{
+		Scheme: strings.ToLower(raw[:separator]),
+		Path:   path,
+		Query:  query,
+		Raw:    raw,
+	}, nil
+}
+
+// Open parses the DSN and opens the repository of the backend it's for, taking the times of notes from c
+func Open(ctx context.Context, raw string, c clock.Clock) (notes.Repository, error) {
+	dsn, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	openersMu.RLock()
+	opener, ok := openers[dsn.Scheme]
+	openersMu.RUnlock()
+
+	if !ok {
+		return nil, noteerrors.InvalidInputf("unknown store '%v', it should be one of: %v",
+			dsn.Scheme, strings.Join(Schemes(), ", "))
+	}
+
+	repo, err := opener(ctx, dsn, c)
+	if err != nil {
+		return nil, noteerrors.Storage(err)
+	}
+
+	return repo, nil
+}
+
+func init() {
+	Register("sqlite", openSQLite)
+	Register("memory", openMemory)
+	Register("jsonl", openJSONL)
+	Register("dir", openMarkdownDir)
+	Register("postgres", openPostgres)
+	Register("postgresql", openPostgres)
+}
+
+// SQLiteConfig gives the config for opening the DB of a sqlite store, with its busy_timeout option
+func SQLiteConfig(dsn *DSN) (*sqlite.Config, error) {
+	if dsn.Scheme != "sqlite" {
+		return nil, noteerrors.InvalidInputf("this only works with a SQLite DB, not the %v store", dsn.Scheme)
+	}
+
+	if dsn.Path == "" {
+		return nil, noteerrors.InvalidInputf(
+			"the sqlite store needs the path to the DB, like sqlite:///path/to/notes.sqlite")
+	}
+
+	cfg := &sqlite.Config{Filename: dsn.Path}
+
+	if busyTimeout := dsn.Query.Get("busy_timeout"); busyTimeout != "" {
+		var err error
+
+		cfg.BusyTimeout, err = time.ParseDuration(busyTimeout)
+		if err != nil || cfg.BusyTimeout <= 0 {
+			return nil, noteerrors.InvalidInputf("invalid busy_timeout '%v', it should be a duration like 10s", busyTimeout)
+		}
+	}
+
+	return cfg, nil
+}
+
+func openSQLite(ctx context.Context, dsn *DSN, c clock.Clock) (notes.Repository, error) {
+	cfg, err := SQLiteConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlite.New(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return notes.NewRepository(&notes.Config{DB: db, Clock: c})
+}
+
+// openPostgres hands the whole DSN to the driver, since it's a connection string in its own right
+func openPostgres(ctx context.Context, dsn *DSN, c clock.Clock) (notes.Repository, error) {
+	db, err := postgres.New(ctx, &postgres.Config{DSN: dsn.Raw})
+	if err != nil {
+		return nil, err
+	}
+
+	return notes.NewPostgresRepository(&notes.Config{DB: db, Clock: c})
+}
+
+func openMemory(_ context.Context, _ *DSN, c clock.Clock) (notes.Repository, error) {
+	return notes.NewMemoryRepository(&notes.MemoryConfig{Clock: c}), nil
+}
+
+func openJSONL(_ context.Context, dsn *DSN, c clock.Clock) (notes.Repository, error) {
+	if dsn.Path == "" {
+		return nil, noteerrors.InvalidInputf(
+			"the jsonl store needs the path to the file, like jsonl:///path/to/notes.jsonl")
+	}
+
+	return notes.NewJSONLRepository(&notes.JSONLConfig{Filename: dsn.Path, Clock: c})
+}
+
+func openMarkdownDir(_ context.Context, dsn *DSN, c clock.Clock) (notes.Repository, error) {
+	if dsn.Path == "" {
+		return nil, noteerrors.InvalidInputf("the dir store needs the path to the directory, like dir:///path/to/notes")
+	}
+
+	return notes.NewMarkdownDirRepository(&notes.MarkdownDirConfig{Dir: dsn.Path, Clock: c})
+}
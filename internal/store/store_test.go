@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"note-logger/internal/clock"
+	"note-logger/internal/databases/sqlite"
+	"note-logger/internal/entities"
+	"note-logger/internal/repositories/notes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Parse(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	tests := []struct {
+		raw  string
+		want *DSN
+	}{
+		{"sqlite:///some/notes.sqlite", &DSN{Scheme: "sqlite", Path: "/some/notes.sqlite", Raw: "sqlite:///some/notes.sqlite"}},
+		{"memory://", &DSN{Scheme: "memory", Path: "", Raw: "memory://"}},
+		{"JSONL://notes.jsonl", &DSN{Scheme: "jsonl", Path: "notes.jsonl", Raw: "JSONL://notes.jsonl"}},
+		{"dir://~/notes", &DSN{Scheme: "dir", Path: filepath.Join(home, "notes"), Raw: "dir://~/notes"}},
+		{"sqlite:///some/notes.sqlite?busy_timeout=10s", &DSN{
+			Scheme: "sqlite",
+			Path:   "/some/notes.sqlite",
+			Query:  url.Values{"busy_timeout": []string{"10s"}},
+			Raw:    "sqlite:///some/notes.sqlite?busy_timeout=10s",
+		}},
+		{"sqlite:///some/what%3F 100%25.sqlite", &DSN{
+			Scheme: "sqlite",
+			Path:   "/some/what? 100%.sqlite",
+			Raw:    "sqlite:///some/what%3F 100%25.sqlite",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			dsn, err := Parse(tt.raw)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, dsn)
+		})
+	}
+
+	dsn, err := Parse(SQLiteDSN("/some/what? 100%.sqlite"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/some/what? 100%.sqlite", dsn.Path)
+	assert.Empty(t, dsn.Query)
+
+	_, err = Parse("sqlite:///some/100%.sqlite")
+	assert.EqualError(t, err, `invalid path in store 'sqlite:///some/100%.sqlite': invalid URL escape "%.s"`)
+
+	_, err = Parse("/some/notes.sqlite")
+	assert.EqualError(t, err,
+		"invalid store '/some/notes.sqlite', it should look like scheme://path, with the scheme one of: dir, jsonl, "+
+			"memory, postgres, postgresql, sqlite")
+}
+
+func TestStore_SQLiteConfig(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    *sqlite.Config
+		wantErr string
+	}{
+		{raw: "sqlite:///some/notes.sqlite", want: &sqlite.Config{Filename: "/some/notes.sqlite"}},
+		{
+			raw:  "sqlite:///some/notes.sqlite?busy_timeout=10s",
+			want: &sqlite.Config{Filename: "/some/notes.sqlite", BusyTimeout: 10 * time.Second},
+		},
+		{raw: "sqlite:///some/notes.sqlite?busy_timeout=soon", wantErr: "invalid busy_timeout 'soon', it should be a duration like 10s"},
+		{raw: "sqlite://", wantErr: "the sqlite store needs the path to the DB, like sqlite:///path/to/notes.sqlite"},
+		{raw: "jsonl:///some/notes.jsonl", wantErr: "this only works with a SQLite DB, not the jsonl store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			dsn, err := Parse(tt.raw)
+			require.NoError(t, err)
+
+			cfg, err := SQLiteConfig(dsn)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cfg)
+		})
+	}
+}
+
+func TestStore_Open(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	createdAt := time.Date(2026, time.March, 14, 9, 30, 0, 0, time.UTC)
+	c := clock.NewFake(createdAt, 0)
+
+	t.Run("unknown scheme", func(t *testing.T) {
+		_, err := Open(ctx, "mongodb://localhost", c)
+		assert.EqualError(t, err, "unknown store 'mongodb', it should be one of: dir, jsonl, memory, postgres, postgresql, sqlite")
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		_, err := Open(ctx, "jsonl://", c)
+		assert.EqualError(t, err, "the jsonl store needs the path to the file, like jsonl:///path/to/notes.jsonl")
+	})
+
+	for _, raw := range []string{
+		"sqlite://" + filepath.Join(dir, "notes.sqlite"),
+		"jsonl://" + filepath.Join(dir, "notes.jsonl"),
+		"dir://" + filepath.Join(dir, "notes"),
+	} {
+		t.Run(raw, func(t *testing.T) {
+			repo, err := Open(ctx, raw, c)
+			require.NoError(t, err)
+
+			created, err := repo.Create(ctx, &entities.Note{Content: "Kept"})
+			require.NoError(t, err)
+
+			reopened, err := Open(ctx, raw, c)
+			require.NoError(t, err)
+
+			note, err := reopened.Get(ctx, created.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "Kept", note.Content)
+			assert.True(t, createdAt.Equal(note.CreatedAt), note.CreatedAt)
+		})
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		repo, err := Open(ctx, "memory://", c)
+		require.NoError(t, err)
+
+		_, err = repo.Get(ctx, 1)
+		assert.ErrorIs(t, err, notes.ErrNoteNotFound)
+	})
+}
+
+func TestStore_Register(t *testing.T) {
+	assert.Panics(t, func() {
+		Register("memory", openMemory)
+	})
+}
@@ -0,0 +1,79 @@
+package tags
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"note-logger/internal/noteerrors"
+)
+
+// a #hashtag has to start the content or follow whitespace, leaving out URL fragments and the like
+var hashtagRegex = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_\-/]*)`)
+
+var validTagRegex = regexp.MustCompile(`^[\p{L}\p{N}_][\p{L}\p{N}_\-/]*$`)
+
+// Extract finds all the #hashtags in some note content.
+func Extract(content string) []string {
+	found := make([]string, 0)
+
+	for _, match := range hashtagRegex.FindAllStringSubmatch(content, -1) {
+		found = append(found, strings.TrimRight(match[1], "-/"))
+	}
+
+	return found
+}
+
+// Normalize lower cases the tags, drops any leading #, and removes duplicates. The result is sorted.
+func Normalize(rawTags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(rawTags))
+
+	for _, rawTag := range rawTags {
+		tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(rawTag), "#"))
+
+		if !validTagRegex.MatchString(tag) {
+			return nil, noteerrors.InvalidInputf(
+				"invalid tag '%v', tags can only contain letters, numbers, _, - and /", rawTag)
+		}
+
+		if seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+// AppendMissing adds any of the tags that aren't already in the content onto the end of it, as #hashtags.
+func AppendMissing(content string, noteTags []string) string {
+	for _, tag := range Missing(content, noteTags) {
+		content += " #" + tag
+	}
+
+	return strings.TrimSpace(content)
+}
+
+// Missing returns the tags that don't already show up as #hashtags in the content.
+func Missing(content string, noteTags []string) []string {
+	inContent := make(map[string]bool)
+
+	for _, tag := range Extract(content) {
+		inContent[strings.ToLower(tag)] = true
+	}
+
+	missing := make([]string, 0)
+
+	for _, tag := range noteTags {
+		if !inContent[tag] {
+			missing = append(missing, tag)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,50 @@
+package tags
+
+import (
+	"errors"
+	"testing"
+
+	"note-logger/internal/noteerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTags_Extract(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected []string
+	}{
+		{content: "no tags here", expected: []string{}},
+		{content: "#standup went long", expected: []string{"standup"}},
+		{content: "fixed the build #work #ci-pipeline", expected: []string{"work", "ci-pipeline"}},
+		{content: "see issue#12 and https://example.com/#anchor", expected: []string{}},
+		{content: "trailing punctuation #done- and #Projects/Notes", expected: []string{"done", "Projects/Notes"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Extract(test.content), test.content)
+	}
+}
+
+func TestTags_Normalize(t *testing.T) {
+	t.Run("lower cases, dedupes and sorts", func(t *testing.T) {
+		normalized, err := Normalize([]string{"Work", "#urgent", " work ", "ci"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"ci", "urgent", "work"}, normalized)
+	})
+
+	t.Run("rejects invalid tags", func(t *testing.T) {
+		_, err := Normalize([]string{"work", "not valid"})
+		assert.EqualError(t, err, "invalid tag 'not valid', tags can only contain letters, numbers, _, - and /")
+		assert.True(t, errors.Is(err, noteerrors.ErrInvalidInput))
+	})
+}
+
+func TestTags_AppendMissing(t *testing.T) {
+	assert.Equal(t, "fixed the build #Work #ci", AppendMissing("fixed the build #Work", []string{"ci", "work"}))
+}
+
+func TestTags_Missing(t *testing.T) {
+	missing := Missing("fixed the build #Work", []string{"ci", "work"})
+	assert.Equal(t, []string{"ci"}, missing)
+}
@@ -0,0 +1,298 @@
+// Package timerange turns the ways people say when something happened, like "last tuesday", into times and windows.
+package timerange
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"note-logger/internal/noteerrors"
+
+	"github.com/tj/go-naturaldate"
+)
+
+// Range is a window of time, where both ends are included
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// the layouts of exact times and dates, tried before anything else
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "january": time.January,
+	"feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March,
+	"apr": time.April, "april": time.April,
+	"may": time.May,
+	"jun": time.June, "june": time.June,
+	"jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November,
+	"dec": time.December, "december": time.December,
+}
+
+var (
+	boundaryRegex   = regexp.MustCompile(`^(beginning|start|end) of (.+)$`)
+	monthRegex      = regexp.MustCompile(`^([a-z]+)(?: (\d{4}))?$`)
+	monthDayRegex   = regexp.MustCompile(`^([a-z]+) (\d{1,2})(?:st|nd|rd|th)?(?:,? (\d{4}))?$`)
+	dayMonthRegex   = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)? ([a-z]+)(?: (\d{4}))?$`)
+	isoMonthRegex   = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+	isoDayRegex     = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	yearRegex       = regexp.MustCompile(`^(\d{4})$`)
+	lastRegex       = regexp.MustCompile(`^(\d+) ?([a-z]+)$`)
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+)
+
+// Parse reads an expression for a day, week, month or year, like "last tuesday", "last week", "march 2026" or "2025".
+// A month without a year is the last one that has started.
+func Parse(expr string, now time.Time) (Range, error) {
+	expr = normalize(expr)
+
+	if r, ok, err := parsePeriod(expr, now); ok {
+		return r, err
+	}
+
+	t, err := parseNatural(expr, now)
+	if err != nil {
+		return Range{}, err
+	}
+
+	return Day(t), nil
+}
+
+// ParseTime reads an expression for a point in time, like "10 minutes ago", where a period is the time it begins
+func ParseTime(expr string, now time.Time) (time.Time, error) {
+	expr = normalize(expr)
+
+	if expr == "now" {
+		return now, nil
+	}
+
+	if matches := boundaryRegex.FindStringSubmatch(expr); matches != nil {
+		r, err := Parse(matches[2], now)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if matches[1] == "end" {
+			return r.End, nil
+		}
+
+		return r.Start, nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, strings.ToUpper(expr), now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if r, ok, err := parsePeriod(expr, now); ok {
+		return r.Start, err
+	}
+
+	return parseNatural(expr, now)
+}
+
+// Last is the window from the given amount of time ago until now, like "3d", "2 weeks" or "1mo"
+func Last(spec string, now time.Time) (Range, error) {
+	matches := lastRegex.FindStringSubmatch(normalize(spec))
+	if matches == nil {
+		return Range{}, noteerrors.InvalidInputf("invalid amount of time '%v', it should be like 3d or 2w", spec)
+	}
+
+	n, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return Range{}, noteerrors.InvalidInput(err)
+	}
+
+	var start time.Time
+
+	switch strings.TrimSuffix(matches[2], "s") {
+	case "m", "min", "minute":
+		start = now.Add(-time.Duration(n) * time.Minute)
+	case "h", "hr", "hour":
+		start = now.Add(-time.Duration(n) * time.Hour)
+	case "d", "day":
+		start = now.AddDate(0, 0, -n)
+	case "w", "wk", "week":
+		start = now.AddDate(0, 0, -7*n)
+	case "mo", "month":
+		start = now.AddDate(0, -n, 0)
+	case "y", "yr", "year":
+		start = now.AddDate(-n, 0, 0)
+	default:
+		return Range{}, noteerrors.InvalidInputf("unknown unit of time '%v', use m, h, d, w, mo or y", matches[2])
+	}
+
+	return Range{Start: start, End: now}, nil
+}
+
+// Today is the window from the start of today until now
+func Today(now time.Time) Range {
+	return Range{Start: Day(now).Start, End: now}
+}
+
+// Yesterday is the whole of the day before now
+func Yesterday(now time.Time) Range {
+	return Day(now.AddDate(0, 0, -1))
+}
+
+// ThisWeek is the window from the start of the week, on Monday, until now
+func ThisWeek(now time.Time) Range {
+	return Range{Start: Week(now).Start, End: now}
+}
+
+// ThisMonth is the window from the start of the month until now
+func ThisMonth(now time.Time) Range {
+	return Range{Start: Month(now).Start, End: now}
+}
+
+// Day is the whole of the day t is in
+func Day(t time.Time) Range {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	return Range{Start: start, End: start.AddDate(0, 0, 1).Add(-time.Nanosecond)}
+}
+
+// Week is the whole of the week t is in, from Monday to Sunday
+func Week(t time.Time) Range {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	start := Day(t.AddDate(0, 0, -daysSinceMonday)).Start
+
+	return Range{Start: start, End: start.AddDate(0, 0, 7).Add(-time.Nanosecond)}
+}
+
+// Month is the whole of the month t is in
+func Month(t time.Time) Range {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+
+	return Range{Start: start, End: start.AddDate(0, 1, 0).Add(-time.Nanosecond)}
+}
+
+// Year is the whole of the year t is in
+func Year(t time.Time) Range {
+	start := time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+
+	return Range{Start: start, End: start.AddDate(1, 0, 0).Add(-time.Nanosecond)}
+}
+
+func normalize(expr string) string {
+	return whitespaceRegex.ReplaceAllString(strings.ToLower(strings.TrimSpace(expr)), " ")
+}
+
+// parsePeriod reads the periods that naturaldate doesn't understand or gets wrong, not ok for anything else
+func parsePeriod(expr string, now time.Time) (Range, bool, error) {
+	switch expr {
+	case "today", "day":
+		return Day(now), true, nil
+	case "yesterday":
+		return Yesterday(now), true, nil
+	case "tomorrow":
+		return Day(now.AddDate(0, 0, 1)), true, nil
+	case "week", "this week":
+		return Week(now), true, nil
+	case "last week":
+		return Week(now.AddDate(0, 0, -7)), true, nil
+	case "month", "this month":
+		return Month(now), true, nil
+	case "last month":
+		return Month(Month(now).Start.AddDate(0, -1, 0)), true, nil
+	case "year", "this year":
+		return Year(now), true, nil
+	case "last year":
+		return Year(Year(now).Start.AddDate(-1, 0, 0)), true, nil
+	}
+
+	if matches := isoDayRegex.FindStringSubmatch(expr); matches != nil {
+		return isoDate(expr, now, matches[1], matches[2], matches[3])
+	}
+
+	if matches := isoMonthRegex.FindStringSubmatch(expr); matches != nil {
+		return isoDate(expr, now, matches[1], matches[2], "")
+	}
+
+	if matches := yearRegex.FindStringSubmatch(expr); matches != nil {
+		year, _ := strconv.Atoi(matches[1])
+
+		return Year(time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location())), true, nil
+	}
+
+	if matches := monthRegex.FindStringSubmatch(expr); matches != nil {
+		if month, ok := months[matches[1]]; ok {
+			return Month(monthStart(now, month, matches[2])), true, nil
+		}
+	}
+
+	if matches := monthDayRegex.FindStringSubmatch(expr); matches != nil {
+		if month, ok := months[matches[1]]; ok {
+			return dayInMonth(expr, now, month, matches[2], matches[3])
+		}
+	}
+
+	if matches := dayMonthRegex.FindStringSubmatch(expr); matches != nil {
+		if month, ok := months[matches[2]]; ok {
+			return dayInMonth(expr, now, month, matches[1], matches[3])
+		}
+	}
+
+	return Range{}, false, nil
+}
+
+// monthStart is the start of the month in the given year, or the last one that has started when there's no year
+func monthStart(now time.Time, month time.Month, year string) time.Time {
+	y := now.Year()
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+	} else if month > now.Month() {
+		y--
+	}
+
+	return time.Date(y, month, 1, 0, 0, 0, 0, now.Location())
+}
+
+func dayInMonth(expr string, now time.Time, month time.Month, day string, year string) (Range, bool, error) {
+	start := monthStart(now, month, year)
+
+	d, _ := strconv.Atoi(day)
+	if d < 1 || d > Month(start).End.Day() {
+		return Range{}, true, noteerrors.InvalidInputf("there's no such date as '%v'", expr)
+	}
+
+	return Day(start.AddDate(0, 0, d-1)), true, nil
+}
+
+// isoDate reads a date like 2026-03-14, or a month like 2026-03
+func isoDate(expr string, now time.Time, year string, month string, day string) (Range, bool, error) {
+	m, _ := strconv.Atoi(month)
+	if m < 1 || m > 12 {
+		return Range{}, true, noteerrors.InvalidInputf("there's no such date as '%v'", expr)
+	}
+
+	if day == "" {
+		return Month(monthStart(now, time.Month(m), year)), true, nil
+	}
+
+	return dayInMonth(expr, now, time.Month(m), day, year)
+}
+
+// parseNatural parses with naturaldate, which gives back the time it was given for anything it doesn't understand
+func parseNatural(expr string, now time.Time) (time.Time, error) {
+	t, err := naturaldate.Parse(expr, now)
+	if err != nil || t.IsZero() || (t.Equal(now) && expr != "now") {
+		return time.Time{}, noteerrors.InvalidInputf("can't make sense of the time '%v'", expr)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,128 @@
+package timerange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"note-logger/internal/noteerrors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// a Sunday afternoon
+var now = time.Date(2026, time.October, 18, 15, 30, 0, 0, time.UTC)
+
+func at(year int, month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func endOf(start time.Time, years int, months int, days int) time.Time {
+	return start.AddDate(years, months, days).Add(-time.Nanosecond)
+}
+
+func TestParse(t *testing.T) {
+	for expr, expected := range map[string]Range{
+		"today":            {Start: at(2026, 10, 18, 0, 0), End: endOf(at(2026, 10, 18, 0, 0), 0, 0, 1)},
+		"Yesterday":        {Start: at(2026, 10, 17, 0, 0), End: endOf(at(2026, 10, 17, 0, 0), 0, 0, 1)},
+		"last tuesday":     {Start: at(2026, 10, 13, 0, 0), End: endOf(at(2026, 10, 13, 0, 0), 0, 0, 1)},
+		"3 days ago":       {Start: at(2026, 10, 15, 0, 0), End: endOf(at(2026, 10, 15, 0, 0), 0, 0, 1)},
+		"2026-03-14":       {Start: at(2026, 3, 14, 0, 0), End: endOf(at(2026, 3, 14, 0, 0), 0, 0, 1)},
+		"march 14":         {Start: at(2026, 3, 14, 0, 0), End: endOf(at(2026, 3, 14, 0, 0), 0, 0, 1)},
+		"14th March 2025":  {Start: at(2025, 3, 14, 0, 0), End: endOf(at(2025, 3, 14, 0, 0), 0, 0, 1)},
+		"this week":        {Start: at(2026, 10, 12, 0, 0), End: endOf(at(2026, 10, 12, 0, 0), 0, 0, 7)},
+		"last week":        {Start: at(2026, 10, 5, 0, 0), End: endOf(at(2026, 10, 5, 0, 0), 0, 0, 7)},
+		"march 2026":       {Start: at(2026, 3, 1, 0, 0), End: endOf(at(2026, 3, 1, 0, 0), 0, 1, 0)},
+		"  March   2026 ":  {Start: at(2026, 3, 1, 0, 0), End: endOf(at(2026, 3, 1, 0, 0), 0, 1, 0)},
+		"2026-02":          {Start: at(2026, 2, 1, 0, 0), End: endOf(at(2026, 2, 1, 0, 0), 0, 1, 0)},
+		"december":         {Start: at(2025, 12, 1, 0, 0), End: endOf(at(2025, 12, 1, 0, 0), 0, 1, 0)},
+		"this month":       {Start: at(2026, 10, 1, 0, 0), End: endOf(at(2026, 10, 1, 0, 0), 0, 1, 0)},
+		"last month":       {Start: at(2026, 9, 1, 0, 0), End: endOf(at(2026, 9, 1, 0, 0), 0, 1, 0)},
+		"2025":             {Start: at(2025, 1, 1, 0, 0), End: endOf(at(2025, 1, 1, 0, 0), 1, 0, 0)},
+		"last year":        {Start: at(2025, 1, 1, 0, 0), End: endOf(at(2025, 1, 1, 0, 0), 1, 0, 0)},
+		"10 minutes ago":   {Start: at(2026, 10, 18, 0, 0), End: endOf(at(2026, 10, 18, 0, 0), 0, 0, 1)},
+		"last wednesday ":  {Start: at(2026, 10, 14, 0, 0), End: endOf(at(2026, 10, 14, 0, 0), 0, 0, 1)},
+		"tomorrow":         {Start: at(2026, 10, 19, 0, 0), End: endOf(at(2026, 10, 19, 0, 0), 0, 0, 1)},
+		"february 29 2028": {Start: at(2028, 2, 29, 0, 0), End: endOf(at(2028, 2, 29, 0, 0), 0, 0, 1)},
+	} {
+		t.Run(expr, func(t *testing.T) {
+			actual, err := Parse(expr, now)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"whenever", "february 30", "2026-13", "2026-03-32", ""} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := Parse(expr, now)
+			assert.True(t, errors.Is(err, noteerrors.ErrInvalidInput), err)
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	for expr, expected := range map[string]time.Time{
+		"now":                       now,
+		"10 minutes ago":            at(2026, 10, 18, 15, 20),
+		"beginning of today":        at(2026, 10, 18, 0, 0),
+		"start of week":             at(2026, 10, 12, 0, 0),
+		"beginning of month":        at(2026, 10, 1, 0, 0),
+		"end of march 2026":         endOf(at(2026, 3, 1, 0, 0), 0, 1, 0),
+		"yesterday":                 at(2026, 10, 17, 0, 0),
+		"march 2026":                at(2026, 3, 1, 0, 0),
+		"2026-03-14":                at(2026, 3, 14, 0, 0),
+		"2026-03-14 09:30":          at(2026, 3, 14, 9, 30),
+		"2026-03-14T09:30:00Z":      at(2026, 3, 14, 9, 30),
+		"2026-03-14t11:30:00+02:00": at(2026, 3, 14, 9, 30),
+	} {
+		t.Run(expr, func(t *testing.T) {
+			actual, err := ParseTime(expr, now)
+			require.NoError(t, err)
+			assert.True(t, expected.Equal(actual), actual)
+		})
+	}
+
+	_, err := ParseTime("beginning of whenever", now)
+	assert.EqualError(t, err, "can't make sense of the time 'whenever'")
+
+	_, err = ParseTime("february 30", now)
+	assert.EqualError(t, err, "there's no such date as 'february 30'")
+}
+
+func TestLast(t *testing.T) {
+	for spec, expected := range map[string]time.Time{
+		"30m":    at(2026, 10, 18, 15, 0),
+		"2h":     at(2026, 10, 18, 13, 30),
+		"3d":     at(2026, 10, 15, 15, 30),
+		"3 days": at(2026, 10, 15, 15, 30),
+		"2w":     at(2026, 10, 4, 15, 30),
+		"1mo":    at(2026, 9, 18, 15, 30),
+		"1 year": at(2025, 10, 18, 15, 30),
+	} {
+		t.Run(spec, func(t *testing.T) {
+			actual, err := Last(spec, now)
+			require.NoError(t, err)
+			assert.Equal(t, Range{Start: expected, End: now}, actual)
+		})
+	}
+
+	_, err := Last("3 fortnights", now)
+	assert.EqualError(t, err, "unknown unit of time 'fortnights', use m, h, d, w, mo or y")
+
+	_, err = Last("d3", now)
+	assert.EqualError(t, err, "invalid amount of time 'd3', it should be like 3d or 2w")
+}
+
+func TestShortcuts(t *testing.T) {
+	assert.Equal(t, Range{Start: at(2026, 10, 18, 0, 0), End: now}, Today(now))
+	assert.Equal(t, Range{Start: at(2026, 10, 17, 0, 0), End: endOf(at(2026, 10, 17, 0, 0), 0, 0, 1)}, Yesterday(now))
+	assert.Equal(t, Range{Start: at(2026, 10, 12, 0, 0), End: now}, ThisWeek(now))
+	assert.Equal(t, Range{Start: at(2026, 10, 1, 0, 0), End: now}, ThisMonth(now))
+
+	// Monday is the start of its own week
+	monday := at(2026, 10, 12, 9, 0)
+	assert.Equal(t, at(2026, 10, 12, 0, 0), Week(monday).Start)
+}
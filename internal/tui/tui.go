@@ -0,0 +1,625 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"note-logger/internal/editor"
+	"note-logger/internal/entities"
+	"note-logger/internal/export"
+	"note-logger/internal/repositories/notes"
+	"note-logger/internal/tags"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+// detailMinWidth is the narrowest terminal that still shows the detail pane next to the timeline
+const detailMinWidth int = 60
+
+// the size to lay out for until the terminal reports its real size
+const (
+	defaultWidth  int = 80
+	defaultHeight int = 24
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	dayStyle      = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	timeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	detailStyle   = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderLeft(true).PaddingLeft(1)
+	labelStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+)
+
+const help string = "j/k move • / filter • a add • e edit • d delete • r reload • tab details • q quit"
+
+// EditorFunc creates the command that edits a file, which the UI hands the terminal over to until it exits
+type EditorFunc func(filename string) *exec.Cmd
+
+type Config struct {
+	Repo   notes.Repository
+	Editor EditorFunc
+}
+
+// Run shows the UI until the user quits
+func Run(ctx context.Context, cfg *Config) error {
+	m, err := New(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	_, err = tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+
+	return err
+}
+
+// Model is the state of the UI, with every note loaded up front
+type Model struct {
+	ctx    context.Context
+	repo   notes.Repository
+	editor EditorFunc
+
+	// all the notes, newest first, and the ones that match the filter
+	all     []*entities.Note
+	visible []*entities.Note
+
+	cursor     int
+	offset     int
+	filter     textinput.Model
+	filtering  bool
+	showDetail bool
+	deleting   bool
+	status     string
+	err        error
+	width      int
+	height     int
+	loaded     bool
+	quitting   bool
+}
+
+func New(ctx context.Context, cfg *Config) (*Model, error) {
+	if cfg.Repo == nil {
+		return nil, errors.New("missing Repo parameter")
+	}
+
+	editorFunc := cfg.Editor
+	if editorFunc == nil {
+		editorFunc = editor.Command
+	}
+
+	filter := textinput.New()
+	filter.Prompt = "/ "
+	filter.Placeholder = "filter, e.g. standup #work"
+
+	return &Model{
+		ctx:        ctx,
+		repo:       cfg.Repo,
+		editor:     editorFunc,
+		filter:     filter,
+		showDetail: true,
+		width:      defaultWidth,
+		height:     defaultHeight,
+	}, nil
+}
+
+type notesLoadedMsg struct {
+	notes []*entities.Note
+	err   error
+}
+
+// editorDoneMsg comes back once the editor exits, with the note being edited, or nil when adding a new one
+type editorDoneMsg struct {
+	note     *entities.Note
+	filename string
+	original string
+	err      error
+}
+
+// changedMsg comes back after a note was added, updated or deleted, with what to tell the user
+type changedMsg struct {
+	status string
+	err    error
+}
+
+func (m *Model) Init() tea.Cmd {
+	return m.load
+}
+
+func (m *Model) load() tea.Msg {
+	loaded := make([]*entities.Note, 0)
+
+	err := m.repo.ForEach(m.ctx, time.Time{}, notes.MaxTime, nil, func(note *entities.Note) error {
+		loaded = append(loaded, note)
+		return nil
+	})
+
+	// newest first, so the latest notes are at the top
+	for i, j := 0, len(loaded)-1; i < j; i, j = i+1, j-1 {
+		loaded[i], loaded[j] = loaded[j], loaded[i]
+	}
+
+	return notesLoadedMsg{notes: loaded, err: err}
+}
+
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+
+		return m, nil
+	case notesLoadedMsg:
+		m.loaded = true
+
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.setNotes(msg.notes)
+
+		return m, nil
+	case editorDoneMsg:
+		return m, m.save(msg)
+	case changedMsg:
+		m.status, m.err = msg.status, msg.err
+
+		return m, m.load
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m *Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		m.quitting = true
+		return m, tea.Quit
+	}
+
+	if m.deleting {
+		m.deleting = false
+
+		note := m.selected()
+		if msg.String() != "y" || note == nil {
+			m.status = "Delete cancelled."
+			return m, nil
+		}
+
+		return m, m.delete(note)
+	}
+
+	if m.filtering {
+		switch msg.Type {
+		case tea.KeyEsc:
+			m.filter.SetValue("")
+			m.stopFiltering()
+		case tea.KeyEnter:
+			m.stopFiltering()
+		case tea.KeyUp:
+			m.move(-1)
+		case tea.KeyDown:
+			m.move(1)
+		default:
+			var cmd tea.Cmd
+
+			m.filter, cmd = m.filter.Update(msg)
+			m.cursor = 0
+			m.applyFilter()
+
+			return m, cmd
+		}
+
+		return m, nil
+	}
+
+	m.status, m.err = "", nil
+
+	switch msg.String() {
+	case "q":
+		m.quitting = true
+		return m, tea.Quit
+	case "j", "down":
+		m.move(1)
+	case "k", "up":
+		m.move(-1)
+	case "ctrl+d", "pgdown":
+		m.move(m.bodyHeight() / 2)
+	case "ctrl+u", "pgup":
+		m.move(-m.bodyHeight() / 2)
+	case "g", "home":
+		m.move(-len(m.visible))
+	case "G", "end":
+		m.move(len(m.visible))
+	case "/":
+		m.filtering = true
+		return m, m.filter.Focus()
+	case "esc":
+		m.filter.SetValue("")
+		m.applyFilter()
+	case "tab":
+		m.showDetail = !m.showDetail
+	case "r":
+		return m, m.load
+	case "a":
+		return m, m.edit(nil)
+	case "e", "enter":
+		if note := m.selected(); note != nil {
+			return m, m.edit(note)
+		}
+	case "d":
+		if note := m.selected(); note != nil {
+			m.deleting = true
+			m.status = fmt.Sprintf("Delete note %v? (y/n)", note.ID)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Model) stopFiltering() {
+	m.filtering = false
+	m.filter.Blur()
+	m.applyFilter()
+}
+
+// setNotes replaces the notes, keeping the same note selected if it's still there
+func (m *Model) setNotes(loaded []*entities.Note) {
+	var selectedID int64
+	if note := m.selected(); note != nil {
+		selectedID = note.ID
+	}
+
+	m.all = loaded
+	m.applyFilter()
+
+	for i, note := range m.visible {
+		if note.ID == selectedID {
+			m.cursor = i
+		}
+	}
+
+	m.scroll()
+}
+
+// applyFilter narrows the notes down to the ones matching every word in the filter, where #words match tags too
+func (m *Model) applyFilter() {
+	words := strings.Fields(strings.ToLower(m.filter.Value()))
+
+	m.visible = make([]*entities.Note, 0, len(m.all))
+
+	for _, note := range m.all {
+		if matches(note, words) {
+			m.visible = append(m.visible, note)
+		}
+	}
+
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+
+	m.scroll()
+}
+
+func matches(note *entities.Note, words []string) bool {
+	content := strings.ToLower(note.Content)
+
+	for _, word := range words {
+		if strings.Contains(content, word) {
+			continue
+		}
+
+		tagMatch := false
+
+		if strings.HasPrefix(word, "#") {
+			for _, tag := range note.Tags {
+				if strings.HasPrefix(tag, strings.TrimPrefix(word, "#")) {
+					tagMatch = true
+				}
+			}
+		}
+
+		if !tagMatch {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m *Model) selected() *entities.Note {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return nil
+	}
+
+	return m.visible[m.cursor]
+}
+
+func (m *Model) move(by int) {
+	m.cursor += by
+
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+
+	m.scroll()
+}
+
+// bodyHeight is the number of lines for the timeline, after the header and footer
+func (m *Model) bodyHeight() int {
+	height := m.height - 3
+	if height < 1 {
+		height = 1
+	}
+
+	return height
+}
+
+// scroll moves the timeline to show the selected note, along with its day heading where possible
+func (m *Model) scroll() {
+	lines := m.timelineLines(m.timelineWidth())
+
+	selectedLine := 0
+
+	for i, line := range lines {
+		if line.noteIndex == m.cursor {
+			selectedLine = i
+		}
+	}
+
+	if selectedLine > 0 && lines[selectedLine-1].noteIndex == dayLine && selectedLine-1 < m.offset {
+		m.offset = selectedLine - 1
+	}
+
+	if selectedLine < m.offset {
+		m.offset = selectedLine
+	}
+
+	if selectedLine >= m.offset+m.bodyHeight() {
+		m.offset = selectedLine - m.bodyHeight() + 1
+	}
+
+	if m.offset < 0 {
+		m.offset = 0
+	}
+}
+
+func (m *Model) timelineWidth() int {
+	if !m.detailShowing() {
+		return m.width
+	}
+
+	return m.width * 3 / 5
+}
+
+func (m *Model) detailShowing() bool {
+	return m.showDetail && m.width >= detailMinWidth
+}
+
+type timelineLine struct {
+	text string
+
+	// the index of the note in the visible notes, unless it's a day heading or the blank line before one
+	noteIndex int
+}
+
+const (
+	dayLine   int = -1
+	blankLine int = -2
+)
+
+func (m *Model) timelineLines(width int) []timelineLine {
+	lines := make([]timelineLine, 0, len(m.visible))
+
+	currentDay := ""
+
+	for i, note := range m.visible {
+		if day := note.CreatedAt.Format(export.DayLayout); day != currentDay {
+			currentDay = day
+
+			if len(lines) > 0 {
+				lines = append(lines, timelineLine{noteIndex: blankLine})
+			}
+
+			lines = append(lines, timelineLine{text: dayStyle.Render(truncate.String(day, uint(width))), noteIndex: dayLine})
+		}
+
+		// only the first line of each note fits in the timeline, the rest is in the detail pane
+		firstLine, _, _ := strings.Cut(note.Content, "\n")
+		firstLine = tags.AppendMissing(firstLine, tags.Missing(note.Content, note.Tags))
+		noteTime := note.CreatedAt.Format("15:04")
+		content := truncate.StringWithTail(firstLine, uint(maxInt(width-len(noteTime)-4, 0)), "…")
+
+		text := timeStyle.Render(noteTime) + " " + content
+		if i == m.cursor {
+			text = selectedStyle.Render(noteTime + " " + content)
+		}
+
+		lines = append(lines, timelineLine{text: "  " + text, noteIndex: i})
+	}
+
+	return lines
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func (m *Model) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	header := titleStyle.Render("Notes") + fmt.Sprintf(" %v/%v  ", len(m.visible), len(m.all))
+	if m.filtering || m.filter.Value() != "" {
+		header += m.filter.View()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		truncate.String(header, uint(m.width)),
+		"",
+		m.body(),
+		m.footer(),
+	)
+}
+
+func (m *Model) body() string {
+	height := m.bodyHeight()
+	width := m.timelineWidth()
+
+	rows := make([]string, 0, height)
+
+	switch {
+	case !m.loaded:
+		rows = append(rows, "Loading notes...")
+	case len(m.all) == 0:
+		rows = append(rows, "No notes yet, press a to add one.")
+	case len(m.visible) == 0:
+		rows = append(rows, "No notes match the filter.")
+	}
+
+	lines := m.timelineLines(width)
+
+	for i := m.offset; i < len(lines) && len(rows) < height; i++ {
+		rows = append(rows, lines[i].text)
+	}
+
+	timeline := lipgloss.NewStyle().Width(width).Height(height).MaxHeight(height).Render(strings.Join(rows, "\n"))
+
+	if !m.detailShowing() {
+		return timeline
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, timeline, m.detail(m.width-width-2, height))
+}
+
+func (m *Model) detail(width int, height int) string {
+	style := detailStyle.Copy().Width(width).Height(height).MaxHeight(height)
+
+	note := m.selected()
+	if note == nil {
+		return style.Render("")
+	}
+
+	rows := []string{
+		titleStyle.Render(fmt.Sprintf("Note %v", note.ID)),
+		labelStyle.Render("Created ") + note.CreatedAt.Format("Mon, 02 Jan 2006 15:04:05"),
+	}
+
+	if note.UpdatedAt != nil {
+		rows = append(rows, labelStyle.Render("Updated ")+note.UpdatedAt.Format("Mon, 02 Jan 2006 15:04:05"))
+	}
+
+	if len(note.Tags) > 0 {
+		rows = append(rows, labelStyle.Render("Tags    ")+"#"+strings.Join(note.Tags, " #"))
+	}
+
+	rows = append(rows, "", note.Content)
+
+	return style.Render(strings.Join(rows, "\n"))
+}
+
+func (m *Model) footer() string {
+	switch {
+	case m.err != nil:
+		return errorStyle.Render(truncate.String("Error: "+m.err.Error(), uint(m.width)))
+	case m.status != "":
+		return truncate.String(m.status, uint(m.width))
+	}
+
+	return helpStyle.Render(truncate.String(help, uint(m.width)))
+}
+
+// edit hands the terminal over to the editor on a temp file with the note's content, or nothing for a new note
+func (m *Model) edit(note *entities.Note) tea.Cmd {
+	original := ""
+	if note != nil {
+		original = note.Content
+	}
+
+	filename, err := editor.Prepare(original)
+	if err != nil {
+		return func() tea.Msg { return changedMsg{err: err} }
+	}
+
+	return tea.ExecProcess(m.editor(filename), func(err error) tea.Msg {
+		return editorDoneMsg{note: note, filename: filename, original: original, err: err}
+	})
+}
+
+// save adds or updates the note from what was left in the editor, as long as it isn't empty or unchanged
+func (m *Model) save(msg editorDoneMsg) tea.Cmd {
+	return func() tea.Msg {
+		//nolint
+		defer os.Remove(msg.filename)
+
+		if msg.err != nil {
+			return changedMsg{err: msg.err}
+		}
+
+		content, err := editor.Read(msg.filename, msg.original)
+		if errors.Is(err, editor.ErrEmpty) || errors.Is(err, editor.ErrUnchanged) {
+			return changedMsg{status: "Nothing changed."}
+		}
+
+		if err != nil {
+			return changedMsg{err: err}
+		}
+
+		if msg.note == nil {
+			note, err := m.repo.Create(m.ctx, &entities.Note{
+				Content: content,
+				Tags:    tags.Extract(content),
+			})
+			if err != nil {
+				return changedMsg{err: err}
+			}
+
+			return changedMsg{status: fmt.Sprintf("Note %v added.", note.ID)}
+		}
+
+		_, err = m.repo.Update(m.ctx, &entities.Note{
+			ID:      msg.note.ID,
+			Content: content,
+		}, nil)
+		if err != nil {
+			return changedMsg{err: err}
+		}
+
+		return changedMsg{status: fmt.Sprintf("Note %v updated.", msg.note.ID)}
+	}
+}
+
+func (m *Model) delete(note *entities.Note) tea.Cmd {
+	return func() tea.Msg {
+		err := m.repo.Delete(m.ctx, note.ID)
+		if err != nil {
+			return changedMsg{err: err}
+		}
+
+		return changedMsg{status: fmt.Sprintf("Note %v moved to the trash.", note.ID)}
+	}
+}
@@ -0,0 +1,270 @@
+package tui
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"testing"
+	"time"
+
+	"note-logger/internal/editor"
+	"note-logger/internal/entities"
+	"note-logger/internal/repositories/notes"
+	mock_notes "note-logger/internal/repositories/notes/mock"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type testSuite struct {
+	suite.Suite
+	ctrl     *gomock.Controller
+	ctx      context.Context
+	mockRepo *mock_notes.MockRepository
+	model    *Model
+}
+
+var testNotes = []*entities.Note{
+	{
+		ID:        1,
+		Content:   "Standup with the team",
+		CreatedAt: time.Date(2022, time.April, 11, 9, 30, 0, 0, time.UTC),
+	},
+	{
+		ID:        2,
+		Content:   "Fixed the build\nIt was the cache again",
+		CreatedAt: time.Date(2022, time.April, 12, 14, 5, 0, 0, time.UTC),
+		Tags:      []string{"work"},
+	},
+	{
+		ID:        3,
+		Content:   "Lunch",
+		CreatedAt: time.Date(2022, time.April, 12, 16, 26, 0, 0, time.UTC),
+	},
+}
+
+func (s *testSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+	s.ctx = context.Background()
+	s.mockRepo = mock_notes.NewMockRepository(s.ctrl)
+
+	model, err := New(s.ctx, &Config{
+		Repo: s.mockRepo,
+		Editor: func(filename string) *exec.Cmd {
+			return exec.Command("true", filename)
+		},
+	})
+	require.NoError(s.T(), err)
+
+	s.model = model
+}
+
+func (s *testSuite) AfterTest(_, _ string) {
+	s.ctrl.Finish()
+}
+
+// expectLoad returns a copy of the notes each time, since the UI keeps hold of them
+func (s *testSuite) expectLoad(loaded []*entities.Note) {
+	s.mockRepo.EXPECT().ForEach(s.ctx, time.Time{}, notes.MaxTime, nil, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ time.Time, _ time.Time, _ *notes.TagFilter,
+			fn func(note *entities.Note) error) error {
+			for _, note := range loaded {
+				copied := *note
+
+				err := fn(&copied)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+}
+
+// run feeds the message to the model, along with whatever messages its commands produce in turn, apart from the
+// cursor blinking in the filter box
+func (s *testSuite) run(msg tea.Msg) {
+	for msg != nil {
+		_, cmd := s.model.Update(msg)
+		if cmd == nil {
+			return
+		}
+
+		switch msg.(type) {
+		case tea.KeyMsg:
+			if s.model.filtering {
+				return
+			}
+		}
+
+		msg = cmd()
+	}
+}
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// view is the UI without any styling
+func (s *testSuite) view() string {
+	return ansiRegex.ReplaceAllString(s.model.View(), "")
+}
+
+func (s *testSuite) load() {
+	s.expectLoad(testNotes)
+	s.run(s.model.Init()())
+	s.run(tea.WindowSizeMsg{Width: 100, Height: 20})
+}
+
+func key(value string) tea.KeyMsg {
+	switch value {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(value)}
+}
+
+func (s *testSuite) TestTUI_Timeline() {
+	s.load()
+
+	view := s.view()
+
+	assert.Contains(s.T(), view, "Notes 3/3")
+	assert.Regexp(s.T(), `(?s)Tuesday, 2022-04-12.*16:26 Lunch.*14:05 Fixed the build #work.*Monday, 2022-04-11.*09:30 Standup`,
+		view)
+	assert.NotContains(s.T(), view, "14:05 Fixed the build\nIt was")
+
+	// the detail pane shows the newest note to start with
+	assert.Contains(s.T(), view, "Note 3")
+	assert.Equal(s.T(), int64(3), s.model.selected().ID)
+
+	s.run(key("j"))
+
+	view = s.view()
+	assert.Contains(s.T(), view, "Note 2")
+	assert.Contains(s.T(), view, "It was the cache again")
+	assert.Contains(s.T(), view, "#work")
+
+	s.run(key("G"))
+	assert.Equal(s.T(), int64(1), s.model.selected().ID)
+
+	s.run(key("tab"))
+	assert.NotContains(s.T(), s.view(), "Note 1")
+}
+
+func (s *testSuite) TestTUI_Filter() {
+	s.load()
+
+	s.run(key("/"))
+
+	for _, r := range "#wo" {
+		s.run(key(string(r)))
+	}
+
+	assert.Contains(s.T(), s.view(), "Notes 1/3")
+	assert.Equal(s.T(), int64(2), s.model.selected().ID)
+
+	s.run(key("enter"))
+	assert.False(s.T(), s.model.filtering)
+	assert.Equal(s.T(), 1, len(s.model.visible))
+
+	s.run(key("esc"))
+	assert.Equal(s.T(), 3, len(s.model.visible))
+
+	s.run(key("/"))
+
+	for _, r := range "nothing matches" {
+		s.run(key(string(r)))
+	}
+
+	assert.Contains(s.T(), s.view(), "No notes match the filter.")
+	assert.Nil(s.T(), s.model.selected())
+}
+
+func (s *testSuite) TestTUI_Delete() {
+	s.load()
+
+	s.run(key("d"))
+	assert.Contains(s.T(), s.view(), "Delete note 3? (y/n)")
+
+	s.run(key("n"))
+	assert.Contains(s.T(), s.view(), "Delete cancelled.")
+
+	s.mockRepo.EXPECT().Delete(s.ctx, int64(3)).Return(nil)
+	s.expectLoad(testNotes[:2])
+
+	s.run(key("d"))
+	s.run(key("y"))
+
+	assert.Contains(s.T(), s.view(), "Note 3 moved to the trash.")
+	assert.Equal(s.T(), 2, len(s.model.visible))
+}
+
+// editorDone writes what the editor would have left in the file, above the template
+func (s *testSuite) editorDone(note *entities.Note, original string, edited string) editorDoneMsg {
+	filename, err := editor.Prepare(edited)
+	require.NoError(s.T(), err)
+
+	return editorDoneMsg{note: note, filename: filename, original: original}
+}
+
+func (s *testSuite) TestTUI_Add() {
+	s.load()
+
+	s.mockRepo.EXPECT().Create(s.ctx, &entities.Note{
+		Content: "New note #idea",
+		Tags:    []string{"idea"},
+	}).Return(&entities.Note{ID: 4}, nil)
+	s.expectLoad(testNotes)
+
+	msg := s.editorDone(nil, "", "\nNew note #idea  \n\n")
+	s.run(msg)
+
+	assert.Contains(s.T(), s.view(), "Note 4 added.")
+	assert.NoFileExists(s.T(), msg.filename)
+}
+
+func (s *testSuite) TestTUI_Edit() {
+	s.load()
+
+	s.mockRepo.EXPECT().Update(s.ctx, &entities.Note{
+		ID:      3,
+		Content: "Lunch with Sam",
+	}, nil).Return(&entities.Note{ID: 3}, nil)
+	s.expectLoad(testNotes)
+
+	s.run(s.editorDone(testNotes[2], "Lunch", "Lunch with Sam\n"))
+
+	assert.Contains(s.T(), s.view(), "Note 3 updated.")
+}
+
+func (s *testSuite) TestTUI_EditUnchanged() {
+	s.load()
+
+	s.expectLoad(testNotes)
+
+	s.run(s.editorDone(testNotes[2], "Lunch", "Lunch\n"))
+	assert.Contains(s.T(), s.view(), "Nothing changed.")
+
+	s.expectLoad(testNotes)
+
+	s.run(s.editorDone(nil, "", "   \n"))
+	assert.Contains(s.T(), s.view(), "Nothing changed.")
+}
+
+func (s *testSuite) TestTUI_Quit() {
+	s.load()
+
+	_, cmd := s.model.Update(key("q"))
+
+	assert.Equal(s.T(), tea.Quit(), cmd())
+	assert.Equal(s.T(), "", s.model.View())
+}
+
+func TestSuites(t *testing.T) {
+	suite.Run(t, new(testSuite))
+}
@@ -0,0 +1,31 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// DataHome returns $XDG_DATA_HOME, falling back to ~/.local/share as described by the XDG Base Directory spec.
+func DataHome() (string, error) {
+	return baseDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// ConfigHome returns $XDG_CONFIG_HOME, falling back to ~/.config as described by the XDG Base Directory spec.
+func ConfigHome() (string, error) {
+	return baseDir("XDG_CONFIG_HOME", ".config")
+}
+
+func baseDir(envVar string, fallback ...string) (string, error) {
+	// the spec says relative paths are invalid, and should be ignored
+	dir := os.Getenv(envVar)
+	if dir != "" && filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(append([]string{home}, fallback...)...), nil
+}
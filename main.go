@@ -6,8 +6,5 @@ import (
 )
 
 func main() {
-	err := cmd.Execute()
-	if err != nil {
-		os.Exit(1)
-	}
+	os.Exit(cmd.Execute())
 }